@@ -1,19 +1,31 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/adrock-miles/go-laserbeak/internal/application"
 	"github.com/adrock-miles/go-laserbeak/internal/config"
 	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/conversation"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/ratelimit"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/usage"
+	"github.com/adrock-miles/go-laserbeak/internal/infrastructure/admin"
 	"github.com/adrock-miles/go-laserbeak/internal/infrastructure/discord"
 	"github.com/adrock-miles/go-laserbeak/internal/infrastructure/llm"
+	"github.com/adrock-miles/go-laserbeak/internal/infrastructure/metrics"
 	"github.com/adrock-miles/go-laserbeak/internal/infrastructure/persistence"
 	"github.com/adrock-miles/go-laserbeak/internal/infrastructure/playoptions"
+	"github.com/adrock-miles/go-laserbeak/internal/infrastructure/soundboard"
+	"github.com/adrock-miles/go-laserbeak/internal/logging"
 	"github.com/spf13/cobra"
 )
 
@@ -33,9 +45,44 @@ func runServe(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("load config: %w", err)
 	}
 
+	logger, err := logging.New(os.Stderr, logging.Options{
+		Level:             cfg.Log.Level,
+		Format:            cfg.Log.Format,
+		RedactTranscripts: cfg.Log.RedactTranscripts,
+		Secrets:           cfg.Secrets(),
+	})
+	if err != nil {
+		return fmt.Errorf("configure logging: %w", err)
+	}
+	slog.SetDefault(logger) // also routes the log package, used by discordgo
+
 	// Infrastructure
+	registry := metrics.NewRegistry()
+	instruments := metrics.NewInstruments(registry)
+
 	convRepo := persistence.NewInMemoryConversationRepo()
-	llmClient := llm.NewOpenAIClient(cfg.LLM.APIKey, cfg.LLM.BaseURL, cfg.LLM.Model)
+
+	usageLedger, err := persistence.NewFileUsageLedger(cfg.Usage.File)
+	if err != nil {
+		return fmt.Errorf("load usage: %w", err)
+	}
+	defer usageLedger.Close()
+	usageService, err := application.NewUsageService(usageLedger, usagePrices(cfg.Usage), usageQuotas(cfg.Usage), cfg.Bot.Location)
+	if err != nil {
+		return fmt.Errorf("load usage: %w", err)
+	}
+
+	playLog, err := persistence.NewFilePlayLog(cfg.PlayOptions.StatsFile)
+	if err != nil {
+		return fmt.Errorf("load play stats: %w", err)
+	}
+	defer playLog.Close()
+	playStats, err := application.NewPlayStatsService(playLog)
+	if err != nil {
+		return fmt.Errorf("load play stats: %w", err)
+	}
+
+	llmClient := newLLMService(cfg.LLM, usageService, instruments)
 
 	// Application services
 	chatService := application.NewChatService(
@@ -43,15 +90,41 @@ func runServe(cmd *cobra.Command, args []string) error {
 		llmClient,
 		cfg.Bot.SystemPrompt,
 		cfg.Bot.MaxHistory,
+		conversation.ContextPolicy{
+			MaxTokens:           cfg.Bot.ContextTokens,
+			ReservedReplyTokens: cfg.LLM.MaxTokens,
+			SummarizeAfter:      cfg.Bot.SummarizeAfter,
+			SummaryKeep:         cfg.Bot.SummaryKeep,
+		},
 	)
 
+	chatService.SetUsage(usageService)
+
+	personaRepo, err := persistence.NewFilePersonaRepo(cfg.Bot.PersonaFile)
+	if err != nil {
+		return fmt.Errorf("load personas: %w", err)
+	}
+	personaService := application.NewPersonaService(personaRepo, cfg.Bot.SystemPrompt, cfg.Bot.Personas)
+	chatService.SetPersonas(personaService)
+
 	// Discord bot
 	botCfg := discord.BotConfig{
-		Token:          cfg.Discord.Token,
-		CommandPrefix:  cfg.Discord.CommandPrefix,
-		GuildID:        cfg.Discord.GuildID,
-		VoiceChannelID: cfg.Discord.VoiceChannelID,
-		TextChannelID:  cfg.Discord.TextChannelID,
+		Token:            cfg.Discord.Token,
+		CommandPrefix:    cfg.Discord.CommandPrefix,
+		GuildID:          cfg.Discord.GuildID,
+		VoiceChannelID:   cfg.Discord.VoiceChannelID,
+		TextChannelID:    cfg.Discord.TextChannelID,
+		Threads:          cfg.Discord.Threads,
+		ThreadArchive:    cfg.Discord.ThreadArchive,
+		ReplyDepth:       cfg.Discord.ReplyDepth,
+		MaxImages:        cfg.Discord.MaxImages,
+		MaxImageMB:       cfg.Discord.MaxImageMB,
+		MaxTextKB:        cfg.Discord.MaxTextKB,
+		TextTokens:       cfg.Discord.TextTokens,
+		MaxReplyChunks:   cfg.Discord.MaxReplyChunks,
+		VoiceMessages:    cfg.Discord.VoiceMessages,
+		VoiceMessageChat: cfg.Discord.VoiceMessageChat,
+		OptionsRoles:     cfg.Discord.OptionsRoles,
 	}
 
 	discordBot, err := discord.NewBot(botCfg)
@@ -60,34 +133,102 @@ func runServe(cmd *cobra.Command, args []string) error {
 	}
 
 	discordBot.SetChatHandler(chatService.HandleMessage)
+	discordBot.SetChatQueue(func(channelID string) discord.ChatHandler { return chatService.Reserve(channelID) })
+	discordBot.SetPersonaManager(personaService)
+	discordBot.SetUsageReporter(usageService)
+	discordBot.SetPlayStats(playStats)
+	discordBot.SetMetrics(instruments)
 
-	// Set up voice service if STT API key is provided
-	if cfg.STT.APIKey != "" {
-		sttClient := llm.NewSTTClient(cfg.STT.APIKey, cfg.STT.BaseURL, cfg.STT.Model)
+	if cfg.RateLimit.Enabled {
+		discordBot.SetRateLimiter(newRateLimiter(cfg.RateLimit))
+		slog.Info("rate limiting enabled")
+	}
+
+	// Build play options sources (local file + optional API)
+	var playOptsSources []playoptions.Source
+	var playOptsStore bot.PlayOptionsStore // where the options command adds options
+	if cfg.PlayOptions.File != "" {
+		playOptsFile := playoptions.NewFileSource(cfg.PlayOptions.File)
+		playOptsFile.Start()
+		defer playOptsFile.Stop()
+		playOptsStore = playOptsFile
+		registry.GaugeFunc("laserbeak_play_options", "Play options loaded, by source.",
+			func() float64 { return float64(playOptsFile.Len()) }, "source", "file")
+		playOptsSources = append(playOptsSources, playoptions.Source{
+			Name:     "file",
+			Priority: cfg.PlayOptions.FilePriority,
+			Timeout:  cfg.PlayOptions.SourceTimeout,
+			Service:  playOptsFile,
+		})
+	}
+
+	// Sound files play without being listed, below every other source.
+	var sounds *soundboard.Library
+	if cfg.Soundboard.Enabled {
+		sounds = soundboard.NewLibrary(cfg.Soundboard.Dir)
+		playOptsSources = append(playOptsSources, playoptions.Source{
+			Name:     "sounds",
+			Priority: math.MinInt,
+			Timeout:  cfg.PlayOptions.SourceTimeout,
+			Service:  sounds,
+		})
+	}
 
-		// Build play options sources (local file + optional API)
-		var playOptsSources []bot.PlayOptionsService
-		playOptsSources = append(playOptsSources, playoptions.NewFileSource("play_options.json"))
-
-		var playOptsClient *playoptions.Client
-		if cfg.PlayOptions.APIURL != "" {
-			playOptsClient = playoptions.NewClient(cfg.PlayOptions.APIURL, cfg.PlayOptions.CacheTTL)
-			playOptsClient.Start()
-			playOptsSources = append(playOptsSources, playOptsClient)
-			log.Printf("Play options matching enabled (API: %s, cache TTL: %s)",
-				cfg.PlayOptions.APIURL, cfg.PlayOptions.CacheTTL)
+	if cfg.PlayOptions.APIURL != "" {
+		playOptsClient, err := newPlayOptionsClient(cfg.PlayOptions)
+		if err != nil {
+			return err
 		}
+		playOptsClient.Start()
+		defer playOptsClient.Stop()
+		registry.GaugeFunc("laserbeak_play_options", "Play options loaded, by source.",
+			func() float64 { return float64(playOptsClient.Len()) }, "source", "api")
+		registry.GaugeFunc("laserbeak_play_options_cache_age_seconds",
+			"Time since the play options API was last fetched or revalidated; +Inf before the first fetch.",
+			func() float64 {
+				age, ok := playOptsClient.CacheAge()
+				if !ok {
+					return math.Inf(1)
+				}
+				return age.Seconds()
+			})
+		playOptsSources = append(playOptsSources, playoptions.Source{
+			Name:     "api",
+			Priority: cfg.PlayOptions.APIPriority,
+			Timeout:  cfg.PlayOptions.SourceTimeout,
+			Service:  playOptsClient,
+		})
+		slog.Info("play options API enabled", "url", cfg.PlayOptions.APIURL, "cache_ttl", cfg.PlayOptions.CacheTTL)
+	}
+
+	playOpts := playoptions.NewComposite(playOptsSources...)
+	if sounds != nil {
+		sounds.SetPlayOptions(playOpts)
+		discordBot.SetSoundboard(sounds, cfg.Soundboard.MaxQueue)
+		slog.Info("soundboard mode enabled", "dir", cfg.Soundboard.Dir)
+	}
+	discordBot.SetOptionsManager(application.NewPlayOptionsAdmin(playOpts, playOptsStore))
+	chatService.SetPromptRenderer(application.NewPromptRenderer(Version, cfg.Bot.Location, playOpts))
+
+	if cfg.Bot.Tools {
+		tools := application.NewBuiltinTools(playOpts, discordBot, playStats, cfg.Bot.Location)
+		chatService.SetTools(tools)
+		slog.Info("chat tools enabled", "tools", tools.Len())
+	}
 
-		playOpts := playoptions.NewComposite(playOptsSources...)
+	// Set up voice service if STT API key is provided
+	if cfg.STT.APIKey != "" {
+		sttClient := llm.NewSTTClient(cfg.STT.APIKey, cfg.STT.BaseURL, cfg.STT.Model)
+		sttClient.SetUsageRecorder(usageService)
+		sttClient.SetRequestObserver(instruments)
 		voiceService := application.NewVoiceService(sttClient, cfg.Bot.WakePhrase, llmClient, playOpts)
+		voiceService.SetUsage(usageService)
+		voiceService.SetPlayStats(playStats)
 		discordBot.SetVoiceHandler(voiceService.HandleVoice)
-		log.Printf("Voice commands enabled (wake phrase: %q)", cfg.Bot.WakePhrase)
-
-		if playOptsClient != nil {
-			defer playOptsClient.Stop()
-		}
+		discordBot.SetVoiceMessageHandler(voiceService.HandleVoiceMessage)
+		slog.Info("voice commands enabled", "wake_phrase", cfg.Bot.WakePhrase)
 	} else {
-		log.Println("Voice commands disabled (no STT API key configured)")
+		slog.Info("voice commands disabled: no STT API key configured")
 	}
 
 	if err := discordBot.Start(); err != nil {
@@ -95,17 +236,126 @@ func runServe(cmd *cobra.Command, args []string) error {
 	}
 	defer discordBot.Stop()
 
-	log.Printf("Laserbeak is running. Command prefix: %s", cfg.Discord.CommandPrefix)
-	if cfg.Discord.TextChannelID != "" {
-		log.Printf("Voice command output channel: %s", cfg.Discord.TextChannelID)
+	if cfg.Admin.Enabled {
+		adminServer := admin.NewServer(cfg.Admin.Addr, registry,
+			admin.Check{Name: "discord", Check: discordBot.Ready},
+			admin.Check{Name: "play options", Check: playOpts.Ready},
+		)
+		if err := adminServer.Start(); err != nil {
+			return fmt.Errorf("start admin server: %w", err)
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			adminServer.Stop(ctx)
+		}()
+		slog.Info("admin server listening", "addr", adminServer.Addr())
 	}
-	log.Println("Press Ctrl+C to exit")
+
+	slog.Info("laserbeak is running, press Ctrl+C to exit",
+		"version", Version, "prefix", cfg.Discord.CommandPrefix, "output_channel", cfg.Discord.TextChannelID)
 
 	// Wait for shutdown signal
 	sc := make(chan os.Signal, 1)
 	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM)
 	<-sc
 
-	log.Println("Shutting down...")
+	slog.Info("shutting down")
 	return nil
 }
+
+// newLLMService builds the fallback chain of configured LLM providers, each
+// reporting its token usage to recorder and its requests to observer.
+func newLLMService(cfg config.LLMConfig, recorder usage.Recorder, observer llm.RequestObserver) bot.LLMService {
+	providers := make([]llm.Provider, len(cfg.Providers))
+	names := make([]string, len(cfg.Providers))
+	for i, p := range cfg.Providers {
+		var service bot.LLMService
+		switch p.Provider {
+		case config.LLMProviderAnthropic:
+			client := llm.NewAnthropicClient(p.APIKey, p.BaseURL, p.Model, p.MaxTokens)
+			client.SetUsageRecorder(recorder)
+			client.SetRequestObserver(observer)
+			service = client
+		default:
+			client := llm.NewOpenAIClient(p.APIKey, p.BaseURL, p.Model)
+			client.SetUsageRecorder(recorder)
+			client.SetRequestObserver(observer)
+			service = client
+		}
+		providers[i] = llm.Provider{
+			Name:    p.Name,
+			Service: service,
+			Timeout: p.Timeout,
+		}
+		names[i] = fmt.Sprintf("%s (%s %s)", p.Name, p.Provider, p.Model)
+	}
+	slog.Info("LLM providers configured", "chain", strings.Join(names, " -> "))
+	return llm.NewFallback(providers...)
+}
+
+// newRateLimiter builds a ratelimit.Limiter from the rate limit config.
+func newRateLimiter(cfg config.RateLimitConfig) *ratelimit.Limiter {
+	rules := func(scopes config.RateLimitScopes) ratelimit.Rules {
+		rule := func(r config.RateLimitRule) ratelimit.Rule {
+			return ratelimit.Rule{Burst: float64(r.Burst), Refill: r.Refill}
+		}
+		return ratelimit.Rules{
+			ratelimit.ScopeUser:    rule(scopes.User),
+			ratelimit.ScopeChannel: rule(scopes.Channel),
+			ratelimit.ScopeGuild:   rule(scopes.Guild),
+		}
+	}
+	return ratelimit.NewLimiter(map[ratelimit.Kind]ratelimit.Rules{
+		ratelimit.KindChat:  rules(cfg.Chat),
+		ratelimit.KindVoice: rules(cfg.Voice),
+		ratelimit.KindSTT:   rules(cfg.STT),
+	})
+}
+
+// usagePrices converts the configured model prices.
+func usagePrices(cfg config.UsageConfig) map[string]usage.Price {
+	prices := make(map[string]usage.Price, len(cfg.Prices))
+	for model, p := range cfg.Prices {
+		prices[model] = usage.Price{Input: p.Input, Output: p.Output, AudioMinute: p.AudioMinute}
+	}
+	return prices
+}
+
+// usageQuotas converts the configured quotas.
+func usageQuotas(cfg config.UsageConfig) []usage.Quota {
+	quotas := make([]usage.Quota, len(cfg.Quotas))
+	for i, q := range cfg.Quotas {
+		quotas[i] = usage.Quota{
+			Scope:        usage.Scope(q.Scope),
+			Period:       usage.Period(q.Period),
+			Tokens:       q.Tokens,
+			AudioSeconds: q.AudioSeconds,
+			Cost:         q.Cost,
+		}
+	}
+	return quotas
+}
+
+// newPlayOptionsClient builds the play options API client from its config.
+func newPlayOptionsClient(cfg config.PlayOptionsConfig) (*playoptions.Client, error) {
+	format, err := playoptions.ParseFormat(cfg.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string, len(cfg.Headers)+1)
+	if cfg.Token != "" {
+		headers["Authorization"] = "Bearer " + cfg.Token
+	}
+	for k, v := range cfg.Headers {
+		headers[http.CanonicalHeaderKey(k)] = v // config keys are lowercased
+	}
+
+	client := playoptions.NewClient(cfg.APIURL, cfg.CacheTTL)
+	client.SetTimeout(cfg.Timeout)
+	client.SetHeaders(headers)
+	client.SetFormat(format)
+	client.SetSelector(cfg.Selector)
+	return client, nil
+}
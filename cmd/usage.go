@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/config"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/usage"
+	"github.com/adrock-miles/go-laserbeak/internal/infrastructure/persistence"
+	"github.com/spf13/cobra"
+)
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Inspect recorded API usage",
+}
+
+var usageReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Summarize the usage ledger by user, channel, guild, model or day",
+	RunE:  runUsageReport,
+}
+
+func init() {
+	usageReportCmd.Flags().String("file", "", "usage ledger (default: usage.file from config)")
+	usageReportCmd.Flags().String("from", "", "first day to include, YYYY-MM-DD (default: start of this month)")
+	usageReportCmd.Flags().String("to", "", "last day to include, YYYY-MM-DD (default: today)")
+	usageReportCmd.Flags().String("by", "user", "group by user, channel, guild, model, day or month")
+	usageReportCmd.Flags().Bool("csv", false, "write CSV instead of a table")
+	usageCmd.AddCommand(usageReportCmd)
+	rootCmd.AddCommand(usageCmd)
+}
+
+// usageGroups maps each --by option to the key it groups records by.
+var usageGroups = map[string]func(usage.Record) string{
+	"user":    func(r usage.Record) string { return r.UserID },
+	"channel": func(r usage.Record) string { return r.ChannelID },
+	"guild":   func(r usage.Record) string { return r.GuildID },
+	"model":   func(r usage.Record) string { return r.Model },
+	"day":     func(r usage.Record) string { return r.Time.Format("2006-01-02") },
+	"month":   func(r usage.Record) string { return r.Time.Format("2006-01") },
+}
+
+func runUsageReport(cmd *cobra.Command, args []string) error {
+	by, _ := cmd.Flags().GetString("by")
+	group, ok := usageGroups[by]
+	if !ok {
+		return fmt.Errorf("unknown --by %q (want user, channel, guild, model, day or month)", by)
+	}
+
+	// Days and months begin in bot.timezone, as they do for quotas.
+	loc, err := config.LoadLocation()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	path, _ := cmd.Flags().GetString("file")
+	if path == "" {
+		cfg, err := config.LoadUsage()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		path = cfg.File
+	}
+
+	now := time.Now().In(loc)
+	from, err := dayFlag(cmd, "from", usage.PeriodMonthly.Start(now))
+	if err != nil {
+		return err
+	}
+	to, err := dayFlag(cmd, "to", usage.PeriodDaily.Start(now))
+	if err != nil {
+		return err
+	}
+	to = to.AddDate(0, 0, 1) // include the whole last day
+
+	records, err := persistence.ReadUsageLedger(path, from)
+	if err != nil {
+		return err
+	}
+
+	totals := make(map[string]*usage.Totals)
+	for _, r := range records {
+		if !r.Time.Before(to) {
+			continue
+		}
+		r.Time = r.Time.In(loc)
+		key := group(r)
+		if totals[key] == nil {
+			totals[key] = &usage.Totals{}
+		}
+		totals[key].Add(r)
+	}
+
+	keys := make([]string, 0, len(totals))
+	for k := range totals {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if by == "day" || by == "month" {
+			return keys[i] < keys[j]
+		}
+		return totals[keys[i]].Cost > totals[keys[j]].Cost ||
+			totals[keys[i]].Cost == totals[keys[j]].Cost && keys[i] < keys[j]
+	})
+
+	if csvOut, _ := cmd.Flags().GetBool("csv"); csvOut {
+		return writeUsageCSV(cmd.OutOrStdout(), by, keys, totals)
+	}
+	return writeUsageTable(cmd.OutOrStdout(), by, keys, totals)
+}
+
+// dayFlag parses a YYYY-MM-DD flag in def's location.
+func dayFlag(cmd *cobra.Command, name string, def time.Time) (time.Time, error) {
+	v, _ := cmd.Flags().GetString(name)
+	if v == "" {
+		return def, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", v, def.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("--%s: want YYYY-MM-DD: %w", name, err)
+	}
+	return t, nil
+}
+
+var usageColumns = []string{"requests", "prompt_tokens", "completion_tokens", "audio_seconds", "cost_usd"}
+
+func usageRow(key string, t *usage.Totals) []string {
+	if key == "" {
+		key = "(none)"
+	}
+	return []string{
+		key,
+		strconv.Itoa(t.Requests),
+		strconv.Itoa(t.PromptTokens),
+		strconv.Itoa(t.CompletionTokens),
+		strconv.FormatFloat(t.AudioSeconds, 'f', 1, 64),
+		strconv.FormatFloat(t.Cost, 'f', 4, 64),
+	}
+}
+
+func writeUsageCSV(w io.Writer, by string, keys []string, totals map[string]*usage.Totals) error {
+	cw := csv.NewWriter(w)
+	cw.Write(append([]string{by}, usageColumns...))
+	for _, k := range keys {
+		cw.Write(usageRow(k, totals[k]))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeUsageTable(w io.Writer, by string, keys []string, totals map[string]*usage.Totals) error {
+	if len(keys) == 0 {
+		fmt.Fprintln(w, "No usage recorded in this period.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	writeTabbed(tw, append([]string{by}, usageColumns...))
+	var sum usage.Totals
+	for _, k := range keys {
+		t := totals[k]
+		writeTabbed(tw, usageRow(k, t))
+		sum.Requests += t.Requests
+		sum.PromptTokens += t.PromptTokens
+		sum.CompletionTokens += t.CompletionTokens
+		sum.AudioSeconds += t.AudioSeconds
+		sum.Cost += t.Cost
+	}
+	writeTabbed(tw, usageRow("total", &sum))
+	return tw.Flush()
+}
+
+func writeTabbed(w io.Writer, cells []string) {
+	for _, c := range cells {
+		fmt.Fprint(w, c, "\t")
+	}
+	fmt.Fprintln(w)
+}
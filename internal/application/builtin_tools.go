@@ -0,0 +1,268 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/playstats"
+)
+
+// searchResultLimit caps how many play options search_play_options returns.
+const searchResultLimit = 25
+
+// NewBuiltinTools returns a registry with the built-in chat tools. playOptions
+// and actions may be nil; tools that need them are left out. stats, if not
+// nil, records the sounds played. location is the time zone current_time
+// reports in by default; nil uses the system zone.
+func NewBuiltinTools(playOptions bot.PlayOptionsService, actions bot.ActionService, stats *PlayStatsService, location *time.Location) *ToolRegistry {
+	if location == nil {
+		location = time.Local
+	}
+	r := NewToolRegistry()
+	r.Register(currentTimeTool(location))
+
+	if playOptions != nil {
+		r.Register(searchPlayOptionsTool(playOptions))
+	}
+	if actions != nil {
+		r.Register(playSoundTool(playOptions, actions, stats))
+		r.Register(stopPlaybackTool(actions))
+		r.Register(joinVoiceTool(actions))
+		r.Register(leaveVoiceTool(actions))
+	}
+	return r
+}
+
+func currentTimeTool(location *time.Location) Tool {
+	return Tool{
+		Definition: bot.ToolDefinition{
+			Name:        "current_time",
+			Description: "Get the current date and time.",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"timezone": map[string]any{
+						"type":        "string",
+						"description": "IANA time zone such as Europe/London. Defaults to the bot's configured time zone.",
+					},
+				},
+			},
+		},
+		Run: func(_ context.Context, _ bot.ChatRequest, raw json.RawMessage) (string, error) {
+			var args struct {
+				Timezone string `json:"timezone"`
+			}
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return "", fmt.Errorf("parse arguments: %w", err)
+			}
+
+			loc := location
+			if args.Timezone != "" {
+				var err error
+				if loc, err = time.LoadLocation(args.Timezone); err != nil {
+					return "", fmt.Errorf("unknown time zone %q", args.Timezone)
+				}
+			}
+			return time.Now().In(loc).Format("Monday, 2 January 2006 15:04 MST"), nil
+		},
+	}
+}
+
+func searchPlayOptionsTool(playOptions bot.PlayOptionsService) Tool {
+	return Tool{
+		Definition: bot.ToolDefinition{
+			Name: "search_play_options",
+			Description: "Search the sounds that can be played. Returns matching option names, " +
+				"or every option when the query is empty.",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"query": map[string]any{
+						"type":        "string",
+						"description": "Words to look for in option names. Leave empty to list all options.",
+					},
+				},
+			},
+		},
+		Run: func(ctx context.Context, _ bot.ChatRequest, raw json.RawMessage) (string, error) {
+			var args struct {
+				Query string `json:"query"`
+			}
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return "", fmt.Errorf("parse arguments: %w", err)
+			}
+
+			options, err := playOptions.GetOptions(ctx)
+			if err != nil {
+				return "", fmt.Errorf("get play options: %w", err)
+			}
+			if len(options) == 0 {
+				return "No play options are available.", nil
+			}
+
+			names := searchOptions(options, args.Query)
+			if args.Query == "" {
+				return fmt.Sprintf("%d options: %s", len(names), strings.Join(names, ", ")), nil
+			}
+			if len(names) == 0 {
+				return fmt.Sprintf("No options match %q. Search with an empty query to list them all.", args.Query), nil
+			}
+			if len(names) > searchResultLimit {
+				names = names[:searchResultLimit]
+			}
+			return "Matching options: " + strings.Join(names, ", "), nil
+		},
+	}
+}
+
+func playSoundTool(playOptions bot.PlayOptionsService, actions bot.ActionService, stats *PlayStatsService) Tool {
+	return Tool{
+		Definition: bot.ToolDefinition{
+			Name: "play_sound",
+			Description: "Play a sound in the voice channel. Use an exact option name from search_play_options, " +
+				`or "random" for a random sound.`,
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"name": map[string]any{
+						"type":        "string",
+						"description": `Exact play option name, or "random".`,
+					},
+				},
+				"required": []string{"name"},
+			},
+		},
+		Run: func(ctx context.Context, req bot.ChatRequest, raw json.RawMessage) (string, error) {
+			var args struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return "", fmt.Errorf("parse arguments: %w", err)
+			}
+			name := strings.TrimSpace(args.Name)
+			if name == "" {
+				return "", fmt.Errorf("name is required")
+			}
+
+			command, option := "!pr", ""
+			if !strings.EqualFold(name, "random") {
+				resolved, err := resolvePlayOption(ctx, playOptions, name)
+				if err != nil {
+					return "", err
+				}
+				command, option = "!play "+resolved, resolved
+			}
+
+			if err := actions.SendCommand(ctx, req, command); err != nil {
+				return "", fmt.Errorf("send play command: %w", err)
+			}
+			if option != "" && stats != nil {
+				stats.Record(ctx, option, playstats.MatchTool)
+			}
+			return "Sent " + command, nil
+		},
+	}
+}
+
+func stopPlaybackTool(actions bot.ActionService) Tool {
+	return Tool{
+		Definition: bot.ToolDefinition{
+			Name:        "stop_playback",
+			Description: "Stop the sound that is currently playing.",
+			Parameters:  map[string]any{"type": "object", "properties": map[string]any{}},
+		},
+		Run: func(ctx context.Context, req bot.ChatRequest, _ json.RawMessage) (string, error) {
+			if err := actions.SendCommand(ctx, req, "!stop"); err != nil {
+				return "", fmt.Errorf("send stop command: %w", err)
+			}
+			return "Sent !stop", nil
+		},
+	}
+}
+
+func joinVoiceTool(actions bot.ActionService) Tool {
+	return Tool{
+		Definition: bot.ToolDefinition{
+			Name:        "join_voice",
+			Description: "Join the voice channel the requesting user is in.",
+			Parameters:  map[string]any{"type": "object", "properties": map[string]any{}},
+		},
+		Run: func(ctx context.Context, req bot.ChatRequest, _ json.RawMessage) (string, error) {
+			if err := actions.JoinVoice(ctx, req); err != nil {
+				return "", err
+			}
+			return "Joined the voice channel.", nil
+		},
+	}
+}
+
+func leaveVoiceTool(actions bot.ActionService) Tool {
+	return Tool{
+		Definition: bot.ToolDefinition{
+			Name:        "leave_voice",
+			Description: "Leave the current voice channel.",
+			Parameters:  map[string]any{"type": "object", "properties": map[string]any{}},
+		},
+		Run: func(ctx context.Context, req bot.ChatRequest, _ json.RawMessage) (string, error) {
+			if err := actions.LeaveVoice(ctx, req); err != nil {
+				return "", err
+			}
+			return "Left the voice channel.", nil
+		},
+	}
+}
+
+// resolvePlayOption returns the option whose name or alias matches name
+// ignoring case. Without a play options source the name is passed through
+// unchecked.
+func resolvePlayOption(ctx context.Context, playOptions bot.PlayOptionsService, name string) (string, error) {
+	if playOptions == nil {
+		return name, nil
+	}
+	options, err := playOptions.GetOptions(ctx)
+	if err != nil {
+		return "", fmt.Errorf("get play options: %w", err)
+	}
+	if len(options) == 0 {
+		return name, nil
+	}
+
+	for _, opt := range options {
+		if opt.Matches(name) {
+			return opt.Name, nil
+		}
+	}
+
+	msg := fmt.Sprintf("no play option named %q", name)
+	if similar := searchOptions(options, name); len(similar) > 0 {
+		if len(similar) > 5 {
+			similar = similar[:5]
+		}
+		msg += "; similar options: " + strings.Join(similar, ", ")
+	}
+	return "", errors.New(msg)
+}
+
+// searchOptions returns option names matching query, best matches first (see
+// scoreOptions). An empty query returns every name in order.
+func searchOptions(options []bot.PlayOption, query string) []string {
+	if strings.TrimSpace(query) == "" {
+		names := make([]string, len(options))
+		for i, opt := range options {
+			names[i] = opt.Name
+		}
+		return names
+	}
+
+	matches := scoreOptions(options, query)
+	names := make([]string, len(matches))
+	for i, m := range matches {
+		names[i] = m.Option.Name
+	}
+	return names
+}
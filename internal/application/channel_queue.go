@@ -0,0 +1,86 @@
+package application
+
+import (
+	"context"
+	"sync"
+)
+
+// ChannelQueue runs work for the same channel one at a time, in the order it
+// was submitted, while different channels run in parallel. Each caller waits
+// on its predecessor's completion, so there are no idle worker goroutines and
+// a channel's entry is dropped as soon as its queue drains.
+type ChannelQueue struct {
+	mu    sync.Mutex
+	tails map[string]chan struct{} // channelID -> completion of the last queued job
+}
+
+// NewChannelQueue creates an empty ChannelQueue.
+func NewChannelQueue() *ChannelQueue {
+	return &ChannelQueue{tails: make(map[string]chan struct{})}
+}
+
+// Do runs fn once all earlier work for channelID has finished. If ctx ends
+// while waiting, fn is skipped and ctx.Err() is returned; the slot is still
+// held until the predecessor finishes so later work keeps its order.
+func (q *ChannelQueue) Do(ctx context.Context, channelID string, fn func()) error {
+	return q.Reserve(channelID).Do(ctx, fn)
+}
+
+// Turn is a reserved place in a channel's queue.
+type Turn struct {
+	prev    <-chan struct{} // nil when the channel was idle
+	release func()
+}
+
+// Reserve takes the next place in channelID's queue without waiting, for work
+// whose order is known before it is ready to run. The Turn's Do must be
+// called exactly once, or the channel's later work never runs.
+func (q *ChannelQueue) Reserve(channelID string) *Turn {
+	prev, release := q.enqueue(channelID)
+	return &Turn{prev: prev, release: release}
+}
+
+// Do runs fn once all work reserved before the turn has finished, with the
+// same cancellation as ChannelQueue.Do.
+func (t *Turn) Do(ctx context.Context, fn func()) error {
+	if t.prev != nil {
+		select {
+		case <-t.prev:
+		case <-ctx.Done():
+			go func() {
+				<-t.prev
+				t.release()
+			}()
+			return ctx.Err()
+		}
+	}
+
+	defer t.release()
+	fn()
+	return nil
+}
+
+// enqueue reserves the next slot for channelID, returning the predecessor's
+// completion channel (nil if idle) and a func that marks this slot done.
+func (q *ChannelQueue) enqueue(channelID string) (<-chan struct{}, func()) {
+	done := make(chan struct{})
+
+	q.mu.Lock()
+	prev := q.tails[channelID]
+	q.tails[channelID] = done
+	q.mu.Unlock()
+
+	release := func() {
+		close(done)
+		q.mu.Lock()
+		if q.tails[channelID] == done {
+			delete(q.tails, channelID)
+		}
+		q.mu.Unlock()
+	}
+
+	if prev == nil {
+		return nil, release
+	}
+	return prev, release
+}
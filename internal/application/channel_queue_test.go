@@ -0,0 +1,167 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/conversation"
+	"github.com/adrock-miles/go-laserbeak/internal/infrastructure/persistence"
+)
+
+func TestChannelQueue_PreservesOrder(t *testing.T) {
+	q := NewChannelQueue()
+	var mu sync.Mutex
+	var order []int
+
+	// Reserve slots sequentially, then let the jobs race to run.
+	var wg sync.WaitGroup
+	first := make(chan struct{})
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		started := make(chan struct{})
+		go func(i int) {
+			defer wg.Done()
+			close(started)
+			q.Do(context.Background(), "ch", func() {
+				if i == 0 {
+					<-first
+				}
+				mu.Lock()
+				order = append(order, i)
+				mu.Unlock()
+			})
+		}(i)
+		<-started
+		time.Sleep(time.Millisecond)
+	}
+	close(first)
+	wg.Wait()
+
+	for i, got := range order {
+		if got != i {
+			t.Fatalf("order = %v, want 0..19 in sequence", order)
+		}
+	}
+}
+
+func TestChannelQueue_ReserveKeepsReservationOrder(t *testing.T) {
+	q := NewChannelQueue()
+	first := q.Reserve("ch")
+	second := q.Reserve("ch")
+
+	// The later reservation runs first only if it skips the queue.
+	var order []int
+	done := make(chan struct{})
+	go func() {
+		second.Do(context.Background(), func() { order = append(order, 2) })
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	first.Do(context.Background(), func() { order = append(order, 1) })
+	<-done
+
+	if len(order) != 2 || order[0] != 1 || order[1] != 2 {
+		t.Fatalf("order = %v, want [1 2]", order)
+	}
+}
+
+func TestChannelQueue_ChannelsRunInParallel(t *testing.T) {
+	q := NewChannelQueue()
+
+	// Each job waits until both have started, which only happens if they overlap.
+	var entered sync.WaitGroup
+	entered.Add(2)
+	allIn := make(chan struct{})
+	go func() { entered.Wait(); close(allIn) }()
+
+	for _, ch := range []string{"a", "b"} {
+		go q.Do(context.Background(), ch, func() {
+			entered.Done()
+			select {
+			case <-allIn:
+			case <-time.After(2 * time.Second):
+			}
+		})
+	}
+
+	select {
+	case <-allIn:
+	case <-time.After(time.Second):
+		t.Fatal("channels a and b did not run concurrently")
+	}
+}
+
+func TestChannelQueue_CancelWhileWaiting(t *testing.T) {
+	q := NewChannelQueue()
+	block := make(chan struct{})
+	go q.Do(context.Background(), "ch", func() { <-block })
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ran := false
+	if err := q.Do(ctx, "ch", func() { ran = true }); err == nil || ran {
+		t.Fatalf("Do with cancelled ctx = %v, ran=%v; want ctx error and no run", err, ran)
+	}
+
+	// Later work still runs once the blocker finishes.
+	close(block)
+	if err := q.Do(context.Background(), "ch", func() {}); err != nil {
+		t.Fatalf("Do after cancellation: %v", err)
+	}
+}
+
+// echoLLM replies with the content of the last message after a short random
+// delay, widening the window for concurrent turns to interleave.
+type echoLLM struct{}
+
+func (echoLLM) ChatCompletion(_ context.Context, msgs []bot.LLMMessage) (string, error) {
+	time.Sleep(time.Duration(rand.IntN(200)) * time.Microsecond)
+	return "echo: " + msgs[len(msgs)-1].Content, nil
+}
+
+// TestChatService_ConcurrentTurnsOneChannel hammers a single channel from many
+// goroutines. Run with -race: every turn must land as an adjacent user/assistant
+// pair, with no lost or interleaved messages.
+func TestChatService_ConcurrentTurnsOneChannel(t *testing.T) {
+	const turns = 100
+	repo := persistence.NewInMemoryConversationRepo()
+	svc := NewChatService(repo, echoLLM{}, "sys", 0, conversation.ContextPolicy{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < turns; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			content := fmt.Sprintf("msg %d", i)
+			reply, err := svc.HandleMessage(context.Background(), bot.ChatRequest{ChannelID: "ch", UserID: "u", Content: content})
+			if err != nil {
+				t.Errorf("HandleMessage error: %v", err)
+				return
+			}
+			if reply != "echo: "+content {
+				t.Errorf("reply to %q = %q; turns interleaved", content, reply)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	conv, _ := repo.FindByChannel("ch")
+	if len(conv.Messages) != 2*turns {
+		t.Fatalf("history has %d messages, want %d", len(conv.Messages), 2*turns)
+	}
+	for i := 0; i < len(conv.Messages); i += 2 {
+		user, assistant := conv.Messages[i], conv.Messages[i+1]
+		if user.Role != conversation.RoleUser || assistant.Role != conversation.RoleAssistant {
+			t.Fatalf("messages %d/%d have roles %s/%s, want user/assistant", i, i+1, user.Role, assistant.Role)
+		}
+		if assistant.Content != "echo: "+user.Content {
+			t.Fatalf("message %d reply %q does not answer %q", i+1, assistant.Content, user.Content)
+		}
+	}
+}
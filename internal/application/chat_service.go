@@ -3,10 +3,13 @@ package application
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"net/url"
 	"strings"
 
 	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
 	"github.com/adrock-miles/go-laserbeak/internal/domain/conversation"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/usage"
 )
 
 // ChatService is the application service that orchestrates text-based conversations.
@@ -15,64 +18,309 @@ type ChatService struct {
 	llm          bot.LLMService
 	systemPrompt string
 	maxHistory   int
+	policy       conversation.ContextPolicy
+	tools        *ToolRegistry
+	personas     *PersonaService
+	renderer     *PromptRenderer
+	usage        *UsageService
+
+	// queue serializes turns per channel, since a Conversation is not safe
+	// for concurrent use and replies must follow message order.
+	queue *ChannelQueue
+	// llmSem limits concurrent turns talking to the LLM across channels.
+	llmSem chan struct{}
 }
 
+// maxConcurrentTurns is how many channels' turns may call the LLM at once.
+const maxConcurrentTurns = 10
+
 // NewChatService creates a new ChatService.
 func NewChatService(
 	repo conversation.Repository,
 	llm bot.LLMService,
 	systemPrompt string,
 	maxHistory int,
+	policy conversation.ContextPolicy,
 ) *ChatService {
 	return &ChatService{
 		repo:         repo,
 		llm:          llm,
 		systemPrompt: systemPrompt,
 		maxHistory:   maxHistory,
+		policy:       policy,
+		queue:        NewChannelQueue(),
+		llmSem:       make(chan struct{}, maxConcurrentTurns),
 	}
 }
 
+// SetTools sets the tools offered to the LLM. Tools are only used when the
+// LLM service implements bot.ToolCallingLLM.
+func (s *ChatService) SetTools(tools *ToolRegistry) {
+	s.tools = tools
+}
+
+// SetPersonas sets the source of per-channel system prompts, replacing the
+// fixed systemPrompt.
+func (s *ChatService) SetPersonas(personas *PersonaService) {
+	s.personas = personas
+}
+
+// SetPromptRenderer makes system prompts Go templates, rendered for each
+// request. Without a renderer prompts are used as is.
+func (s *ChatService) SetPromptRenderer(r *PromptRenderer) {
+	s.renderer = r
+}
+
+// SetUsage enforces usage quotas on chat requests.
+func (s *ChatService) SetUsage(u *UsageService) {
+	s.usage = u
+}
+
 // HandleMessage processes a user message and returns the LLM response.
-func (s *ChatService) HandleMessage(ctx context.Context, channelID, userID, content string) (string, error) {
+// Messages for the same channel are handled one at a time in arrival order;
+// different channels proceed in parallel.
+func (s *ChatService) HandleMessage(ctx context.Context, req bot.ChatRequest) (string, error) {
+	return s.Reserve(req.ChannelID)(ctx, req)
+}
+
+// Reserve takes channelID's next turn now, for a message whose request is
+// still being prepared, such as while its attachments download. The returned
+// func handles the request in that turn and must be called exactly once.
+func (s *ChatService) Reserve(channelID string) func(ctx context.Context, req bot.ChatRequest) (string, error) {
+	turn := s.queue.Reserve(channelID)
+	return func(ctx context.Context, req bot.ChatRequest) (string, error) {
+		var reply string
+		var err error
+		if qerr := turn.Do(ctx, func() {
+			reply, err = s.handle(ctx, req)
+		}); qerr != nil {
+			return "", fmt.Errorf("waiting for channel %s: %w", channelID, qerr)
+		}
+		return reply, err
+	}
+}
+
+// handle processes a single turn. Callers must hold the channel's queue slot.
+func (s *ChatService) handle(ctx context.Context, req bot.ChatRequest) (string, error) {
+	channelID := req.ChannelID
+
 	// Handle special commands
-	if strings.TrimSpace(content) == "/clear" {
+	switch strings.TrimSpace(req.Content) {
+	case "/clear":
 		s.repo.Delete(channelID)
 		return "", nil
+	case "/summary":
+		if conv, found := s.repo.FindByChannel(channelID); found {
+			return conv.Summary, nil
+		}
+		return "", nil
+	}
+
+	// Usage in threads counts towards the parent channel, as rate limits do.
+	attr := usage.Attribution{GuildID: req.GuildID, ChannelID: req.ChannelID, UserID: req.UserID}
+	if req.ParentChannelID != "" {
+		attr.ChannelID = req.ParentChannelID
+	}
+	ctx = usage.WithAttribution(ctx, attr)
+	if s.usage != nil {
+		if err := s.usage.Check(attr); err != nil {
+			return "", err
+		}
+	}
+
+	select {
+	case s.llmSem <- struct{}{}:
+		defer func() { <-s.llmSem }()
+	case <-ctx.Done():
+		return "", ctx.Err()
 	}
 
 	conv := s.getOrCreateConversation(channelID)
+	// The prompt is rendered per request, and the persona may have changed
+	// since the conversation started; history is kept either way.
+	conv.SystemPrompt = s.systemPromptFor(ctx, req)
 
-	conv.AddMessage(conversation.NewMessage(conversation.RoleUser, content))
+	userMsg := conversation.NewUserMessage(req.UserID, req.UserName, req.MessageID, req.Content)
+	userMsg.Images = imageRefs(req.Images)
+	conv.AddMessage(userMsg)
 
 	llmMessages := toLLMMessages(conv.AllMessages())
+	// Images go with this turn only; Discord attachment URLs expire, so
+	// history keeps a placeholder instead.
+	last := &llmMessages[len(llmMessages)-1]
+	last.Content = userMsg.Content
+	last.Images = req.Images
+	if len(req.ReplyChain) > 0 {
+		// The quoted messages are context for this turn only; history keeps the user's own words.
+		last := len(llmMessages) - 1
+		llmMessages = append(llmMessages[:last:last], replyContext(req.ReplyChain), llmMessages[last])
+	}
 
-	reply, err := s.llm.ChatCompletion(ctx, llmMessages)
+	reply, err := s.complete(ctx, req, llmMessages)
 	if err != nil {
 		return "", fmt.Errorf("LLM completion: %w", err)
 	}
 
 	conv.AddMessage(conversation.NewMessage(conversation.RoleAssistant, reply))
+	s.summarize(ctx, conv)
 	s.repo.Save(conv)
 
 	return reply, nil
 }
 
+// complete asks the LLM for a reply, running any tools it calls and feeding
+// their results back until it answers. Only the final answer is kept in the
+// conversation history.
+func (s *ChatService) complete(ctx context.Context, req bot.ChatRequest, messages []bot.LLMMessage) (string, error) {
+	tl, ok := s.llm.(bot.ToolCallingLLM)
+	if !ok || s.tools == nil || s.tools.Len() == 0 {
+		return s.llm.ChatCompletion(ctx, messages)
+	}
+
+	defs := s.tools.Definitions()
+	for round := 0; round < maxToolRounds; round++ {
+		reply, err := tl.ChatCompletionWithTools(ctx, messages, defs)
+		if err != nil {
+			return "", err
+		}
+		if len(reply.ToolCalls) == 0 {
+			return reply.Content, nil
+		}
+
+		messages = append(messages, reply)
+		for _, call := range reply.ToolCalls {
+			messages = append(messages, bot.LLMMessage{
+				Role:       "tool",
+				ToolCallID: call.ID,
+				Content:    s.tools.Call(ctx, req, call),
+			})
+		}
+	}
+	return "", fmt.Errorf("no answer after %d rounds of tool calls", maxToolRounds)
+}
+
+// summarize condenses the oldest messages into the conversation's running
+// summary once history grows past the policy threshold. On failure the
+// history is left as is and summarization is retried on the next turn.
+func (s *ChatService) summarize(ctx context.Context, conv *conversation.Conversation) {
+	pending := conv.PendingSummary()
+	if len(pending) == 0 {
+		return
+	}
+
+	var transcript strings.Builder
+	for _, m := range pending {
+		fmt.Fprintf(&transcript, "%s: %s\n", m.Speaker(), m.Text())
+	}
+
+	previous := conv.Summary
+	if previous == "" {
+		previous = "(none)"
+	}
+
+	messages := []bot.LLMMessage{
+		{Role: "system", Content: "You maintain a running summary of a Discord chat between users and an assistant. " +
+			"Merge the existing summary with the new messages into one concise summary of at most 200 words. " +
+			"Keep facts, names, decisions, preferences and open questions; drop greetings and filler. " +
+			"Reply with only the summary."},
+		{Role: "user", Content: fmt.Sprintf("Existing summary:\n%s\n\nNew messages:\n%s", previous, transcript.String())},
+	}
+
+	summary, err := s.llm.ChatCompletion(ctx, messages)
+	if err != nil {
+		slog.WarnContext(ctx, "conversation summary failed", "conversation", conv.ChannelID, "pending", len(pending), "err", err)
+		return
+	}
+	summary = strings.TrimSpace(summary)
+	if summary == "" {
+		return
+	}
+
+	conv.ApplySummary(summary, len(pending))
+	slog.InfoContext(ctx, "summarized conversation", "conversation", conv.ChannelID, "messages", len(pending), "summary_length", len(summary))
+}
+
 func (s *ChatService) getOrCreateConversation(channelID string) *conversation.Conversation {
 	conv, found := s.repo.FindByChannel(channelID)
 	if !found {
-		conv = conversation.NewConversation(channelID, s.systemPrompt, s.maxHistory)
+		// The system prompt is filled in per request by handle.
+		conv = conversation.NewConversation(channelID, "", s.maxHistory, s.policy)
 		s.repo.Save(conv)
 	}
 	return conv
 }
 
+// replyContext describes the messages a user replied to, oldest first.
+func replyContext(chain []bot.ReferencedMessage) bot.LLMMessage {
+	var sb strings.Builder
+	sb.WriteString("The next message is a reply. The message it replies to")
+	if len(chain) > 1 {
+		sb.WriteString(", and the earlier messages in that reply chain (oldest first)")
+	}
+	sb.WriteString(":\n")
+	for _, m := range chain {
+		author := m.AuthorName
+		if m.FromBot {
+			author = "you (the assistant)"
+		}
+		fmt.Fprintf(&sb, "\n[%s]: %s\n", author, m.Content)
+	}
+	return bot.LLMMessage{Role: "system", Content: sb.String()}
+}
+
+// systemPromptFor returns the rendered system prompt for a request.
+func (s *ChatService) systemPromptFor(ctx context.Context, req bot.ChatRequest) string {
+	text := s.systemPrompt
+	if s.personas != nil {
+		// Threads use their parent channel's persona unless they have their own.
+		channelID := req.ChannelID
+		if _, ok := s.personas.Current(channelID); !ok && req.ParentChannelID != "" {
+			channelID = req.ParentChannelID
+		}
+		text = s.personas.SystemPrompt(channelID)
+	}
+	if s.renderer == nil {
+		return text
+	}
+	return s.renderer.Render(ctx, text, req)
+}
+
 func toLLMMessages(msgs []conversation.Message) []bot.LLMMessage {
 	result := make([]bot.LLMMessage, len(msgs))
 	for i, m := range msgs {
 		result[i] = bot.LLMMessage{
 			Role:    string(m.Role),
-			Content: m.Content,
+			Content: m.Text(),
+			Name:    m.AuthorName,
 		}
 	}
 	return result
 }
+
+// imageRefs converts request images to the records kept in history.
+// Inline images have no file name.
+func imageRefs(images []bot.Image) []conversation.Image {
+	var refs []conversation.Image
+	for _, img := range images {
+		var name string
+		if img.URL != "" {
+			name = imageName(img.URL)
+		}
+		refs = append(refs, conversation.Image{Name: name})
+	}
+	return refs
+}
+
+// imageName returns the file name at the end of an image URL.
+func imageName(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		rawURL = u.Path
+	}
+	if i := strings.LastIndex(rawURL, "/"); i >= 0 {
+		rawURL = rawURL[i+1:]
+	}
+	if rawURL == "" {
+		return "image"
+	}
+	return rawURL
+}
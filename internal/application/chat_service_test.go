@@ -0,0 +1,267 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/conversation"
+	"github.com/adrock-miles/go-laserbeak/internal/infrastructure/persistence"
+)
+
+// scriptedLLM records every request and answers summary requests and chat
+// turns separately, so tests can tell the two apart.
+type scriptedLLM struct {
+	chatReply    string
+	summaryReply string
+	summaryErr   error
+	calls        [][]bot.LLMMessage
+}
+
+func (m *scriptedLLM) ChatCompletion(_ context.Context, msgs []bot.LLMMessage) (string, error) {
+	m.calls = append(m.calls, msgs)
+	if isSummaryRequest(msgs) {
+		return m.summaryReply, m.summaryErr
+	}
+	return m.chatReply, nil
+}
+
+func (m *scriptedLLM) summaryCalls() [][]bot.LLMMessage {
+	var out [][]bot.LLMMessage
+	for _, c := range m.calls {
+		if isSummaryRequest(c) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func isSummaryRequest(msgs []bot.LLMMessage) bool {
+	return len(msgs) > 0 && strings.Contains(msgs[0].Content, "running summary")
+}
+
+// chatRequest builds a request from userID in channel "ch".
+func chatRequest(userID, content string) bot.ChatRequest {
+	return bot.ChatRequest{ChannelID: "ch", UserID: userID, Content: content}
+}
+
+func newSummarizingChatService(llm bot.LLMService) (*ChatService, *persistence.InMemoryConversationRepo) {
+	repo := persistence.NewInMemoryConversationRepo()
+	svc := NewChatService(repo, llm, "You are Laserbeak.", 50, conversation.ContextPolicy{
+		SummarizeAfter: 6,
+		SummaryKeep:    2,
+	})
+	return svc, repo
+}
+
+func TestChatService_SummarizesWhenHistoryOverflows(t *testing.T) {
+	llm := &scriptedLLM{chatReply: "ok", summaryReply: "Sam asked about pizza."}
+	svc, repo := newSummarizingChatService(llm)
+	ctx := context.Background()
+
+	// Each turn stores two messages; the fourth turn pushes history to 8 > 6.
+	for i := 0; i < 3; i++ {
+		if _, err := svc.HandleMessage(ctx, chatRequest("u1", "hello")); err != nil {
+			t.Fatalf("HandleMessage error: %v", err)
+		}
+	}
+	if n := len(llm.summaryCalls()); n != 0 {
+		t.Fatalf("summarized %d times below the threshold, want 0", n)
+	}
+
+	if _, err := svc.HandleMessage(ctx, chatRequest("u1", "what about pizza?")); err != nil {
+		t.Fatalf("HandleMessage error: %v", err)
+	}
+
+	calls := llm.summaryCalls()
+	if len(calls) != 1 {
+		t.Fatalf("summarized %d times, want 1", len(calls))
+	}
+	if !strings.Contains(calls[0][1].Content, "Existing summary:\n(none)") {
+		t.Errorf("first summary request should have no previous summary: %q", calls[0][1].Content)
+	}
+
+	conv, _ := repo.FindByChannel("ch")
+	if conv.Summary != "Sam asked about pizza." {
+		t.Errorf("Summary = %q, want the LLM summary", conv.Summary)
+	}
+	if len(conv.Messages) != 2 {
+		t.Errorf("kept %d messages after summarizing, want 2", len(conv.Messages))
+	}
+	if conv.Messages[0].Content != "what about pizza?" {
+		t.Errorf("oldest kept message = %q, want the latest user turn", conv.Messages[0].Content)
+	}
+}
+
+func TestChatService_InjectsSummaryAfterSystemPrompt(t *testing.T) {
+	llm := &scriptedLLM{chatReply: "ok", summaryReply: "Earlier: pizza."}
+	svc, _ := newSummarizingChatService(llm)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		svc.HandleMessage(ctx, chatRequest("u1", "hello"))
+	}
+
+	last := llm.calls[len(llm.calls)-1]
+	if isSummaryRequest(last) {
+		t.Fatal("expected the last request to be a chat turn")
+	}
+	if last[0].Content != "You are Laserbeak." {
+		t.Errorf("first message = %q, want the system prompt", last[0].Content)
+	}
+	if last[1].Role != "system" || !strings.Contains(last[1].Content, "Earlier: pizza.") {
+		t.Errorf("second message = %+v, want the running summary", last[1])
+	}
+}
+
+func TestChatService_SummaryFailureKeepsHistory(t *testing.T) {
+	llm := &scriptedLLM{chatReply: "ok", summaryErr: errors.New("boom")}
+	svc, repo := newSummarizingChatService(llm)
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		if _, err := svc.HandleMessage(ctx, chatRequest("u1", "hello")); err != nil {
+			t.Fatalf("HandleMessage error: %v", err)
+		}
+	}
+
+	conv, _ := repo.FindByChannel("ch")
+	if conv.Summary != "" {
+		t.Errorf("Summary = %q, want empty after a failed summary", conv.Summary)
+	}
+	if len(conv.Messages) != 8 {
+		t.Errorf("kept %d messages, want all 8 after a failed summary", len(conv.Messages))
+	}
+}
+
+func TestChatService_SummaryCommand(t *testing.T) {
+	llm := &scriptedLLM{chatReply: "ok", summaryReply: "A recap."}
+	svc, _ := newSummarizingChatService(llm)
+	ctx := context.Background()
+
+	got, err := svc.HandleMessage(ctx, chatRequest("u1", "/summary"))
+	if err != nil || got != "" {
+		t.Fatalf("/summary before any chat = %q, %v; want empty", got, err)
+	}
+
+	for i := 0; i < 4; i++ {
+		svc.HandleMessage(ctx, chatRequest("u1", "hello"))
+	}
+	calls := len(llm.calls)
+
+	got, err = svc.HandleMessage(ctx, chatRequest("u1", "/summary"))
+	if err != nil {
+		t.Fatalf("/summary error: %v", err)
+	}
+	if got != "A recap." {
+		t.Errorf("/summary = %q, want %q", got, "A recap.")
+	}
+	if len(llm.calls) != calls {
+		t.Error("/summary should not call the LLM")
+	}
+}
+
+func TestChatService_AttributesSpeakers(t *testing.T) {
+	llm := &scriptedLLM{chatReply: "ok"}
+	svc := NewChatService(persistence.NewInMemoryConversationRepo(), llm, "You are Laserbeak.", 50, conversation.ContextPolicy{})
+	ctx := context.Background()
+
+	svc.HandleMessage(ctx, bot.ChatRequest{ChannelID: "ch", UserID: "u1", UserName: "Sam", MessageID: "m1", Content: "is pineapple ok on pizza?"})
+	svc.HandleMessage(ctx, bot.ChatRequest{ChannelID: "ch", UserID: "u2", UserName: "Alex", MessageID: "m2", Content: "what did Sam ask earlier?"})
+
+	last := llm.calls[len(llm.calls)-1]
+	if !strings.Contains(last[0].Content, "Participants so far: Sam, Alex.") {
+		t.Errorf("system prompt = %q, want it to list the participants", last[0].Content)
+	}
+
+	var names []string
+	for _, m := range last {
+		if m.Role == "user" {
+			names = append(names, m.Name)
+		}
+	}
+	if len(names) != 2 || names[0] != "Sam" || names[1] != "Alex" {
+		t.Errorf("user message names = %v, want [Sam Alex]", names)
+	}
+}
+
+func TestChatService_IncludesReplyChainForTurnOnly(t *testing.T) {
+	llm := &scriptedLLM{chatReply: "It means X."}
+	svc, repo := newSummarizingChatService(llm)
+
+	req := chatRequest("u1", "explain this")
+	req.UserName = "Sam"
+	req.ReplyChain = []bot.ReferencedMessage{
+		{AuthorName: "Alex", Content: "what does X mean?"},
+		{AuthorName: "Laserbeak", FromBot: true, Content: "X is a letter.\n[attachment: x.png (image/png, 2 KB)]"},
+	}
+	if _, err := svc.HandleMessage(context.Background(), req); err != nil {
+		t.Fatalf("HandleMessage error: %v", err)
+	}
+
+	sent := llm.calls[0]
+	if len(sent) < 3 {
+		t.Fatalf("sent %d messages, want system prompt, reply context and user message", len(sent))
+	}
+	ctxMsg, user := sent[len(sent)-2], sent[len(sent)-1]
+	if ctxMsg.Role != "system" || !strings.Contains(ctxMsg.Content, "[Alex]: what does X mean?") ||
+		!strings.Contains(ctxMsg.Content, "[you (the assistant)]: X is a letter.") {
+		t.Errorf("reply context = %+v", ctxMsg)
+	}
+	if strings.Index(ctxMsg.Content, "[Alex]") > strings.Index(ctxMsg.Content, "[you") {
+		t.Error("reply chain is not oldest first")
+	}
+	if user.Role != "user" || user.Content != "explain this" {
+		t.Errorf("last message = %+v, want the user's message", user)
+	}
+
+	conv, _ := repo.FindByChannel("ch")
+	for _, m := range conv.Messages {
+		if strings.Contains(m.Content, "what does X mean?") {
+			t.Error("reply chain was stored in history")
+		}
+	}
+}
+
+func TestChatService_SendsAndStoresImages(t *testing.T) {
+	llm := &scriptedLLM{chatReply: "The loop never ends."}
+	svc, repo := newSummarizingChatService(llm)
+
+	req := chatRequest("u1", "what's wrong here?")
+	req.Images = []bot.Image{
+		{URL: "https://cdn.example/attachments/1/2/shot.png?ex=abc", MIMEType: "image/png"},
+		{Data: []byte("inline"), MIMEType: "image/png"},
+	}
+	if _, err := svc.HandleMessage(context.Background(), req); err != nil {
+		t.Fatalf("HandleMessage error: %v", err)
+	}
+
+	sent := llm.calls[0][len(llm.calls[0])-1]
+	if len(sent.Images) != 2 || sent.Images[0].URL != req.Images[0].URL || string(sent.Images[1].Data) != "inline" {
+		t.Errorf("sent images = %+v, want the URL and the inline image", sent.Images)
+	}
+
+	if sent.Content != req.Content {
+		t.Errorf("sent content = %q, want %q", sent.Content, req.Content)
+	}
+
+	conv, _ := repo.FindByChannel("ch")
+	stored := conv.Messages[0].Images
+	if len(stored) != 2 || stored[0].Name != "shot.png" || stored[1].Name != "" {
+		t.Errorf("stored images = %+v, want shot.png and an unnamed image", stored)
+	}
+
+	// Later turns send placeholders, not the images.
+	svc.HandleMessage(context.Background(), chatRequest("u1", "and now?"))
+	history := llm.calls[len(llm.calls)-1]
+	for _, m := range history {
+		if len(m.Images) > 0 {
+			t.Errorf("history resent images: %+v", m.Images)
+		}
+	}
+	if want := "what's wrong here?\n[image: shot.png]\n[image]"; history[len(history)-3].Content != want {
+		t.Errorf("earlier turn = %q, want %q", history[len(history)-3].Content, want)
+	}
+}
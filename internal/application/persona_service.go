@@ -0,0 +1,112 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/persona"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/prompt"
+)
+
+// maxPersonaLength caps custom persona prompts so one channel can't crowd
+// out its own history.
+const maxPersonaLength = 2000
+
+// ErrEmptyPersona is returned when setting a persona with no text.
+var ErrEmptyPersona = errors.New("persona text is empty")
+
+// PersonaService manages the system prompt used in each channel. A channel
+// without a persona uses the configured default prompt.
+type PersonaService struct {
+	repo          persona.Repository
+	defaultPrompt string
+	presets       map[string]string // lowercase name -> prompt
+}
+
+// NewPersonaService creates a new PersonaService. Preset names are matched
+// case-insensitively.
+func NewPersonaService(repo persona.Repository, defaultPrompt string, presets map[string]string) *PersonaService {
+	s := &PersonaService{
+		repo:          repo,
+		defaultPrompt: defaultPrompt,
+		presets:       make(map[string]string, len(presets)),
+	}
+	for name, prompt := range presets {
+		s.presets[strings.ToLower(name)] = prompt
+	}
+	return s
+}
+
+// SystemPrompt returns the system prompt in effect for a channel.
+func (s *PersonaService) SystemPrompt(channelID string) string {
+	p, ok := s.repo.FindByChannel(channelID)
+	if !ok {
+		return s.defaultPrompt
+	}
+	if preset, ok := s.presets[p.Preset]; ok {
+		return preset
+	}
+	return p.Prompt
+}
+
+// Current returns the persona set for a channel, reporting false when the
+// channel uses the default prompt.
+func (s *PersonaService) Current(channelID string) (persona.Persona, bool) {
+	p, ok := s.repo.FindByChannel(channelID)
+	if !ok {
+		return persona.Persona{ChannelID: channelID, Prompt: s.defaultPrompt}, false
+	}
+	p.Prompt = s.SystemPrompt(channelID)
+	return p, true
+}
+
+// Set sets a channel's persona. text is either the name of a preset or a
+// custom system prompt. The change applies from the channel's next message;
+// its conversation history is kept.
+func (s *PersonaService) Set(channelID, text, userID string) (persona.Persona, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return persona.Persona{}, ErrEmptyPersona
+	}
+
+	p := persona.Persona{
+		ChannelID: channelID,
+		Prompt:    text,
+		SetBy:     userID,
+		UpdatedAt: time.Now(),
+	}
+	if preset, ok := s.presets[strings.ToLower(text)]; ok {
+		p.Preset = strings.ToLower(text)
+		p.Prompt = preset
+	} else if len(text) > maxPersonaLength {
+		return persona.Persona{}, fmt.Errorf("persona is %d characters, the limit is %d", len(text), maxPersonaLength)
+	} else if _, err := prompt.Parse(text); err != nil {
+		return persona.Persona{}, fmt.Errorf("invalid prompt template: %w", err)
+	}
+
+	if err := s.repo.Save(p); err != nil {
+		return persona.Persona{}, fmt.Errorf("save persona: %w", err)
+	}
+	return p, nil
+}
+
+// Reset returns a channel to the default prompt.
+func (s *PersonaService) Reset(channelID string) error {
+	if err := s.repo.Delete(channelID); err != nil {
+		return fmt.Errorf("delete persona: %w", err)
+	}
+	return nil
+}
+
+// Presets returns the preset names in alphabetical order.
+func (s *PersonaService) Presets() []string {
+	names := make([]string, 0, len(s.presets))
+	for name := range s.presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,110 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/adrock-miles/go-laserbeak/internal/infrastructure/persistence"
+)
+
+func newTestPersonaService(t *testing.T) *PersonaService {
+	t.Helper()
+	repo, err := persistence.NewFilePersonaRepo("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewPersonaService(repo, "You are Laserbeak.", map[string]string{
+		"Pirate": "Talk like a pirate.",
+	})
+}
+
+func TestPersonaService_PresetsAndCustom(t *testing.T) {
+	svc := newTestPersonaService(t)
+
+	if got := svc.SystemPrompt("ch"); got != "You are Laserbeak." {
+		t.Errorf("default prompt = %q", got)
+	}
+
+	p, err := svc.Set("ch", "PIRATE", "u1")
+	if err != nil {
+		t.Fatalf("Set preset: %v", err)
+	}
+	if p.Preset != "pirate" || svc.SystemPrompt("ch") != "Talk like a pirate." {
+		t.Errorf("preset persona = %+v, prompt %q", p, svc.SystemPrompt("ch"))
+	}
+
+	if _, err := svc.Set("ch", "Answer only in haiku.", "u1"); err != nil {
+		t.Fatalf("Set custom: %v", err)
+	}
+	if got, custom := svc.Current("ch"); !custom || got.IsPreset() || got.Prompt != "Answer only in haiku." {
+		t.Errorf("Current = %+v, %v", got, custom)
+	}
+	if svc.SystemPrompt("other") != "You are Laserbeak." {
+		t.Error("persona leaked into another channel")
+	}
+
+	if err := svc.Reset("ch"); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if _, custom := svc.Current("ch"); custom {
+		t.Error("persona still set after Reset")
+	}
+}
+
+func TestPersonaService_RejectsBadText(t *testing.T) {
+	svc := newTestPersonaService(t)
+
+	if _, err := svc.Set("ch", "   ", "u1"); !errors.Is(err, ErrEmptyPersona) {
+		t.Errorf("empty text error = %v, want ErrEmptyPersona", err)
+	}
+	if _, err := svc.Set("ch", strings.Repeat("x", maxPersonaLength+1), "u1"); err == nil {
+		t.Error("expected error for an over-long persona")
+	}
+}
+
+func TestChatService_PersonaAppliesToExistingConversation(t *testing.T) {
+	llm := &scriptedLLM{}
+	svc, repo := newSummarizingChatService(llm)
+	personas := newTestPersonaService(t)
+	svc.SetPersonas(personas)
+
+	ctx := context.Background()
+	svc.HandleMessage(ctx, chatRequest("u1", "hello"))
+	if _, err := personas.Set("ch", "pirate", "u1"); err != nil {
+		t.Fatal(err)
+	}
+	svc.HandleMessage(ctx, chatRequest("u1", "again"))
+
+	conv, _ := repo.FindByChannel("ch")
+	if len(conv.Messages) != 4 {
+		t.Errorf("history has %d messages, want 4", len(conv.Messages))
+	}
+	last := llm.calls[len(llm.calls)-1]
+	if !strings.HasPrefix(last[0].Content, "Talk like a pirate.") {
+		t.Errorf("system prompt = %q, want the pirate preset", last[0].Content)
+	}
+}
+
+func TestChatService_ThreadInheritsParentPersona(t *testing.T) {
+	llm := &scriptedLLM{}
+	svc, _ := newSummarizingChatService(llm)
+	personas := newTestPersonaService(t)
+	svc.SetPersonas(personas)
+	personas.Set("parent", "pirate", "u1")
+
+	req := chatRequest("u1", "hi")
+	req.ChannelID = "thread"
+	req.ParentChannelID = "parent"
+	svc.HandleMessage(context.Background(), req)
+	if got := llm.calls[0][0].Content; got != "Talk like a pirate." {
+		t.Errorf("thread system prompt = %q, want the parent's pirate persona", got)
+	}
+
+	personas.Set("thread", "Be brief.", "u1")
+	svc.HandleMessage(context.Background(), req)
+	if got := llm.calls[1][0].Content; !strings.HasPrefix(got, "Be brief.") {
+		t.Errorf("thread system prompt = %q, want its own persona", got)
+	}
+}
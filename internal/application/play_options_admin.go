@@ -0,0 +1,135 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+)
+
+// ErrReadOnlyOptions is returned when changing play options without a
+// writable store.
+var ErrReadOnlyOptions = errors.New("play options are read-only")
+
+// PlayOptionsAdmin backs the play options admin commands: it lists and
+// searches every source, and adds and removes options in a writable store.
+type PlayOptionsAdmin struct {
+	options bot.PlayOptionsService
+	store   bot.PlayOptionsStore // may be nil
+}
+
+// NewPlayOptionsAdmin creates a PlayOptionsAdmin. options should serve every
+// source, store included; store may be nil, making the options read-only.
+func NewPlayOptionsAdmin(options bot.PlayOptionsService, store bot.PlayOptionsStore) *PlayOptionsAdmin {
+	return &PlayOptionsAdmin{options: options, store: store}
+}
+
+// List returns the options whose name, aliases or tags contain filter,
+// ignoring case, or every option for an empty filter.
+func (a *PlayOptionsAdmin) List(ctx context.Context, filter string) ([]bot.PlayOption, error) {
+	options, err := a.options.GetOptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get play options: %w", err)
+	}
+	filter = bot.NormalizeName(filter)
+	if filter == "" {
+		return options, nil
+	}
+
+	var out []bot.PlayOption
+	for _, opt := range options {
+		if strings.Contains(searchText(opt), filter) {
+			out = append(out, opt)
+		}
+	}
+	return out, nil
+}
+
+// Search returns the options matching query with their scores, best first.
+func (a *PlayOptionsAdmin) Search(ctx context.Context, query string) ([]bot.ScoredOption, error) {
+	options, err := a.options.GetOptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get play options: %w", err)
+	}
+	return scoreOptions(options, query), nil
+}
+
+// Add adds an option with optional aliases to the store.
+func (a *PlayOptionsAdmin) Add(ctx context.Context, name string, aliases []string, userID string) (bot.PlayOption, error) {
+	if a.store == nil {
+		return bot.PlayOption{}, ErrReadOnlyOptions
+	}
+	opt := bot.PlayOption{Name: strings.TrimSpace(name), Aliases: aliases}
+	if err := a.store.AddOption(ctx, opt); err != nil {
+		return bot.PlayOption{}, err
+	}
+	slog.InfoContext(ctx, "play option added", "option", opt.Name, "by", userID)
+	return opt, nil
+}
+
+// Remove removes an option from the store. Options that only come from
+// another source can't be removed.
+func (a *PlayOptionsAdmin) Remove(ctx context.Context, name, userID string) error {
+	if a.store == nil {
+		return ErrReadOnlyOptions
+	}
+	err := a.store.RemoveOption(ctx, name)
+	if errors.Is(err, bot.ErrOptionNotFound) {
+		options, _ := a.options.GetOptions(ctx)
+		for _, opt := range options {
+			if bot.NormalizeName(opt.Name) == bot.NormalizeName(name) {
+				return fmt.Errorf("%q comes from the %s source: %w", opt.Name, opt.Source, ErrReadOnlyOptions)
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "play option removed", "option", name, "by", userID)
+	return nil
+}
+
+// scoreOptions scores the options matching query, best first. Each query word
+// found in a name, alias or tag scores a point, with a bonus when the whole
+// query appears in one.
+func scoreOptions(options []bot.PlayOption, query string) []bot.ScoredOption {
+	query = bot.NormalizeName(query)
+	if query == "" {
+		return nil
+	}
+
+	words := strings.Fields(query)
+	var matches []bot.ScoredOption
+	for _, opt := range options {
+		text := searchText(opt)
+		score := 0
+		if strings.Contains(text, query) {
+			score += len(words) + 1
+		}
+		for _, w := range words {
+			if strings.Contains(text, w) {
+				score++
+			}
+		}
+		if score > 0 {
+			matches = append(matches, bot.ScoredOption{Option: opt, Score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches
+}
+
+// searchText is the normalized name, aliases and tags of an option, one per
+// line so a query can't match across them.
+func searchText(opt bot.PlayOption) string {
+	parts := []string{bot.NormalizeName(opt.Name)}
+	for _, s := range append(append([]string(nil), opt.Aliases...), opt.Tags...) {
+		parts = append(parts, bot.NormalizeName(s))
+	}
+	return strings.Join(parts, "\n")
+}
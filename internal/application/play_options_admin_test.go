@@ -0,0 +1,92 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+)
+
+// memoryOptionsStore is an in-memory bot.PlayOptionsStore.
+type memoryOptionsStore struct {
+	mockPlayOptions
+}
+
+func (s *memoryOptionsStore) AddOption(_ context.Context, opt bot.PlayOption) error {
+	for _, o := range s.options {
+		if bot.NormalizeName(o.Name) == bot.NormalizeName(opt.Name) {
+			return bot.ErrOptionExists
+		}
+	}
+	s.options = append(s.options, opt)
+	return nil
+}
+
+func (s *memoryOptionsStore) RemoveOption(_ context.Context, name string) error {
+	for i, o := range s.options {
+		if bot.NormalizeName(o.Name) == bot.NormalizeName(name) {
+			s.options = append(s.options[:i], s.options[i+1:]...)
+			return nil
+		}
+	}
+	return bot.ErrOptionNotFound
+}
+
+func TestPlayOptionsAdmin_AddRemove(t *testing.T) {
+	store := &memoryOptionsStore{}
+	all := &mockPlayOptions{options: []bot.PlayOption{{Name: "airhorn", Source: "api"}}}
+	admin := NewPlayOptionsAdmin(all, store)
+	ctx := context.Background()
+
+	if _, err := admin.Add(ctx, " rimshot ", []string{"ba dum tss"}, "u1"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if len(store.options) != 1 || store.options[0].Name != "rimshot" || store.options[0].Aliases[0] != "ba dum tss" {
+		t.Errorf("store = %+v", store.options)
+	}
+	if err := admin.Remove(ctx, "rimshot", "u1"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+
+	err := admin.Remove(ctx, "airhorn", "u1")
+	if !errors.Is(err, ErrReadOnlyOptions) || !strings.Contains(err.Error(), "api") {
+		t.Errorf("Remove of an API option: err = %v, want read-only from api", err)
+	}
+	if err := admin.Remove(ctx, "nope", "u1"); !errors.Is(err, bot.ErrOptionNotFound) {
+		t.Errorf("Remove missing: err = %v, want ErrOptionNotFound", err)
+	}
+
+	readOnly := NewPlayOptionsAdmin(all, nil)
+	if _, err := readOnly.Add(ctx, "rimshot", nil, "u1"); !errors.Is(err, ErrReadOnlyOptions) {
+		t.Errorf("Add without a store: err = %v, want ErrReadOnlyOptions", err)
+	}
+}
+
+func TestPlayOptionsAdmin_ListAndSearch(t *testing.T) {
+	all := &mockPlayOptions{options: []bot.PlayOption{
+		{Name: "airhorn"},
+		{Name: "air raid siren", Tags: []string{"loud"}},
+		{Name: "sad trombone", Aliases: []string{"womp womp"}},
+	}}
+	admin := NewPlayOptionsAdmin(all, nil)
+	ctx := context.Background()
+
+	listed, _ := admin.List(ctx, "LOUD")
+	if len(listed) != 1 || listed[0].Name != "air raid siren" {
+		t.Errorf("List(loud) = %+v", listed)
+	}
+
+	results, _ := admin.Search(ctx, "air horn")
+	if len(results) != 2 {
+		t.Fatalf("Search = %+v, want 2 results", results)
+	}
+	// "airhorn" contains both words; "air raid siren" only "air".
+	if results[0].Option.Name != "airhorn" || results[0].Score != 2 || results[1].Score != 1 {
+		t.Errorf("Search = %+v", results)
+	}
+	if womp, _ := admin.Search(ctx, "womp"); len(womp) != 1 || womp[0].Option.Name != "sad trombone" {
+		t.Errorf("Search(womp) = %+v", womp)
+	}
+}
@@ -0,0 +1,136 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/playstats"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/usage"
+)
+
+// maxRecentPlays is how many of the newest plays are kept in memory for
+// counting plays over a window of time; all-time counts include every play.
+const maxRecentPlays = 10000
+
+// PlayStatsService records dispatched play commands and answers what is
+// played most, by a server or by one user. Options are counted by normalized
+// name and shown by the name they were last played as.
+type PlayStatsService struct {
+	repo playstats.Repository
+	now  func() time.Time
+
+	mu      sync.RWMutex
+	plays   []playstats.Play          // newest maxRecentPlays, oldest first
+	byGuild map[string]map[string]int // guild -> option -> plays
+	byUser  map[string]map[string]int // user -> option -> plays
+	names   map[string]string         // normalized option -> display name
+}
+
+// NewPlayStatsService creates a PlayStatsService, loading the play log.
+func NewPlayStatsService(repo playstats.Repository) (*PlayStatsService, error) {
+	s := &PlayStatsService{
+		repo:    repo,
+		now:     time.Now,
+		byGuild: make(map[string]map[string]int),
+		byUser:  make(map[string]map[string]int),
+		names:   make(map[string]string),
+	}
+	plays, err := repo.All()
+	if err != nil {
+		return nil, fmt.Errorf("read play log: %w", err)
+	}
+	for _, p := range plays {
+		s.add(p)
+	}
+	return s, nil
+}
+
+// Record logs a play of option, attributed to the requester in ctx.
+func (s *PlayStatsService) Record(ctx context.Context, option string, match playstats.Match) {
+	a := usage.AttributionFrom(ctx)
+	p := playstats.Play{
+		Time:      s.now(),
+		GuildID:   a.GuildID,
+		ChannelID: a.ChannelID,
+		UserID:    a.UserID,
+		Option:    option,
+		Match:     match,
+	}
+	if err := s.repo.Append(p); err != nil {
+		slog.ErrorContext(ctx, "failed to record play", "option", option, "err", err)
+	}
+	s.mu.Lock()
+	s.add(p)
+	s.mu.Unlock()
+}
+
+// add counts a play. Callers hold s.mu, except during construction.
+func (s *PlayStatsService) add(p playstats.Play) {
+	key := bot.NormalizeName(p.Option)
+	if key == "" {
+		return
+	}
+	s.plays = append(s.plays, p)
+	if len(s.plays) > maxRecentPlays {
+		s.plays = slices.Delete(s.plays, 0, len(s.plays)-maxRecentPlays)
+	}
+	s.names[key] = p.Option
+	increment(s.byGuild, p.GuildID, key)
+	increment(s.byUser, p.UserID, key)
+}
+
+func increment(counts map[string]map[string]int, id, key string) {
+	if counts[id] == nil {
+		counts[id] = make(map[string]int)
+	}
+	counts[id][key]++
+}
+
+// Top returns a guild's most played options since a time (all time when
+// zero), keeping at most n. Since a time, only the newest maxRecentPlays
+// plays are counted.
+func (s *PlayStatsService) Top(guildID string, since time.Time, n int) []playstats.Count {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := s.byGuild[guildID]
+	if !since.IsZero() {
+		counts = make(map[string]int)
+		for i := len(s.plays) - 1; i >= 0 && !s.plays[i].Time.Before(since); i-- {
+			if p := s.plays[i]; p.GuildID == guildID {
+				counts[bot.NormalizeName(p.Option)]++
+			}
+		}
+	}
+	return s.named(counts, n)
+}
+
+// Favourites returns the options a user plays most, keeping at most n.
+func (s *PlayStatsService) Favourites(userID string, n int) []playstats.Count {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.named(s.byUser[userID], n)
+}
+
+// named ranks counts keyed by normalized name and swaps in display names.
+func (s *PlayStatsService) named(counts map[string]int, n int) []playstats.Count {
+	ranked := playstats.Ranked(counts, n)
+	for i := range ranked {
+		ranked[i].Option = s.names[ranked[i].Option]
+	}
+	return ranked
+}
+
+// Prior returns how often a user, and everyone in a guild, has played an
+// option. Matching uses these to decide between equally good candidates.
+func (s *PlayStatsService) Prior(guildID, userID, option string) (userPlays, guildPlays int) {
+	key := bot.NormalizeName(option)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.byUser[userID][key], s.byGuild[guildID][key]
+}
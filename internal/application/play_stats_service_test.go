@@ -0,0 +1,106 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/playstats"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/usage"
+)
+
+type memoryPlayLog struct {
+	plays []playstats.Play
+}
+
+func (l *memoryPlayLog) Append(p playstats.Play) error {
+	l.plays = append(l.plays, p)
+	return nil
+}
+
+func (l *memoryPlayLog) All() ([]playstats.Play, error) {
+	return l.plays, nil
+}
+
+// newTestPlayStats creates a PlayStatsService with the given plays already
+// logged, each played by user in guild g1 at now.
+func newTestPlayStats(t *testing.T, now time.Time, plays map[string][]string) *PlayStatsService {
+	t.Helper()
+	log := &memoryPlayLog{}
+	for user, options := range plays {
+		for _, opt := range options {
+			log.plays = append(log.plays, playstats.Play{Time: now, GuildID: "g1", UserID: user, Option: opt, Match: playstats.MatchExact})
+		}
+	}
+	svc, err := NewPlayStatsService(log)
+	if err != nil {
+		t.Fatalf("NewPlayStatsService: %v", err)
+	}
+	svc.now = func() time.Time { return now }
+	return svc
+}
+
+func TestPlayStatsService_Top(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	svc := newTestPlayStats(t, now.AddDate(0, 0, -30), map[string][]string{
+		"u1": {"airhorn", "airhorn", "airhorn"},
+	})
+	svc.now = func() time.Time { return now }
+
+	ctx := usage.WithAttribution(context.Background(), usage.Attribution{GuildID: "g1", UserID: "u2"})
+	svc.Record(ctx, "Sad Trombone", playstats.MatchLLM)
+	svc.Record(ctx, "sad trombone", playstats.MatchExact)
+	svc.Record(ctx, "airhorn", playstats.MatchExact)
+
+	all := svc.Top("g1", time.Time{}, 10)
+	if len(all) != 2 || all[0] != (playstats.Count{Option: "airhorn", Plays: 4}) {
+		t.Fatalf("all time = %+v", all)
+	}
+	if all[1] != (playstats.Count{Option: "sad trombone", Plays: 2}) {
+		t.Errorf("plays should be counted by normalized name, got %+v", all[1])
+	}
+
+	week := svc.Top("g1", now.AddDate(0, 0, -7), 10)
+	if len(week) != 2 || week[0].Option != "sad trombone" || week[1] != (playstats.Count{Option: "airhorn", Plays: 1}) {
+		t.Errorf("week = %+v", week)
+	}
+
+	if got := svc.Top("g2", time.Time{}, 10); len(got) != 0 {
+		t.Errorf("other guild = %+v, want none", got)
+	}
+	if got := svc.Top("g1", time.Time{}, 1); len(got) != 1 {
+		t.Errorf("limit: got %d counts, want 1", len(got))
+	}
+}
+
+func TestPlayStatsService_FavouritesAndPrior(t *testing.T) {
+	svc := newTestPlayStats(t, time.Now(), map[string][]string{
+		"u1": {"wow", "wow", "bruh"},
+		"u2": {"bruh", "bruh"},
+	})
+
+	favs := svc.Favourites("u1", 0)
+	if len(favs) != 2 || favs[0] != (playstats.Count{Option: "wow", Plays: 2}) {
+		t.Errorf("favourites = %+v", favs)
+	}
+
+	user, guild := svc.Prior("g1", "u1", "BRUH")
+	if user != 1 || guild != 3 {
+		t.Errorf("Prior = %d, %d, want 1, 3", user, guild)
+	}
+}
+
+func TestPlayStatsService_KeepsRecentPlays(t *testing.T) {
+	svc := newTestPlayStats(t, time.Now(), nil)
+	ctx := usage.WithAttribution(context.Background(), usage.Attribution{GuildID: "g1", UserID: "u1"})
+	for i := 0; i < maxRecentPlays+5; i++ {
+		svc.Record(ctx, "airhorn", playstats.MatchExact)
+	}
+
+	if len(svc.plays) != maxRecentPlays {
+		t.Errorf("kept %d plays, want %d", len(svc.plays), maxRecentPlays)
+	}
+	if all := svc.Top("g1", time.Time{}, 1); all[0].Plays != maxRecentPlays+5 {
+		t.Errorf("all time = %+v, want every play counted", all)
+	}
+}
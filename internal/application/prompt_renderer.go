@@ -0,0 +1,83 @@
+package application
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/prompt"
+)
+
+// PromptRenderer renders system prompt templates for each chat request.
+type PromptRenderer struct {
+	version     string
+	location    *time.Location
+	playOptions bot.PlayOptionsService // may be nil
+	now         func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]*prompt.Template // prompt text -> parsed template
+}
+
+// NewPromptRenderer creates a PromptRenderer. Times are shown in location;
+// playOptions may be nil, in which case PlayOptionCount is zero.
+func NewPromptRenderer(version string, location *time.Location, playOptions bot.PlayOptionsService) *PromptRenderer {
+	if location == nil {
+		location = time.Local
+	}
+	return &PromptRenderer{
+		version:     version,
+		location:    location,
+		playOptions: playOptions,
+		now:         time.Now,
+		cache:       make(map[string]*prompt.Template),
+	}
+}
+
+// Render renders the system prompt text for req. If the text isn't a valid
+// template it is logged and used as is.
+func (r *PromptRenderer) Render(ctx context.Context, text string, req bot.ChatRequest) string {
+	tmpl, err := r.template(text)
+	if err != nil {
+		slog.WarnContext(ctx, "system prompt template error, using it unrendered", "err", err)
+		return text
+	}
+
+	data := prompt.NewData(r.now().In(r.location))
+	data.GuildName = req.GuildName
+	data.ChannelName = req.ChannelName
+	data.Requester = req.UserName
+	data.VoiceParticipants = req.VoiceParticipants
+	data.BotVersion = r.version
+	if r.playOptions != nil {
+		if options, err := r.playOptions.GetOptions(ctx); err == nil {
+			data.PlayOptionCount = len(options)
+		} else {
+			slog.WarnContext(ctx, "system prompt: failed to count play options", "err", err)
+		}
+	}
+
+	rendered, err := tmpl.Render(data)
+	if err != nil {
+		slog.WarnContext(ctx, "system prompt render error, using it unrendered", "err", err)
+		return text
+	}
+	return rendered
+}
+
+// template returns the parsed template for text, parsing it on first use.
+func (r *PromptRenderer) template(text string) (*prompt.Template, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if tmpl, ok := r.cache[text]; ok {
+		return tmpl, nil
+	}
+	tmpl, err := prompt.Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	r.cache[text] = tmpl
+	return tmpl, nil
+}
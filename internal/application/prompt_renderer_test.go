@@ -0,0 +1,54 @@
+package application
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+)
+
+func TestChatService_RendersSystemPromptPerRequest(t *testing.T) {
+	llm := &scriptedLLM{chatReply: "ok"}
+	svc, _ := newSummarizingChatService(llm)
+	svc.systemPrompt = "You are Laserbeak {{.BotVersion}} in #{{.ChannelName}} ({{.GuildName}}). " +
+		"{{.Requester}} asked at {{.Time}}. {{.PlayOptionCount}} sounds. Voice: {{join .VoiceParticipants \", \"}}."
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	options := &mockPlayOptions{options: []bot.PlayOption{{Name: "a"}, {Name: "b"}}}
+	renderer := NewPromptRenderer("1.0.0", tokyo, options)
+	renderer.now = func() time.Time { return time.Date(2025, 6, 1, 0, 30, 0, 0, time.UTC) }
+	svc.SetPromptRenderer(renderer)
+
+	req := chatRequest("u1", "hi")
+	req.UserName = "Sam"
+	req.GuildName = "Lasers"
+	req.ChannelName = "general"
+	req.VoiceParticipants = []string{"Sam", "Alex"}
+	if _, err := svc.HandleMessage(context.Background(), req); err != nil {
+		t.Fatalf("HandleMessage error: %v", err)
+	}
+
+	want := "You are Laserbeak 1.0.0 in #general (Lasers). Sam asked at 09:30 JST. 2 sounds. Voice: Sam, Alex."
+	if got := llm.calls[0][0].Content; !strings.HasPrefix(got, want) {
+		t.Errorf("system prompt =\n%q\nwant prefix\n%q", got, want)
+	}
+
+	// The next request is rendered afresh for its own requester.
+	req.UserName = "Alex"
+	svc.HandleMessage(context.Background(), req)
+	if got := llm.calls[1][0].Content; !strings.Contains(got, "Alex asked") {
+		t.Errorf("second system prompt = %q, want it rendered for Alex", got)
+	}
+}
+
+func TestPersonaService_RejectsInvalidTemplate(t *testing.T) {
+	svc := newTestPersonaService(t)
+	if _, err := svc.Set("ch", "Hello {{.Nickname}}", "u1"); err == nil {
+		t.Error("expected error for a persona referencing an unknown variable")
+	}
+}
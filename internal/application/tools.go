@@ -0,0 +1,81 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+)
+
+// maxToolRounds bounds how many times one chat turn may go back to the LLM
+// with tool results before it must answer.
+const maxToolRounds = 5
+
+// ToolFunc executes a tool call. args is the JSON arguments object sent by
+// the LLM; req identifies who asked and where. The returned text is fed back
+// to the LLM as the tool result.
+type ToolFunc func(ctx context.Context, req bot.ChatRequest, args json.RawMessage) (string, error)
+
+// Tool is a function the chat LLM may call.
+type Tool struct {
+	Definition bot.ToolDefinition
+	Run        ToolFunc
+}
+
+// ToolRegistry holds the tools offered to the chat LLM.
+type ToolRegistry struct {
+	tools map[string]Tool
+}
+
+// NewToolRegistry creates an empty ToolRegistry.
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{tools: make(map[string]Tool)}
+}
+
+// Register adds a tool, replacing any tool with the same name.
+func (r *ToolRegistry) Register(t Tool) {
+	r.tools[t.Definition.Name] = t
+}
+
+// Len returns the number of registered tools.
+func (r *ToolRegistry) Len() int {
+	return len(r.tools)
+}
+
+// Definitions returns the registered tool definitions sorted by name.
+func (r *ToolRegistry) Definitions() []bot.ToolDefinition {
+	defs := make([]bot.ToolDefinition, 0, len(r.tools))
+	for _, t := range r.tools {
+		defs = append(defs, t.Definition)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+// Call executes a tool call and returns its result. Failures are returned as
+// text so the LLM can see what went wrong and adjust.
+func (r *ToolRegistry) Call(ctx context.Context, req bot.ChatRequest, call bot.ToolCall) string {
+	t, ok := r.tools[call.Name]
+	if !ok {
+		return fmt.Sprintf("error: unknown tool %q", call.Name)
+	}
+
+	args := json.RawMessage(call.Arguments)
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+	if !json.Valid(args) {
+		return "error: arguments are not valid JSON"
+	}
+
+	result, err := t.Run(ctx, req, args)
+	if err != nil {
+		slog.WarnContext(ctx, "tool failed", "tool", call.Name, "err", err)
+		return "error: " + err.Error()
+	}
+	slog.InfoContext(ctx, "tool called", "tool", call.Name)
+	return result
+}
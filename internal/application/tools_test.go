@@ -0,0 +1,196 @@
+package application
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/conversation"
+	"github.com/adrock-miles/go-laserbeak/internal/infrastructure/persistence"
+)
+
+// toolScriptLLM returns its scripted replies in order, recording what it was sent.
+type toolScriptLLM struct {
+	replies []bot.LLMMessage
+	calls   [][]bot.LLMMessage
+}
+
+func (m *toolScriptLLM) ChatCompletion(_ context.Context, msgs []bot.LLMMessage) (string, error) {
+	reply, _ := m.ChatCompletionWithTools(context.Background(), msgs, nil)
+	return reply.Content, nil
+}
+
+func (m *toolScriptLLM) ChatCompletionWithTools(_ context.Context, msgs []bot.LLMMessage, _ []bot.ToolDefinition) (bot.LLMMessage, error) {
+	m.calls = append(m.calls, msgs)
+	if len(m.replies) == 0 {
+		return bot.LLMMessage{Role: "assistant", Content: "done"}, nil
+	}
+	reply := m.replies[0]
+	m.replies = m.replies[1:]
+	return reply, nil
+}
+
+type recordingActions struct {
+	commands []string
+	joined   int
+	left     int
+}
+
+func (a *recordingActions) SendCommand(_ context.Context, _ bot.ChatRequest, text string) error {
+	a.commands = append(a.commands, text)
+	return nil
+}
+
+func (a *recordingActions) JoinVoice(context.Context, bot.ChatRequest) error {
+	a.joined++
+	return nil
+}
+
+func (a *recordingActions) LeaveVoice(context.Context, bot.ChatRequest) error {
+	a.left++
+	return nil
+}
+
+func toolCall(id, name, args string) bot.LLMMessage {
+	return bot.LLMMessage{Role: "assistant", ToolCalls: []bot.ToolCall{{ID: id, Name: name, Arguments: args}}}
+}
+
+func newToolChatService(llm bot.LLMService, tools *ToolRegistry) (*ChatService, *persistence.InMemoryConversationRepo) {
+	repo := persistence.NewInMemoryConversationRepo()
+	svc := NewChatService(repo, llm, "You are Laserbeak.", 50, conversation.ContextPolicy{})
+	svc.SetTools(tools)
+	return svc, repo
+}
+
+func TestChatService_RunsToolsUntilAnswer(t *testing.T) {
+	llm := &toolScriptLLM{replies: []bot.LLMMessage{
+		toolCall("1", "search_play_options", `{"query":"upbeat"}`),
+		toolCall("2", "play_sound", `{"name":"Upbeat Funk"}`),
+		{Role: "assistant", Content: "Playing Upbeat Funk!"},
+	}}
+	actions := &recordingActions{}
+	options := &mockPlayOptions{options: []bot.PlayOption{{Name: "sad trombone"}, {Name: "upbeat funk"}}}
+	svc, repo := newToolChatService(llm, NewBuiltinTools(options, actions, nil, nil))
+
+	reply, err := svc.HandleMessage(context.Background(), chatRequest("u1", "play something upbeat"))
+	if err != nil {
+		t.Fatalf("HandleMessage error: %v", err)
+	}
+	if reply != "Playing Upbeat Funk!" {
+		t.Errorf("reply = %q", reply)
+	}
+	if len(actions.commands) != 1 || actions.commands[0] != "!play upbeat funk" {
+		t.Errorf("commands = %v, want [!play upbeat funk]", actions.commands)
+	}
+
+	// The second request carries the first tool call and its result.
+	if len(llm.calls) != 3 {
+		t.Fatalf("LLM calls = %d, want 3", len(llm.calls))
+	}
+	second := llm.calls[1]
+	result := second[len(second)-1]
+	if result.Role != "tool" || result.ToolCallID != "1" || !strings.Contains(result.Content, "upbeat funk") {
+		t.Errorf("tool result = %+v", result)
+	}
+
+	// Only the user message and final answer are kept in history.
+	conv, _ := repo.FindByChannel("ch")
+	if len(conv.Messages) != 2 || conv.Messages[1].Content != "Playing Upbeat Funk!" {
+		t.Errorf("history = %+v", conv.Messages)
+	}
+}
+
+func TestChatService_ToolLoopIsBounded(t *testing.T) {
+	llm := &toolScriptLLM{}
+	for i := 0; i < maxToolRounds+1; i++ {
+		llm.replies = append(llm.replies, toolCall("x", "current_time", `{}`))
+	}
+	svc, _ := newToolChatService(llm, NewBuiltinTools(nil, nil, nil, nil))
+
+	if _, err := svc.HandleMessage(context.Background(), chatRequest("u1", "time?")); err == nil {
+		t.Fatal("expected error when the LLM never stops calling tools")
+	}
+	if len(llm.calls) != maxToolRounds {
+		t.Errorf("LLM calls = %d, want %d", len(llm.calls), maxToolRounds)
+	}
+}
+
+func TestToolRegistry_ReportsErrorsToLLM(t *testing.T) {
+	actions := &recordingActions{}
+	options := &mockPlayOptions{options: []bot.PlayOption{{Name: "airhorn"}, {Name: "airhorn remix"}}}
+	r := NewBuiltinTools(options, actions, nil, nil)
+	req := chatRequest("u1", "")
+
+	tests := []struct {
+		call bot.ToolCall
+		want string
+	}{
+		{bot.ToolCall{Name: "nope"}, `error: unknown tool "nope"`},
+		{bot.ToolCall{Name: "play_sound", Arguments: `{"name":`}, "error: arguments are not valid JSON"},
+		{bot.ToolCall{Name: "play_sound", Arguments: `{"name":"horn"}`}, "similar options: airhorn, airhorn remix"},
+		{bot.ToolCall{Name: "current_time", Arguments: `{"timezone":"Mars/Olympus"}`}, "error: unknown time zone"},
+	}
+	for _, tt := range tests {
+		if got := r.Call(context.Background(), req, tt.call); !strings.Contains(got, tt.want) {
+			t.Errorf("Call(%s %s) = %q, want it to contain %q", tt.call.Name, tt.call.Arguments, got, tt.want)
+		}
+	}
+	if len(actions.commands) != 0 {
+		t.Errorf("commands = %v, want none", actions.commands)
+	}
+}
+
+func TestBuiltinTools_CurrentTimeDefaultsToConfiguredZone(t *testing.T) {
+	r := NewBuiltinTools(nil, nil, nil, time.FixedZone("BOT", 5*60*60))
+	got := r.Call(context.Background(), chatRequest("u1", ""), bot.ToolCall{Name: "current_time", Arguments: `{}`})
+	if !strings.HasSuffix(got, " BOT") {
+		t.Errorf("current_time = %q, want the time in the configured zone", got)
+	}
+}
+
+func TestBuiltinTools_MatchAliasesAndTags(t *testing.T) {
+	actions := &recordingActions{}
+	options := &mockPlayOptions{options: []bot.PlayOption{
+		{Name: "airhorn", Aliases: []string{"fog horn"}},
+		{Name: "sad trombone", Tags: []string{"fail"}},
+	}}
+	r := NewBuiltinTools(options, actions, nil, nil)
+	req := chatRequest("u1", "")
+
+	if got := r.Call(context.Background(), req, bot.ToolCall{Name: "search_play_options", Arguments: `{"query":"fail"}`}); !strings.Contains(got, "sad trombone") {
+		t.Errorf("search by tag = %q, want it to contain sad trombone", got)
+	}
+	r.Call(context.Background(), req, bot.ToolCall{Name: "play_sound", Arguments: `{"name":"Fog_Horn"}`})
+	if len(actions.commands) != 1 || actions.commands[0] != "!play airhorn" {
+		t.Errorf("commands = %v, want [!play airhorn]", actions.commands)
+	}
+}
+
+func TestBuiltinTools_VoiceAndRandom(t *testing.T) {
+	actions := &recordingActions{}
+	r := NewBuiltinTools(&mockPlayOptions{}, actions, nil, nil)
+	req := chatRequest("u1", "")
+
+	r.Call(context.Background(), req, bot.ToolCall{Name: "join_voice"})
+	r.Call(context.Background(), req, bot.ToolCall{Name: "play_sound", Arguments: `{"name":"random"}`})
+	r.Call(context.Background(), req, bot.ToolCall{Name: "stop_playback"})
+	r.Call(context.Background(), req, bot.ToolCall{Name: "leave_voice"})
+
+	if actions.joined != 1 || actions.left != 1 {
+		t.Errorf("joined=%d left=%d, want 1 each", actions.joined, actions.left)
+	}
+	if strings.Join(actions.commands, ",") != "!pr,!stop" {
+		t.Errorf("commands = %v, want [!pr !stop]", actions.commands)
+	}
+}
+
+func TestChatService_PlainLLMIgnoresTools(t *testing.T) {
+	llm := &scriptedLLM{}
+	svc, _ := newToolChatService(llm, NewBuiltinTools(nil, nil, nil, nil))
+
+	if _, err := svc.HandleMessage(context.Background(), chatRequest("u1", "hi")); err != nil {
+		t.Fatalf("HandleMessage error: %v", err)
+	}
+}
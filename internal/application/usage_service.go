@@ -0,0 +1,150 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/usage"
+)
+
+// UsageService records the usage reported by API adapters to the ledger,
+// keeps running totals for the current day and month, and enforces quotas.
+type UsageService struct {
+	repo   usage.Repository
+	prices map[string]usage.Price // by model
+	quotas []usage.Quota
+	loc    *time.Location // where days and months begin
+	now    func() time.Time
+
+	mu      sync.Mutex
+	windows map[windowKey]*usageWindow
+}
+
+type windowKey struct {
+	scope  usage.Scope
+	period usage.Period
+}
+
+// usageWindow holds the totals of one scope over the current period.
+type usageWindow struct {
+	start time.Time
+	byID  map[string]*usage.Totals
+}
+
+// NewUsageService creates a UsageService, restoring this month's totals from
+// the ledger.
+func NewUsageService(repo usage.Repository, prices map[string]usage.Price, quotas []usage.Quota, loc *time.Location) (*UsageService, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	s := &UsageService{
+		repo:    repo,
+		prices:  prices,
+		quotas:  quotas,
+		loc:     loc,
+		now:     time.Now,
+		windows: make(map[windowKey]*usageWindow),
+	}
+
+	records, err := repo.Since(usage.PeriodMonthly.Start(s.now().In(loc)))
+	if err != nil {
+		return nil, fmt.Errorf("read usage ledger: %w", err)
+	}
+	for _, r := range records {
+		s.add(r)
+	}
+	return s, nil
+}
+
+// Record implements usage.Recorder. It attributes the record to the request
+// in ctx, prices it and appends it to the ledger.
+func (s *UsageService) Record(ctx context.Context, r usage.Record) {
+	a := usage.AttributionFrom(ctx)
+	r.GuildID, r.ChannelID, r.UserID = a.GuildID, a.ChannelID, a.UserID
+	if r.Time.IsZero() {
+		r.Time = s.now()
+	}
+	if price, ok := s.prices[r.Model]; ok {
+		r.Cost = price.Cost(r)
+	}
+
+	if err := s.repo.Append(r); err != nil {
+		slog.ErrorContext(ctx, "failed to record usage", "model", r.Model, "err", err)
+	}
+	s.mu.Lock()
+	s.add(r)
+	s.mu.Unlock()
+}
+
+// add counts a record in every current window it falls in. Callers hold s.mu,
+// except during construction.
+func (s *UsageService) add(r usage.Record) {
+	a := usage.Attribution{GuildID: r.GuildID, ChannelID: r.ChannelID, UserID: r.UserID}
+	t := r.Time.In(s.loc)
+	for _, scope := range usage.Scopes {
+		id := a.ID(scope)
+		if id == "" {
+			continue
+		}
+		for _, period := range usage.Periods {
+			w := s.window(scope, period)
+			if !period.Start(t).Equal(w.start) {
+				continue // from an earlier period
+			}
+			totals := w.byID[id]
+			if totals == nil {
+				totals = &usage.Totals{}
+				w.byID[id] = totals
+			}
+			totals.Add(r)
+		}
+	}
+}
+
+// window returns the totals of a scope for the current period, starting
+// afresh when a new period has begun.
+func (s *UsageService) window(scope usage.Scope, period usage.Period) *usageWindow {
+	key := windowKey{scope, period}
+	start := period.Start(s.now().In(s.loc))
+	w := s.windows[key]
+	if w == nil || !w.start.Equal(start) {
+		w = &usageWindow{start: start, byID: make(map[string]*usage.Totals)}
+		s.windows[key] = w
+	}
+	return w
+}
+
+// Check returns a *usage.QuotaError if a quota covering a has been reached.
+func (s *UsageService) Check(a usage.Attribution) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, q := range s.quotas {
+		id := a.ID(q.Scope)
+		if id == "" {
+			continue
+		}
+		if totals := s.window(q.Scope, q.Period).byID[id]; totals != nil && q.Exceeded(*totals) {
+			return &usage.QuotaError{Quota: q, Reset: q.Period.End(s.now().In(s.loc))}
+		}
+	}
+	return nil
+}
+
+// Totals returns the usage of a user, channel or guild in the current period.
+func (s *UsageService) Totals(scope usage.Scope, id string, period usage.Period) usage.Totals {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if totals := s.window(scope, period).byID[id]; totals != nil {
+		return *totals
+	}
+	return usage.Totals{}
+}
+
+// Quotas returns the configured quotas.
+func (s *UsageService) Quotas() []usage.Quota {
+	return s.quotas
+}
@@ -0,0 +1,98 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/usage"
+	"github.com/adrock-miles/go-laserbeak/internal/infrastructure/persistence"
+)
+
+func newTestUsageService(t *testing.T, ledger usage.Repository, now *time.Time, quotas ...usage.Quota) *UsageService {
+	t.Helper()
+	prices := map[string]usage.Price{"gpt-test": {Input: 1, Output: 2}}
+	s, err := NewUsageService(ledger, prices, quotas, time.UTC)
+	if err != nil {
+		t.Fatalf("NewUsageService: %v", err)
+	}
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func TestUsageService_RecordAttributesAndPrices(t *testing.T) {
+	ledger, _ := persistence.NewFileUsageLedger("")
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	s := newTestUsageService(t, ledger, &now)
+
+	ctx := usage.WithAttribution(context.Background(), usage.Attribution{GuildID: "g", ChannelID: "c", UserID: "u"})
+	s.Record(ctx, usage.Record{Model: "gpt-test", PromptTokens: 1_000_000, CompletionTokens: 500_000})
+
+	got, _ := ledger.Since(time.Time{})
+	if len(got) != 1 || got[0].UserID != "u" || got[0].GuildID != "g" || got[0].Cost != 2 || !got[0].Time.Equal(now) {
+		t.Errorf("ledger = %+v", got)
+	}
+	if totals := s.Totals(usage.ScopeGuild, "g", usage.PeriodMonthly); totals.Requests != 1 || totals.Tokens() != 1_500_000 {
+		t.Errorf("guild totals = %+v", totals)
+	}
+}
+
+func TestUsageService_QuotaBlocksUntilReset(t *testing.T) {
+	ledger, _ := persistence.NewFileUsageLedger("")
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	s := newTestUsageService(t, ledger, &now, usage.Quota{Scope: usage.ScopeUser, Period: usage.PeriodDaily, Tokens: 100})
+
+	alice := usage.Attribution{UserID: "alice"}
+	ctx := usage.WithAttribution(context.Background(), alice)
+	s.Record(ctx, usage.Record{Model: "gpt-test", PromptTokens: 90})
+	if err := s.Check(alice); err != nil {
+		t.Fatalf("Check below quota: %v", err)
+	}
+
+	s.Record(ctx, usage.Record{Model: "gpt-test", CompletionTokens: 10})
+	err := s.Check(alice)
+	var quotaErr *usage.QuotaError
+	if !errors.As(err, &quotaErr) || !quotaErr.Reset.Equal(time.Date(2026, 5, 11, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Check = %v, want a quota error resetting at midnight", err)
+	}
+	if err := s.Check(usage.Attribution{UserID: "bob"}); err != nil {
+		t.Errorf("other users should not be blocked: %v", err)
+	}
+
+	now = now.Add(12 * time.Hour)
+	if err := s.Check(alice); err != nil {
+		t.Errorf("Check the next day: %v", err)
+	}
+}
+
+func TestUsageService_RestoresTotalsFromLedger(t *testing.T) {
+	ledger, _ := persistence.NewFileUsageLedger("")
+	now := time.Now().UTC()
+	ledger.Append(usage.Record{Time: now, UserID: "u", Model: "gpt-test", PromptTokens: 7})
+	ledger.Append(usage.Record{Time: now.AddDate(0, -2, 0), UserID: "u", Model: "gpt-test", PromptTokens: 1000})
+
+	s := newTestUsageService(t, ledger, &now)
+	if got := s.Totals(usage.ScopeUser, "u", usage.PeriodMonthly); got.Tokens() != 7 {
+		t.Errorf("restored monthly tokens = %d, want 7", got.Tokens())
+	}
+}
+
+func TestChatService_QuotaExceeded(t *testing.T) {
+	ledger, _ := persistence.NewFileUsageLedger("")
+	now := time.Now()
+	s := newTestUsageService(t, ledger, &now, usage.Quota{Scope: usage.ScopeChannel, Period: usage.PeriodMonthly, Cost: 0.01})
+	s.Record(usage.WithAttribution(context.Background(), usage.Attribution{ChannelID: "ch"}), usage.Record{Model: "x", Cost: 1})
+
+	llm := &scriptedLLM{chatReply: "hi"}
+	chat, _ := newSummarizingChatService(llm)
+	chat.SetUsage(s)
+
+	_, err := chat.HandleMessage(context.Background(), chatRequest("u1", "hello"))
+	if !errors.Is(err, usage.ErrQuotaExceeded) {
+		t.Fatalf("HandleMessage err = %v, want a quota error", err)
+	}
+	if len(llm.calls) != 0 {
+		t.Errorf("LLM called %d times despite the quota", len(llm.calls))
+	}
+}
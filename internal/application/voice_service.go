@@ -3,18 +3,16 @@ package application
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"sort"
 	"strings"
 
 	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/playstats"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/usage"
+	"github.com/adrock-miles/go-laserbeak/internal/logging"
 )
 
-// VoiceCommand represents a parsed voice command result.
-type VoiceCommand struct {
-	// Text is the message to send to the output text channel.
-	Text string
-}
-
 // VoiceService handles voice-to-text-to-command pipeline.
 // It transcribes audio, checks for the wake phrase, and parses voice commands.
 // For "play" commands, it uses the LLM to match against available options.
@@ -23,6 +21,8 @@ type VoiceService struct {
 	llm         bot.LLMService
 	playOptions bot.PlayOptionsService
 	wakePhrase  string
+	usage       *UsageService
+	stats       *PlayStatsService
 }
 
 // NewVoiceService creates a new VoiceService.
@@ -36,33 +36,57 @@ func NewVoiceService(stt bot.STTService, wakePhrase string, llm bot.LLMService,
 	}
 }
 
+// SetUsage enforces usage quotas on transcription, for the requester
+// attributed in the context.
+func (s *VoiceService) SetUsage(u *UsageService) {
+	s.usage = u
+}
+
+// SetPlayStats uses play history to decide between equally good matches.
+func (s *VoiceService) SetPlayStats(stats *PlayStatsService) {
+	s.stats = stats
+}
+
 // HandleVoice transcribes audio and parses voice commands.
-// Returns the command text to send to chat, or empty string if no valid command.
-func (s *VoiceService) HandleVoice(ctx context.Context, channelID, userID string, audioWAV []byte) (string, error) {
+// Returns the command to send to chat, with empty Text if no valid command.
+func (s *VoiceService) HandleVoice(ctx context.Context, channelID, userID string, audioWAV []byte) (bot.VoiceCommand, error) {
+	_, command, err := s.HandleVoiceMessage(ctx, channelID, userID, audioWAV)
+	return command, err
+}
+
+// HandleVoiceMessage is HandleVoice that also returns the transcription, so
+// callers can treat speech that isn't a command as a chat message.
+func (s *VoiceService) HandleVoiceMessage(ctx context.Context, channelID, userID string, audioWAV []byte) (transcript string, command bot.VoiceCommand, err error) {
+	if s.usage != nil {
+		if err := s.usage.Check(usage.AttributionFrom(ctx)); err != nil {
+			return "", bot.VoiceCommand{}, err
+		}
+	}
+
 	text, err := s.stt.Transcribe(ctx, audioWAV)
 	if err != nil {
-		return "", fmt.Errorf("transcribe audio: %w", err)
+		return "", bot.VoiceCommand{}, fmt.Errorf("transcribe audio: %w", err)
 	}
 
 	text = strings.TrimSpace(text)
 	if text == "" {
-		return "", nil
+		return "", bot.VoiceCommand{}, nil
 	}
 
-	log.Printf("voice transcription from user %s: %s", userID, text)
+	slog.DebugContext(ctx, "voice transcription", logging.KeyTranscript, text)
 
-	cmd, ok := s.parseCommand(ctx, text)
+	cmd, ok := s.parseCommand(ctx, userID, text)
 	if !ok {
-		return "", nil
+		return text, bot.VoiceCommand{}, nil
 	}
 
-	log.Printf("voice command from user %s: %s", userID, cmd.Text)
-	return cmd.Text, nil
+	slog.InfoContext(ctx, "voice command", logging.KeyTranscript, cmd.Text, "option", cmd.Option, "match", string(cmd.Match))
+	return text, cmd, nil
 }
 
 // parseCommand checks if the transcription contains the wake phrase
 // (optionally preceded by filler words like "hey", "yo") and parses the subsequent command.
-func (s *VoiceService) parseCommand(ctx context.Context, transcription string) (VoiceCommand, bool) {
+func (s *VoiceService) parseCommand(ctx context.Context, userID, transcription string) (bot.VoiceCommand, bool) {
 	lower := strings.ToLower(transcription)
 
 	// Normalize common alternate spellings (e.g. "lazer" → "laser")
@@ -71,7 +95,7 @@ func (s *VoiceService) parseCommand(ctx context.Context, transcription string) (
 	// Find wake phrase as a whole word, allowing up to 2 filler words before it
 	rest, found := s.extractAfterWakePhrase(normalized)
 	if !found {
-		return VoiceCommand{}, false
+		return bot.VoiceCommand{}, false
 	}
 
 	// Strip punctuation for command matching (STT may transcribe "Stop!" or "stop.")
@@ -85,21 +109,21 @@ func (s *VoiceService) parseCommand(ctx context.Context, transcription string) (
 
 	switch {
 	case strings.HasPrefix(stripped, "stop"):
-		return VoiceCommand{Text: "!stop"}, true
+		return bot.VoiceCommand{Text: "!stop"}, true
 
 	case strings.HasPrefix(stripped, "play"):
 		query := strings.TrimSpace(stripped[len("play"):])
 		if query == "" {
-			return VoiceCommand{}, false
+			return bot.VoiceCommand{}, false
 		}
 		if strings.Contains(query, "random") {
-			return VoiceCommand{Text: "!pr"}, true
+			return bot.VoiceCommand{Text: "!pr"}, true
 		}
-		matched := s.matchPlayQuery(ctx, query)
-		return VoiceCommand{Text: "!play " + matched}, true
+		matched, match := s.matchPlayQuery(ctx, userID, query)
+		return bot.VoiceCommand{Text: "!play " + matched, Option: matched, Match: match}, true
 	}
 
-	return VoiceCommand{}, false
+	return bot.VoiceCommand{}, false
 }
 
 // extractAfterWakePhrase finds the wake phrase in the text and returns everything
@@ -118,27 +142,49 @@ func (s *VoiceService) extractAfterWakePhrase(text string) (string, bool) {
 	return "", false
 }
 
-// matchPlayQuery tries to match a spoken query against the available play options
-// using the LLM. Falls back to the raw query if matching is unavailable.
-func (s *VoiceService) matchPlayQuery(ctx context.Context, query string) string {
-	if s.playOptions == nil || s.llm == nil {
-		return query
+// matchPlayQuery matches a spoken query against the available play options:
+// an option named by the query or one of its aliases, then the candidate the
+// user's play history singles out among equally good ones, then the LLM's
+// pick. Falls back to the raw query if nothing matches.
+func (s *VoiceService) matchPlayQuery(ctx context.Context, userID, query string) (string, playstats.Match) {
+	if s.playOptions == nil {
+		return query, playstats.MatchRaw
 	}
 
 	options, err := s.playOptions.GetOptions(ctx)
 	if err != nil {
-		log.Printf("failed to get play options for matching: %v", err)
-		return query
+		slog.WarnContext(ctx, "failed to get play options for matching", "err", err)
+		return query, playstats.MatchRaw
 	}
 
 	if len(options) == 0 {
-		return query
+		return query, playstats.MatchRaw
+	}
+
+	for _, opt := range options {
+		if opt.Matches(query) {
+			return opt.Name, playstats.MatchExact
+		}
+	}
+
+	guildID := usage.AttributionFrom(ctx).GuildID
+	if name, ok := s.matchByHistory(guildID, userID, options, query); ok {
+		slog.DebugContext(ctx, "play history matched", logging.KeyTranscript, query, "option", name)
+		return name, playstats.MatchHistory
+	}
+
+	if s.llm == nil {
+		return query, playstats.MatchRaw
 	}
 
 	// Build the options list for the LLM prompt
 	var optionNames []string
 	for _, opt := range options {
-		optionNames = append(optionNames, opt.Name)
+		line := opt.Name
+		if len(opt.Aliases) > 0 {
+			line += " (also: " + strings.Join(opt.Aliases, ", ") + ")"
+		}
+		optionNames = append(optionNames, line)
 	}
 	optionsList := strings.Join(optionNames, "\n")
 
@@ -150,6 +196,10 @@ func (s *VoiceService) matchPlayQuery(ctx context.Context, query string) string
 			"If nothing matches, reply with the user's original query exactly as given.",
 		query, optionsList,
 	)
+	if favourites := s.favourites(userID, options); len(favourites) > 0 {
+		prompt += "\n\nThis user often plays: " + strings.Join(favourites, ", ") +
+			". Prefer these when several options fit equally well."
+	}
 
 	messages := []bot.LLMMessage{
 		{Role: "system", Content: "You are a matching assistant. Given a spoken query and a list of available options, pick the best match. Reply with only the option name, no explanation."},
@@ -158,15 +208,77 @@ func (s *VoiceService) matchPlayQuery(ctx context.Context, query string) string
 
 	result, err := s.llm.ChatCompletion(ctx, messages)
 	if err != nil {
-		log.Printf("LLM matching failed, using raw query: %v", err)
-		return query
+		slog.WarnContext(ctx, "LLM matching failed, using raw query", "err", err)
+		return query, playstats.MatchRaw
 	}
 
 	result = strings.TrimSpace(result)
 	if result == "" {
-		return query
+		return query, playstats.MatchRaw
 	}
 
-	log.Printf("LLM matched %q -> %q", query, result)
-	return result
+	slog.DebugContext(ctx, "LLM matched", logging.KeyTranscript, query, "option", result)
+	for _, opt := range options {
+		if opt.Matches(result) {
+			return opt.Name, playstats.MatchLLM // the LLM may answer with an alias
+		}
+	}
+	return result, playstats.MatchRaw
+}
+
+// matchByHistory picks among the best-scoring search candidates for query
+// when they tie, preferring the one the user plays most and then the one the
+// server plays most. It reports false when there is no tie or history can't
+// decide it.
+func (s *VoiceService) matchByHistory(guildID, userID string, options []bot.PlayOption, query string) (string, bool) {
+	if s.stats == nil {
+		return "", false
+	}
+	candidates := scoreOptions(options, query)
+	if len(candidates) < 2 || candidates[1].Score < candidates[0].Score {
+		return "", false
+	}
+
+	type prior struct {
+		name        string
+		user, guild int
+	}
+	var tied []prior
+	for _, c := range candidates {
+		if c.Score < candidates[0].Score {
+			break
+		}
+		user, guild := s.stats.Prior(guildID, userID, c.Option.Name)
+		tied = append(tied, prior{c.Option.Name, user, guild})
+	}
+	sort.SliceStable(tied, func(i, j int) bool {
+		if tied[i].user != tied[j].user {
+			return tied[i].user > tied[j].user
+		}
+		return tied[i].guild > tied[j].guild
+	})
+	if tied[0].user == tied[1].user && tied[0].guild == tied[1].guild {
+		return "", false
+	}
+	return tied[0].name, true
+}
+
+// favourites returns up to five of the available options the user plays most.
+func (s *VoiceService) favourites(userID string, options []bot.PlayOption) []string {
+	if s.stats == nil {
+		return nil
+	}
+	var names []string
+	for _, c := range s.stats.Favourites(userID, 0) {
+		for _, opt := range options {
+			if opt.Matches(c.Option) {
+				names = append(names, opt.Name)
+				break
+			}
+		}
+		if len(names) == 5 {
+			break
+		}
+	}
+	return names
 }
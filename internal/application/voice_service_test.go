@@ -3,8 +3,11 @@ package application
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/playstats"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/usage"
 )
 
 // --- Mocks ---
@@ -45,7 +48,7 @@ func newTestService() *VoiceService {
 // Returns empty string if no command was matched.
 func parse(t *testing.T, svc *VoiceService, transcription string) string {
 	t.Helper()
-	cmd, ok := svc.parseCommand(context.Background(), transcription)
+	cmd, ok := svc.parseCommand(context.Background(), "u1", transcription)
 	if !ok {
 		return ""
 	}
@@ -286,6 +289,19 @@ func TestPlayCommand_LLMMatching(t *testing.T) {
 	}
 }
 
+func TestPlayCommand_LLMAnswersWithAlias(t *testing.T) {
+	llm := &mockLLM{reply: "Fog Horn"}
+	opts := &mockPlayOptions{options: []bot.PlayOption{
+		{Name: "airhorn", Aliases: []string{"fog-horn"}},
+	}}
+	svc := NewVoiceService(&mockSTT{}, "laser", llm, opts)
+
+	got := parse(t, svc, "laser play the foghorn")
+	if got != "!play airhorn" {
+		t.Errorf("parse with alias reply = %q, want %q", got, "!play airhorn")
+	}
+}
+
 func TestPlayCommand_LLMFallback_NoOptions(t *testing.T) {
 	llm := &mockLLM{}
 	opts := &mockPlayOptions{options: []bot.PlayOption{}}
@@ -307,8 +323,8 @@ func TestHandleVoice_TranscribesAndParses(t *testing.T) {
 	if err != nil {
 		t.Fatalf("HandleVoice error: %v", err)
 	}
-	if got != "!stop" {
-		t.Errorf("HandleVoice = %q, want %q", got, "!stop")
+	if got.Text != "!stop" {
+		t.Errorf("HandleVoice = %q, want %q", got.Text, "!stop")
 	}
 }
 
@@ -320,8 +336,8 @@ func TestHandleVoice_EmptyTranscription(t *testing.T) {
 	if err != nil {
 		t.Fatalf("HandleVoice error: %v", err)
 	}
-	if got != "" {
-		t.Errorf("HandleVoice = %q, want empty", got)
+	if got.Text != "" {
+		t.Errorf("HandleVoice = %q, want empty", got.Text)
 	}
 }
 
@@ -333,8 +349,20 @@ func TestHandleVoice_NoWakePhrase(t *testing.T) {
 	if err != nil {
 		t.Fatalf("HandleVoice error: %v", err)
 	}
-	if got != "" {
-		t.Errorf("HandleVoice = %q, want empty", got)
+	if got.Text != "" {
+		t.Errorf("HandleVoice = %q, want empty", got.Text)
+	}
+}
+
+func TestHandleVoiceMessage_ReturnsTranscript(t *testing.T) {
+	svc := NewVoiceService(&mockSTT{text: " what's the weather like? "}, "laser", nil, nil)
+
+	transcript, command, err := svc.HandleVoiceMessage(context.Background(), "ch1", "u1", []byte("fake-audio"))
+	if err != nil {
+		t.Fatalf("HandleVoiceMessage error: %v", err)
+	}
+	if transcript != "what's the weather like?" || command.Text != "" {
+		t.Errorf("HandleVoiceMessage = %q, %q, want the transcript and no command", transcript, command.Text)
 	}
 }
 
@@ -363,3 +391,46 @@ func TestCustomWakePhrase(t *testing.T) {
 		})
 	}
 }
+
+// --- Play history ---
+
+func TestPlayMatching_HistoryBreaksTies(t *testing.T) {
+	opts := &mockPlayOptions{options: []bot.PlayOption{{Name: "wowwow"}, {Name: "wow anime"}}}
+	svc := NewVoiceService(&mockSTT{}, "laser", &mockLLM{reply: "wowwow"}, opts)
+	svc.SetPlayStats(newTestPlayStats(t, time.Now(), map[string][]string{
+		"u1": {"wow anime"},
+		"u2": {"wowwow", "wowwow"},
+	}))
+
+	// u1 has played "wow anime", which outweighs the server's preference.
+	if got := parse(t, svc, "laser play wow"); got != "!play wow anime" {
+		t.Errorf("u1: got %q, want %q", got, "!play wow anime")
+	}
+
+	// Without history of their own, the server's most played wins.
+	ctx := usage.WithAttribution(context.Background(), usage.Attribution{GuildID: "g1", UserID: "u3"})
+	cmd, _ := svc.parseCommand(ctx, "u3", "laser play wow")
+	if cmd.Text != "!play wowwow" || cmd.Match != playstats.MatchHistory {
+		t.Errorf("u3: got %q (%s), want %q by history", cmd.Text, cmd.Match, "!play wowwow")
+	}
+}
+
+func TestHandleVoiceMessage_ReturnsPlayWithoutRecording(t *testing.T) {
+	opts := &mockPlayOptions{options: []bot.PlayOption{{Name: "airhorn"}}}
+	svc := NewVoiceService(&mockSTT{text: "laser play airhorn"}, "laser", nil, opts)
+	stats := newTestPlayStats(t, time.Now(), nil)
+	svc.SetPlayStats(stats)
+
+	ctx := usage.WithAttribution(context.Background(), usage.Attribution{GuildID: "g1", UserID: "u1"})
+	_, cmd, err := svc.HandleVoiceMessage(ctx, "c1", "u1", nil)
+	if err != nil {
+		t.Fatalf("HandleVoiceMessage: %v", err)
+	}
+	if cmd.Option != "airhorn" || cmd.Match != playstats.MatchExact {
+		t.Errorf("command = %+v, want an exact play of airhorn", cmd)
+	}
+	// The play is recorded once it has been dispatched, not when parsed.
+	if favs := stats.Favourites("u1", 0); len(favs) != 0 {
+		t.Errorf("favourites = %+v, want none before dispatch", favs)
+	}
+}
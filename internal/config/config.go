@@ -2,9 +2,13 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
+	"os"
 	"strings"
 	"time"
 
+	"github.com/adrock-miles/go-laserbeak/internal/domain/prompt"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/usage"
 	"github.com/spf13/viper"
 )
 
@@ -15,28 +19,149 @@ type Config struct {
 	STT         STTConfig
 	Bot         BotConfig
 	PlayOptions PlayOptionsConfig
+	Soundboard  SoundboardConfig
+	RateLimit   RateLimitConfig
+	Usage       UsageConfig
+	Admin       AdminConfig
+	Log         LogConfig
+}
+
+// LogConfig holds logging settings.
+type LogConfig struct {
+	Level             string // debug, info, warn or error
+	Format            string // text or json
+	RedactTranscripts bool   // log transcripts' lengths instead of their text
+}
+
+// AdminConfig holds settings for the HTTP server exposing health, readiness
+// and metrics.
+type AdminConfig struct {
+	Enabled bool
+	Addr    string // listen address; defaults to $PORT, then :8080
+}
+
+// UsageConfig holds usage accounting and quota settings.
+type UsageConfig struct {
+	File   string                // JSON-lines ledger of API usage; empty keeps it in memory
+	Prices map[string]UsagePrice // USD prices by model name
+	Quotas []UsageQuota
+}
+
+// UsagePrice is what a model costs, in USD.
+type UsagePrice struct {
+	Input       float64 // per million prompt tokens
+	Output      float64 // per million completion tokens
+	AudioMinute float64 // per minute of transcribed audio
+}
+
+// UsageQuota caps the usage of each user, channel or guild over a day or
+// month. Zero limits are not enforced.
+type UsageQuota struct {
+	Scope        string // user, channel or guild
+	Period       string // daily or monthly
+	Tokens       int
+	AudioSeconds float64
+	Cost         float64 // USD
+}
+
+// RateLimitConfig holds token-bucket limits for chat, voice commands and STT usage.
+type RateLimitConfig struct {
+	Enabled bool
+	Chat    RateLimitScopes // chat requests
+	Voice   RateLimitScopes // dispatched voice commands
+	STT     RateLimitScopes // seconds of audio transcribed
+}
+
+// RateLimitScopes holds the bucket settings for each scope a limit is keyed by.
+type RateLimitScopes struct {
+	User    RateLimitRule
+	Channel RateLimitRule
+	Guild   RateLimitRule
+}
+
+// RateLimitRule configures a single token bucket. A zero Burst disables it.
+type RateLimitRule struct {
+	Burst  int           // maximum tokens (requests, or seconds of audio for STT)
+	Refill time.Duration // time to regain one token
 }
 
 // PlayOptionsConfig holds settings for the play options API.
 type PlayOptionsConfig struct {
-	APIURL   string        // URL to fetch play options from (e.g. http://localhost:8080/options)
-	CacheTTL time.Duration // how long to cache the options list
+	File      string            // local JSON file of play options, reloaded on change
+	StatsFile string            // JSON-lines log of plays; empty keeps it in memory
+	APIURL    string            // URL to fetch play options from (e.g. http://localhost:8080/options)
+	CacheTTL  time.Duration     // how long to cache the options list
+	Timeout   time.Duration     // how long a fetch may take
+	Token     string            // sent as "Authorization: Bearer <token>"
+	Headers   map[string]string // extra request headers
+	Format    string            // auto, json, yaml, csv or text
+	Selector  string            // path to the names in a nested payload, e.g. data.sounds[*].title
+
+	// Sources are merged by name; the higher priority supplies each option.
+	FilePriority  int
+	APIPriority   int
+	SourceTimeout time.Duration // how long to wait for each source before using its last options
+}
+
+// SoundboardConfig holds settings for playing sounds in voice without a
+// separate soundboard bot.
+type SoundboardConfig struct {
+	Enabled  bool
+	Dir      string // directory of .ogg and .opus files
+	MaxQueue int    // sounds that can wait behind the one playing
 }
 
 // DiscordConfig holds Discord-specific settings.
 type DiscordConfig struct {
-	Token          string
-	CommandPrefix  string
-	GuildID        string // guild to operate in (required for auto-join)
-	VoiceChannelID string // voice channel to auto-join on startup
-	TextChannelID  string // text channel for voice command output
+	Token            string
+	CommandPrefix    string
+	GuildID          string        // guild to operate in (required for auto-join)
+	VoiceChannelID   string        // voice channel to auto-join on startup
+	TextChannelID    string        // text channel for voice command output
+	Threads          bool          // start a thread for each chat in a regular channel
+	ThreadArchive    time.Duration // inactivity before the bot's threads auto-archive
+	ReplyDepth       int           // messages of a reply chain to include as context; 0 disables
+	MaxImages        int           // image attachments sent to the LLM per message; 0 disables vision
+	MaxImageMB       int           // largest image attachment sent, in megabytes
+	MaxTextKB        int           // largest text attachment read into the chat, in kilobytes; 0 disables
+	TextTokens       int           // token budget shared by a message's text attachments; 0 means no limit
+	MaxReplyChunks   int           // replies needing more messages are sent as a file; 0 disables
+	VoiceMessages    bool          // transcribe voice messages for wake-phrase commands
+	VoiceMessageChat bool          // answer voice messages in bot channels that aren't commands as chat
+	OptionsRoles     []string      // role IDs or names allowed to change play options and personas; empty means Manage Server
 }
 
+// LLM provider types selectable with llm.provider.
+const (
+	LLMProviderOpenAI    = "openai"    // OpenAI-compatible /chat/completions
+	LLMProviderAnthropic = "anthropic" // Anthropic Messages API
+)
+
 // LLMConfig holds LLM API settings.
 type LLMConfig struct {
-	APIKey  string
-	BaseURL string
-	Model   string
+	Provider  string // LLMProviderOpenAI or LLMProviderAnthropic
+	APIKey    string
+	BaseURL   string
+	Model     string
+	MaxTokens int           // reply token limit (required by the Anthropic API)
+	Timeout   time.Duration // per-provider timeout; zero means no limit beyond the request's own
+
+	// Providers is the ordered fallback chain. When llm.providers is not set it
+	// holds a single entry built from the fields above.
+	Providers []LLMProviderConfig
+}
+
+// LLMProviderConfig holds settings for one entry in the LLM fallback chain.
+// Unset fields inherit from the top-level llm block; the API key, base URL and
+// model are only inherited by entries of the same provider type.
+type LLMProviderConfig struct {
+	Name      string
+	Provider  string
+	APIKey    string
+	BaseURL   string
+	Model     string
+	MaxTokens int
+	Timeout   time.Duration
 }
 
 // STTConfig holds speech-to-text API settings.
@@ -48,13 +173,176 @@ type STTConfig struct {
 
 // BotConfig holds general bot behavior settings.
 type BotConfig struct {
-	SystemPrompt string
-	MaxHistory   int
-	WakePhrase   string // wake phrase for voice commands (e.g. "laser")
+	SystemPrompt   string         // Go text/template, rendered per request
+	Location       *time.Location // time zone for dates and times in the system prompt
+	MaxHistory     int
+	ContextTokens  int    // token budget for each chat request; 0 sends all stored history
+	SummarizeAfter int    // stored messages above which the oldest are summarized; 0 disables
+	SummaryKeep    int    // newest messages kept verbatim when summarizing
+	WakePhrase     string // wake phrase for voice commands (e.g. "laser")
+	Tools          bool   // let the chat LLM call built-in tools (play sounds, join voice, ...)
+
+	Personas    map[string]string // named persona presets (name -> system prompt)
+	PersonaFile string            // JSON file storing per-channel personas; empty keeps them in memory
 }
 
 // Load reads configuration from environment variables, config files, and flags.
 func Load() (*Config, error) {
+	if err := readConfig(); err != nil {
+		return nil, err
+	}
+
+	// The OpenAI defaults make no sense for Anthropic; its client supplies its own base URL.
+	if viper.GetString("llm.provider") == LLMProviderAnthropic {
+		viper.SetDefault("llm.baseurl", "")
+		viper.SetDefault("llm.model", "")
+	}
+
+	cfg := &Config{
+		Discord: DiscordConfig{
+			Token:            viper.GetString("discord.token"),
+			CommandPrefix:    viper.GetString("discord.commandprefix"),
+			GuildID:          viper.GetString("discord.guildid"),
+			VoiceChannelID:   viper.GetString("discord.voicechannelid"),
+			TextChannelID:    viper.GetString("discord.textchannelid"),
+			Threads:          viper.GetBool("discord.threads"),
+			ThreadArchive:    viper.GetDuration("discord.threadarchive"),
+			ReplyDepth:       viper.GetInt("discord.replydepth"),
+			MaxImages:        viper.GetInt("discord.maximages"),
+			MaxImageMB:       viper.GetInt("discord.maximagemb"),
+			MaxTextKB:        viper.GetInt("discord.maxtextkb"),
+			TextTokens:       viper.GetInt("discord.texttokens"),
+			MaxReplyChunks:   viper.GetInt("discord.maxreplychunks"),
+			VoiceMessages:    viper.GetBool("discord.voicemessages"),
+			VoiceMessageChat: viper.GetBool("discord.voicemessagechat"),
+			OptionsRoles:     viper.GetStringSlice("discord.optionsroles"),
+		},
+		LLM: LLMConfig{
+			Provider:  viper.GetString("llm.provider"),
+			APIKey:    viper.GetString("llm.apikey"),
+			BaseURL:   viper.GetString("llm.baseurl"),
+			Model:     viper.GetString("llm.model"),
+			MaxTokens: viper.GetInt("llm.maxtokens"),
+			Timeout:   viper.GetDuration("llm.timeout"),
+		},
+		STT: STTConfig{
+			APIKey:  viper.GetString("stt.apikey"),
+			BaseURL: viper.GetString("stt.baseurl"),
+			Model:   viper.GetString("stt.model"),
+		},
+		Bot: BotConfig{
+			SystemPrompt:   viper.GetString("bot.systemprompt"),
+			MaxHistory:     viper.GetInt("bot.maxhistory"),
+			ContextTokens:  viper.GetInt("bot.contexttokens"),
+			SummarizeAfter: viper.GetInt("bot.summarizeafter"),
+			SummaryKeep:    viper.GetInt("bot.summarykeep"),
+			WakePhrase:     viper.GetString("bot.wakephrase"),
+			Tools:          viper.GetBool("bot.tools"),
+			Personas:       viper.GetStringMapString("bot.personas"),
+			PersonaFile:    viper.GetString("bot.personafile"),
+		},
+	}
+
+	cacheTTL, err := time.ParseDuration(viper.GetString("playoptions.cachettl"))
+	if err != nil {
+		cacheTTL = 5 * time.Minute
+	}
+	cfg.PlayOptions = PlayOptionsConfig{
+		File:      viper.GetString("playoptions.file"),
+		StatsFile: viper.GetString("playoptions.statsfile"),
+		APIURL:    viper.GetString("playoptions.apiurl"),
+		CacheTTL:  cacheTTL,
+		Timeout:   viper.GetDuration("playoptions.timeout"),
+		Token:     viper.GetString("playoptions.token"),
+		Headers:   viper.GetStringMapString("playoptions.headers"),
+		Format:    viper.GetString("playoptions.format"),
+		Selector:  viper.GetString("playoptions.selector"),
+
+		FilePriority:  viper.GetInt("playoptions.filepriority"),
+		APIPriority:   viper.GetInt("playoptions.apipriority"),
+		SourceTimeout: viper.GetDuration("playoptions.sourcetimeout"),
+	}
+
+	cfg.Soundboard = SoundboardConfig{
+		Enabled:  viper.GetBool("soundboard.enabled"),
+		Dir:      viper.GetString("soundboard.dir"),
+		MaxQueue: viper.GetInt("soundboard.maxqueue"),
+	}
+
+	cfg.Admin = AdminConfig{
+		Enabled: viper.GetBool("admin.enabled"),
+		Addr:    viper.GetString("admin.addr"),
+	}
+	if cfg.Admin.Addr == "" {
+		cfg.Admin.Addr = ":8080"
+		if port := os.Getenv("PORT"); port != "" {
+			cfg.Admin.Addr = ":" + port
+		}
+	}
+
+	cfg.Log = LogConfig{
+		Level:             viper.GetString("log.level"),
+		Format:            viper.GetString("log.format"),
+		RedactTranscripts: viper.GetBool("log.redacttranscripts"),
+	}
+
+	cfg.RateLimit = RateLimitConfig{Enabled: viper.GetBool("ratelimit.enabled")}
+	for kind, scopes := range map[string]*RateLimitScopes{
+		"chat":  &cfg.RateLimit.Chat,
+		"voice": &cfg.RateLimit.Voice,
+		"stt":   &cfg.RateLimit.STT,
+	} {
+		if *scopes, err = loadRateLimitScopes(kind); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Discord.Token == "" {
+		return nil, fmt.Errorf("discord.token is required (set DISCORD_TOKEN or LASERBEAK_DISCORD_TOKEN)")
+	}
+	summaryDefaults(&cfg.Bot)
+	if b := cfg.Bot; b.SummarizeAfter > 0 {
+		if b.MaxHistory > 0 && b.SummarizeAfter >= b.MaxHistory {
+			return nil, fmt.Errorf("bot.summarizeafter (%d) must be less than bot.maxhistory (%d), or old messages are trimmed before they can be summarized",
+				b.SummarizeAfter, b.MaxHistory)
+		}
+		if b.SummaryKeep >= b.SummarizeAfter {
+			return nil, fmt.Errorf("bot.summarykeep (%d) must be less than bot.summarizeafter (%d)", b.SummaryKeep, b.SummarizeAfter)
+		}
+	}
+
+	if _, err := prompt.Parse(cfg.Bot.SystemPrompt); err != nil {
+		return nil, fmt.Errorf("bot.systemprompt: %w", err)
+	}
+	for name, text := range cfg.Bot.Personas {
+		if strings.TrimSpace(text) == "" {
+			return nil, fmt.Errorf("bot.personas.%s: prompt is empty", name)
+		}
+		if _, err := prompt.Parse(text); err != nil {
+			return nil, fmt.Errorf("bot.personas.%s: %w", name, err)
+		}
+	}
+
+	if cfg.Bot.Location, err = loadLocation(); err != nil {
+		return nil, err
+	}
+
+	if cfg.Usage, err = loadUsage(); err != nil {
+		return nil, err
+	}
+
+	providers, err := loadLLMProviders(cfg.LLM)
+	if err != nil {
+		return nil, err
+	}
+	cfg.LLM.Providers = providers
+
+	return cfg, nil
+}
+
+// readConfig sets up environment bindings and defaults, and reads the config
+// file if there is one.
+func readConfig() error {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath(".")
@@ -71,22 +359,60 @@ func Load() (*Config, error) {
 	// (e.g. DISCORD_TOKEN instead of LASERBEAK_DISCORD_TOKEN).
 	// The LASERBEAK_-prefixed version takes precedence when both are set.
 	envBindings := map[string][2]string{
-		"discord.token":          {"LASERBEAK_DISCORD_TOKEN", "DISCORD_TOKEN"},
-		"discord.commandprefix":  {"LASERBEAK_DISCORD_COMMANDPREFIX", "DISCORD_COMMANDPREFIX"},
-		"discord.guildid":        {"LASERBEAK_DISCORD_GUILDID", "DISCORD_GUILDID"},
-		"discord.voicechannelid": {"LASERBEAK_DISCORD_VOICECHANNELID", "DISCORD_VOICECHANNELID"},
-		"discord.textchannelid":  {"LASERBEAK_DISCORD_TEXTCHANNELID", "DISCORD_TEXTCHANNELID"},
-		"llm.apikey":             {"LASERBEAK_LLM_APIKEY", "LLM_APIKEY"},
-		"llm.baseurl":            {"LASERBEAK_LLM_BASEURL", "LLM_BASEURL"},
-		"llm.model":              {"LASERBEAK_LLM_MODEL", "LLM_MODEL"},
-		"stt.apikey":             {"LASERBEAK_STT_APIKEY", "STT_APIKEY"},
-		"stt.baseurl":            {"LASERBEAK_STT_BASEURL", "STT_BASEURL"},
-		"stt.model":              {"LASERBEAK_STT_MODEL", "STT_MODEL"},
-		"bot.systemprompt":       {"LASERBEAK_BOT_SYSTEMPROMPT", "BOT_SYSTEMPROMPT"},
-		"bot.maxhistory":         {"LASERBEAK_BOT_MAXHISTORY", "BOT_MAXHISTORY"},
-		"bot.wakephrase":         {"LASERBEAK_BOT_WAKEPHRASE", "BOT_WAKEPHRASE"},
-		"playoptions.apiurl":     {"LASERBEAK_PLAYOPTIONS_APIURL", "PLAYOPTIONS_APIURL"},
-		"playoptions.cachettl":   {"LASERBEAK_PLAYOPTIONS_CACHETTL", "PLAYOPTIONS_CACHETTL"},
+		"discord.token":             {"LASERBEAK_DISCORD_TOKEN", "DISCORD_TOKEN"},
+		"discord.commandprefix":     {"LASERBEAK_DISCORD_COMMANDPREFIX", "DISCORD_COMMANDPREFIX"},
+		"discord.guildid":           {"LASERBEAK_DISCORD_GUILDID", "DISCORD_GUILDID"},
+		"discord.voicechannelid":    {"LASERBEAK_DISCORD_VOICECHANNELID", "DISCORD_VOICECHANNELID"},
+		"discord.textchannelid":     {"LASERBEAK_DISCORD_TEXTCHANNELID", "DISCORD_TEXTCHANNELID"},
+		"discord.threads":           {"LASERBEAK_DISCORD_THREADS", "DISCORD_THREADS"},
+		"discord.threadarchive":     {"LASERBEAK_DISCORD_THREADARCHIVE", "DISCORD_THREADARCHIVE"},
+		"discord.replydepth":        {"LASERBEAK_DISCORD_REPLYDEPTH", "DISCORD_REPLYDEPTH"},
+		"discord.maximages":         {"LASERBEAK_DISCORD_MAXIMAGES", "DISCORD_MAXIMAGES"},
+		"discord.maximagemb":        {"LASERBEAK_DISCORD_MAXIMAGEMB", "DISCORD_MAXIMAGEMB"},
+		"discord.maxtextkb":         {"LASERBEAK_DISCORD_MAXTEXTKB", "DISCORD_MAXTEXTKB"},
+		"discord.texttokens":        {"LASERBEAK_DISCORD_TEXTTOKENS", "DISCORD_TEXTTOKENS"},
+		"discord.maxreplychunks":    {"LASERBEAK_DISCORD_MAXREPLYCHUNKS", "DISCORD_MAXREPLYCHUNKS"},
+		"discord.voicemessages":     {"LASERBEAK_DISCORD_VOICEMESSAGES", "DISCORD_VOICEMESSAGES"},
+		"discord.voicemessagechat":  {"LASERBEAK_DISCORD_VOICEMESSAGECHAT", "DISCORD_VOICEMESSAGECHAT"},
+		"discord.optionsroles":      {"LASERBEAK_DISCORD_OPTIONSROLES", "DISCORD_OPTIONSROLES"},
+		"llm.apikey":                {"LASERBEAK_LLM_APIKEY", "LLM_APIKEY"},
+		"llm.baseurl":               {"LASERBEAK_LLM_BASEURL", "LLM_BASEURL"},
+		"llm.model":                 {"LASERBEAK_LLM_MODEL", "LLM_MODEL"},
+		"llm.timeout":               {"LASERBEAK_LLM_TIMEOUT", "LLM_TIMEOUT"},
+		"llm.provider":              {"LASERBEAK_LLM_PROVIDER", "LLM_PROVIDER"},
+		"llm.maxtokens":             {"LASERBEAK_LLM_MAXTOKENS", "LLM_MAXTOKENS"},
+		"stt.apikey":                {"LASERBEAK_STT_APIKEY", "STT_APIKEY"},
+		"stt.baseurl":               {"LASERBEAK_STT_BASEURL", "STT_BASEURL"},
+		"stt.model":                 {"LASERBEAK_STT_MODEL", "STT_MODEL"},
+		"bot.systemprompt":          {"LASERBEAK_BOT_SYSTEMPROMPT", "BOT_SYSTEMPROMPT"},
+		"bot.timezone":              {"LASERBEAK_BOT_TIMEZONE", "BOT_TIMEZONE"},
+		"bot.maxhistory":            {"LASERBEAK_BOT_MAXHISTORY", "BOT_MAXHISTORY"},
+		"bot.contexttokens":         {"LASERBEAK_BOT_CONTEXTTOKENS", "BOT_CONTEXTTOKENS"},
+		"bot.summarizeafter":        {"LASERBEAK_BOT_SUMMARIZEAFTER", "BOT_SUMMARIZEAFTER"},
+		"bot.summarykeep":           {"LASERBEAK_BOT_SUMMARYKEEP", "BOT_SUMMARYKEEP"},
+		"bot.wakephrase":            {"LASERBEAK_BOT_WAKEPHRASE", "BOT_WAKEPHRASE"},
+		"bot.tools":                 {"LASERBEAK_BOT_TOOLS", "BOT_TOOLS"},
+		"bot.personafile":           {"LASERBEAK_BOT_PERSONAFILE", "BOT_PERSONAFILE"},
+		"playoptions.file":          {"LASERBEAK_PLAYOPTIONS_FILE", "PLAYOPTIONS_FILE"},
+		"playoptions.apiurl":        {"LASERBEAK_PLAYOPTIONS_APIURL", "PLAYOPTIONS_APIURL"},
+		"playoptions.cachettl":      {"LASERBEAK_PLAYOPTIONS_CACHETTL", "PLAYOPTIONS_CACHETTL"},
+		"playoptions.timeout":       {"LASERBEAK_PLAYOPTIONS_TIMEOUT", "PLAYOPTIONS_TIMEOUT"},
+		"playoptions.token":         {"LASERBEAK_PLAYOPTIONS_TOKEN", "PLAYOPTIONS_TOKEN"},
+		"playoptions.format":        {"LASERBEAK_PLAYOPTIONS_FORMAT", "PLAYOPTIONS_FORMAT"},
+		"playoptions.selector":      {"LASERBEAK_PLAYOPTIONS_SELECTOR", "PLAYOPTIONS_SELECTOR"},
+		"playoptions.filepriority":  {"LASERBEAK_PLAYOPTIONS_FILEPRIORITY", "PLAYOPTIONS_FILEPRIORITY"},
+		"playoptions.apipriority":   {"LASERBEAK_PLAYOPTIONS_APIPRIORITY", "PLAYOPTIONS_APIPRIORITY"},
+		"playoptions.sourcetimeout": {"LASERBEAK_PLAYOPTIONS_SOURCETIMEOUT", "PLAYOPTIONS_SOURCETIMEOUT"},
+		"soundboard.enabled":        {"LASERBEAK_SOUNDBOARD_ENABLED", "SOUNDBOARD_ENABLED"},
+		"soundboard.dir":            {"LASERBEAK_SOUNDBOARD_DIR", "SOUNDBOARD_DIR"},
+		"soundboard.maxqueue":       {"LASERBEAK_SOUNDBOARD_MAXQUEUE", "SOUNDBOARD_MAXQUEUE"},
+		"usage.file":                {"LASERBEAK_USAGE_FILE", "USAGE_FILE"},
+		"admin.enabled":             {"LASERBEAK_ADMIN_ENABLED", "ADMIN_ENABLED"},
+		"admin.addr":                {"LASERBEAK_ADMIN_ADDR", "ADMIN_ADDR"},
+		"log.level":                 {"LASERBEAK_LOG_LEVEL", "LOG_LEVEL"},
+		"log.format":                {"LASERBEAK_LOG_FORMAT", "LOG_FORMAT"},
+		"log.redacttranscripts":     {"LASERBEAK_LOG_REDACTTRANSCRIPTS", "LOG_REDACTTRANSCRIPTS"},
+		"ratelimit.enabled":         {"LASERBEAK_RATELIMIT_ENABLED", "RATELIMIT_ENABLED"},
 	}
 	for key, envVars := range envBindings {
 		viper.BindEnv(key, envVars[0], envVars[1])
@@ -94,62 +420,234 @@ func Load() (*Config, error) {
 
 	// Defaults
 	viper.SetDefault("discord.commandprefix", "!laser")
+	viper.SetDefault("discord.threads", false)
+	viper.SetDefault("discord.threadarchive", "1h")
+	viper.SetDefault("discord.replydepth", 3)
+	viper.SetDefault("discord.maximages", 4)
+	viper.SetDefault("discord.maximagemb", 10)
+	viper.SetDefault("discord.maxtextkb", 256)
+	viper.SetDefault("discord.texttokens", 4000)
+	viper.SetDefault("discord.maxreplychunks", 3)
+	viper.SetDefault("discord.voicemessages", true)
+	viper.SetDefault("discord.voicemessagechat", false)
+	viper.SetDefault("llm.provider", LLMProviderOpenAI)
 	viper.SetDefault("llm.baseurl", "https://api.openai.com/v1")
 	viper.SetDefault("llm.model", "gpt-4")
+	viper.SetDefault("llm.maxtokens", 1024)
 	viper.SetDefault("stt.baseurl", "https://api.openai.com/v1")
 	viper.SetDefault("stt.model", "whisper-1")
 	viper.SetDefault("bot.systemprompt", "You are Laserbeak, a helpful Discord assistant. Respond concisely and helpfully.")
 	viper.SetDefault("bot.maxhistory", 50)
+	viper.SetDefault("bot.contexttokens", 8192)
 	viper.SetDefault("bot.wakephrase", "laser")
+	viper.SetDefault("bot.tools", true)
+	viper.SetDefault("bot.personafile", "personas.json")
+	viper.SetDefault("bot.personas", map[string]string{
+		"pirate":        "You are Laserbeak, a Discord assistant who talks like a pirate. Stay helpful, but answer in hearty pirate speak.",
+		"terse":         "You are Laserbeak, a Discord assistant. Answer in as few words as possible. No pleasantries.",
+		"code-reviewer": "You are Laserbeak, a meticulous senior engineer reviewing code shared in Discord. Point out bugs, risks and unclear naming first, then suggest concrete improvements.",
+	})
+	viper.SetDefault("playoptions.file", "play_options.json")
+	viper.SetDefault("playoptions.statsfile", "plays.jsonl")
 	viper.SetDefault("playoptions.cachettl", "5m")
+	viper.SetDefault("playoptions.timeout", "10s")
+	viper.SetDefault("playoptions.filepriority", 10)
+	viper.SetDefault("playoptions.apipriority", 0)
+	viper.SetDefault("playoptions.sourcetimeout", "3s")
+	viper.SetDefault("soundboard.enabled", false)
+	viper.SetDefault("soundboard.dir", "sounds")
+	viper.SetDefault("soundboard.maxqueue", 10)
+	viper.SetDefault("usage.file", "usage.jsonl")
+	viper.SetDefault("admin.enabled", true)
+	viper.SetDefault("admin.addr", "")
+	viper.SetDefault("log.level", "info")
+	viper.SetDefault("log.format", "text")
+	viper.SetDefault("log.redacttranscripts", false)
+	viper.SetDefault("ratelimit.enabled", true)
+	viper.SetDefault("ratelimit.chat.user.burst", 5)
+	viper.SetDefault("ratelimit.chat.user.refill", "15s")
+	viper.SetDefault("ratelimit.chat.channel.burst", 15)
+	viper.SetDefault("ratelimit.chat.channel.refill", "4s")
+	viper.SetDefault("ratelimit.chat.guild.burst", 40)
+	viper.SetDefault("ratelimit.chat.guild.refill", "2s")
+	viper.SetDefault("ratelimit.voice.user.burst", 6)
+	viper.SetDefault("ratelimit.voice.user.refill", "10s")
+	viper.SetDefault("ratelimit.voice.guild.burst", 30)
+	viper.SetDefault("ratelimit.voice.guild.refill", "2s")
+	viper.SetDefault("ratelimit.stt.user.burst", 120) // seconds of audio
+	viper.SetDefault("ratelimit.stt.user.refill", "2s")
+	viper.SetDefault("ratelimit.stt.guild.burst", 600)
+	viper.SetDefault("ratelimit.stt.guild.refill", "250ms")
 
 	// Read config file (optional)
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, fmt.Errorf("reading config file: %w", err)
+			return fmt.Errorf("reading config file: %w", err)
 		}
 	}
+	return nil
+}
 
-	cfg := &Config{
-		Discord: DiscordConfig{
-			Token:          viper.GetString("discord.token"),
-			CommandPrefix:  viper.GetString("discord.commandprefix"),
-			GuildID:        viper.GetString("discord.guildid"),
-			VoiceChannelID: viper.GetString("discord.voicechannelid"),
-			TextChannelID:  viper.GetString("discord.textchannelid"),
-		},
-		LLM: LLMConfig{
-			APIKey:  viper.GetString("llm.apikey"),
-			BaseURL: viper.GetString("llm.baseurl"),
-			Model:   viper.GetString("llm.model"),
-		},
-		STT: STTConfig{
-			APIKey:  viper.GetString("stt.apikey"),
-			BaseURL: viper.GetString("stt.baseurl"),
-			Model:   viper.GetString("stt.model"),
-		},
-		Bot: BotConfig{
-			SystemPrompt: viper.GetString("bot.systemprompt"),
-			MaxHistory:   viper.GetInt("bot.maxhistory"),
-			WakePhrase:   viper.GetString("bot.wakephrase"),
-		},
+// LoadUsage reads only the usage settings, for commands that don't run the bot.
+func LoadUsage() (UsageConfig, error) {
+	if err := readConfig(); err != nil {
+		return UsageConfig{}, err
 	}
+	return loadUsage()
+}
 
-	cacheTTL, err := time.ParseDuration(viper.GetString("playoptions.cachettl"))
+// LoadLocation reads the config and returns the bot.timezone location, for
+// commands that only need to know where days begin.
+func LoadLocation() (*time.Location, error) {
+	if err := readConfig(); err != nil {
+		return nil, err
+	}
+	return loadLocation()
+}
+
+// loadLocation returns the bot.timezone location, or the system zone when unset.
+func loadLocation() (*time.Location, error) {
+	tz := viper.GetString("bot.timezone")
+	if tz == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(tz)
 	if err != nil {
-		cacheTTL = 5 * time.Minute
+		return nil, fmt.Errorf("bot.timezone: %w", err)
 	}
-	cfg.PlayOptions = PlayOptionsConfig{
-		APIURL:   viper.GetString("playoptions.apiurl"),
-		CacheTTL: cacheTTL,
+	return loc, nil
+}
+
+// loadUsage reads and validates the usage block.
+func loadUsage() (UsageConfig, error) {
+	cfg := UsageConfig{File: viper.GetString("usage.file")}
+	if err := viper.UnmarshalKey("usage.prices", &cfg.Prices); err != nil {
+		return UsageConfig{}, fmt.Errorf("parse usage.prices: %w", err)
+	}
+	if err := viper.UnmarshalKey("usage.quotas", &cfg.Quotas); err != nil {
+		return UsageConfig{}, fmt.Errorf("parse usage.quotas: %w", err)
 	}
+	for i, q := range cfg.Quotas {
+		quota := usage.Quota{
+			Scope:        usage.Scope(q.Scope),
+			Period:       usage.Period(q.Period),
+			Tokens:       q.Tokens,
+			AudioSeconds: q.AudioSeconds,
+			Cost:         q.Cost,
+		}
+		if err := quota.Validate(); err != nil {
+			return UsageConfig{}, fmt.Errorf("usage.quotas[%d]: %w", i, err)
+		}
+	}
+	return cfg, nil
+}
 
-	if cfg.Discord.Token == "" {
-		return nil, fmt.Errorf("discord.token is required (set DISCORD_TOKEN or LASERBEAK_DISCORD_TOKEN)")
+// loadLLMProviders reads the llm.providers fallback chain, filling unset fields
+// from the top-level llm block. Without a list, the llm block is the only provider.
+func loadLLMProviders(base LLMConfig) ([]LLMProviderConfig, error) {
+	var providers []LLMProviderConfig
+	if err := viper.UnmarshalKey("llm.providers", &providers); err != nil {
+		return nil, fmt.Errorf("parse llm.providers: %w", err)
 	}
-	if cfg.LLM.APIKey == "" {
-		return nil, fmt.Errorf("llm.apikey is required (set LLM_APIKEY or LASERBEAK_LLM_APIKEY)")
+
+	if len(providers) == 0 {
+		if base.APIKey == "" {
+			return nil, fmt.Errorf("llm.apikey is required (set LLM_APIKEY or LASERBEAK_LLM_APIKEY)")
+		}
+		providers = []LLMProviderConfig{{Name: "default"}}
 	}
 
-	return cfg, nil
+	for i := range providers {
+		p := &providers[i]
+		if p.Name == "" {
+			p.Name = fmt.Sprintf("provider-%d", i+1)
+		}
+		if p.Provider == "" {
+			p.Provider = base.Provider
+		}
+		if p.Provider != LLMProviderOpenAI && p.Provider != LLMProviderAnthropic {
+			return nil, fmt.Errorf("llm provider %q: unknown type %q (want %q or %q)",
+				p.Name, p.Provider, LLMProviderOpenAI, LLMProviderAnthropic)
+		}
+		if p.Provider == base.Provider {
+			if p.APIKey == "" {
+				p.APIKey = base.APIKey
+			}
+			if p.BaseURL == "" {
+				p.BaseURL = base.BaseURL
+			}
+			if p.Model == "" {
+				p.Model = base.Model
+			}
+		}
+		if p.MaxTokens == 0 {
+			p.MaxTokens = base.MaxTokens
+		}
+		if p.Timeout == 0 {
+			p.Timeout = base.Timeout
+		}
+		if p.APIKey == "" {
+			return nil, fmt.Errorf("llm provider %q: apikey is required", p.Name)
+		}
+		if p.Model == "" {
+			return nil, fmt.Errorf("llm provider %q: model is required", p.Name)
+		}
+	}
+	return providers, nil
+}
+
+// summaryDefaults fills in bot.summarizeafter and bot.summarykeep when they
+// aren't set, scaled to bot.maxhistory so that any history limit works. If a
+// set summarykeep leaves no room for the derived threshold, summarization is
+// turned off rather than failing.
+func summaryDefaults(b *BotConfig) {
+	afterSet := viper.IsSet("bot.summarizeafter")
+	if !afterSet {
+		b.SummarizeAfter = 30
+		if b.MaxHistory > 0 {
+			b.SummarizeAfter = b.MaxHistory * 3 / 5
+		}
+	}
+	if !viper.IsSet("bot.summarykeep") {
+		b.SummaryKeep = b.SummarizeAfter / 3
+	}
+	if !afterSet && b.SummarizeAfter > 0 && b.SummaryKeep >= b.SummarizeAfter {
+		slog.Warn("conversation summaries disabled: bot.summarykeep leaves no room below bot.maxhistory",
+			"maxhistory", b.MaxHistory, "summarykeep", b.SummaryKeep)
+		b.SummarizeAfter = 0
+	}
+}
+
+// loadRateLimitScopes reads the ratelimit.<kind> block.
+func loadRateLimitScopes(kind string) (RateLimitScopes, error) {
+	var scopes RateLimitScopes
+	for scope, rule := range map[string]*RateLimitRule{
+		"user":    &scopes.User,
+		"channel": &scopes.Channel,
+		"guild":   &scopes.Guild,
+	} {
+		prefix := "ratelimit." + kind + "." + scope
+		rule.Burst = viper.GetInt(prefix + ".burst")
+		if s := viper.GetString(prefix + ".refill"); s != "" { // unset leaves the rule disabled
+			refill, err := time.ParseDuration(s)
+			if err != nil {
+				return RateLimitScopes{}, fmt.Errorf("%s.refill: %w", prefix, err)
+			}
+			rule.Refill = refill
+		}
+	}
+	return scopes, nil
+}
+
+// Secrets returns the configured tokens, API keys and play options API
+// headers, for redacting them from logs.
+func (c *Config) Secrets() []string {
+	secrets := []string{c.Discord.Token, c.LLM.APIKey, c.STT.APIKey, c.PlayOptions.Token}
+	for _, p := range c.LLM.Providers {
+		secrets = append(secrets, p.APIKey)
+	}
+	for _, v := range c.PlayOptions.Headers {
+		secrets = append(secrets, v)
+	}
+	return secrets
 }
@@ -1,10 +1,52 @@
 package bot
 
-import "context"
+import (
+	"context"
+	"errors"
+	"strings"
+)
 
-// PlayOption represents a single playable item from the external bot.
+// PlayOption represents a single playable item, played by the external bot or
+// from a local sound file.
 type PlayOption struct {
-	Name string
+	Name    string
+	Aliases []string // other names it can be asked for by
+	Tags    []string // free-form labels, such as "meme" or "music"
+	Source  string   // the source it came from, such as "file" or "api"
+	File    string   // sound file for built-in playback, relative to the sounds directory
+}
+
+// Matches reports whether name is the option's name or one of its aliases,
+// compared with NormalizeName.
+func (o PlayOption) Matches(name string) bool {
+	name = NormalizeName(name)
+	if NormalizeName(o.Name) == name {
+		return true
+	}
+	for _, alias := range o.Aliases {
+		if NormalizeName(alias) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeName folds a play option name for comparison: lower case, with
+// underscores and dashes read as spaces and runs of spaces collapsed.
+func NormalizeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' {
+			return ' '
+		}
+		return r
+	}, strings.ToLower(name))
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// ScoredOption is a play option with its score for a search query.
+type ScoredOption struct {
+	Option PlayOption
+	Score  int
 }
 
 // PlayOptionsService defines the port for fetching available play options.
@@ -12,3 +54,19 @@ type PlayOptionsService interface {
 	// GetOptions returns the current list of available play options.
 	GetOptions(ctx context.Context) ([]PlayOption, error)
 }
+
+// PlayOptionsStore is a PlayOptionsService whose options can be changed.
+type PlayOptionsStore interface {
+	PlayOptionsService
+	// AddOption adds an option, returning ErrOptionExists if one with the
+	// same normalized name is already stored.
+	AddOption(ctx context.Context, opt PlayOption) error
+	// RemoveOption removes the option with the given name, returning
+	// ErrOptionNotFound if there is none.
+	RemoveOption(ctx context.Context, name string) error
+}
+
+var (
+	ErrOptionExists   = errors.New("play option already exists")
+	ErrOptionNotFound = errors.New("play option not found")
+)
@@ -0,0 +1,30 @@
+package bot
+
+import "testing"
+
+func TestNormalizeName(t *testing.T) {
+	tests := map[string]string{
+		"Air Horn":      "air horn",
+		"  air_horn ":   "air horn",
+		"AIR--HORN":     "air horn",
+		"sad\ttrombone": "sad trombone",
+		"":              "",
+	}
+	for in, want := range tests {
+		if got := NormalizeName(in); got != want {
+			t.Errorf("NormalizeName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPlayOption_Matches(t *testing.T) {
+	opt := PlayOption{Name: "airhorn", Aliases: []string{"fog horn"}}
+	for _, name := range []string{"airhorn", "AIRHORN", "fog-horn", "Fog Horn"} {
+		if !opt.Matches(name) {
+			t.Errorf("Matches(%q) = false", name)
+		}
+	}
+	if opt.Matches("air horn") {
+		t.Error(`Matches("air horn") = true`)
+	}
+}
@@ -1,11 +1,73 @@
 package bot
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+// ErrServiceUnavailable indicates an external service is temporarily unavailable
+// (e.g. a circuit breaker is open) and the caller should fall back or retry later.
+var ErrServiceUnavailable = errors.New("service temporarily unavailable")
 
 // LLMMessage represents a message sent to or received from an LLM.
 type LLMMessage struct {
 	Role    string
 	Content string
+	// Name identifies the speaker of a user message in multi-user chats.
+	// Adapters render it in whatever form their API supports.
+	Name string
+
+	// Images are sent alongside Content by adapters that support vision.
+	Images []Image
+
+	// ToolCalls holds the tools an assistant message asks to invoke.
+	ToolCalls []ToolCall
+	// ToolCallID links a "tool" role message to the call it answers.
+	ToolCallID string
+}
+
+// ToolCallingLLM is implemented by LLM services that support function calling.
+type ToolCallingLLM interface {
+	// ChatCompletionWithTools sends messages along with the tools the model may
+	// call. The returned assistant message carries either content or ToolCalls.
+	ChatCompletionWithTools(ctx context.Context, messages []LLMMessage, tools []ToolDefinition) (LLMMessage, error)
+}
+
+// ChatRequest is an incoming chat message addressed to the bot.
+type ChatRequest struct {
+	GuildID   string
+	ChannelID string
+	UserID    string
+	UserName  string // author's display name
+	MessageID string
+	Content   string
+	Images    []Image // images attached to the message
+
+	// ParentChannelID is the channel a thread belongs to; empty outside threads.
+	ParentChannelID string
+
+	// ReplyChain holds the messages this one replies to, oldest first.
+	ReplyChain []ReferencedMessage
+
+	// Context about where the message was sent, for system prompt templates.
+	GuildName         string
+	ChannelName       string
+	VoiceParticipants []string // display names of users in the bot's voice channel
+}
+
+// Image is an image in a message, referenced by URL or given inline.
+type Image struct {
+	URL      string // http(s) URL the provider fetches
+	MIMEType string // e.g. "image/png"
+	Data     []byte // inline bytes, sent base64-encoded; takes precedence over URL
+}
+
+// ReferencedMessage is an earlier message quoted as context for a chat
+// request, such as the message a user replied to.
+type ReferencedMessage struct {
+	AuthorName string
+	FromBot    bool   // written by this bot
+	Content    string // text, with attachments and embeds summarized
 }
 
 // LLMService defines the port for interacting with a language model.
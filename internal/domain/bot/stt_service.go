@@ -1,9 +1,22 @@
 package bot
 
-import "context"
+import (
+	"context"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/playstats"
+)
 
 // STTService defines the port for speech-to-text transcription.
 type STTService interface {
 	// Transcribe converts raw audio (Opus/PCM) into text.
 	Transcribe(ctx context.Context, audioData []byte) (string, error)
 }
+
+// VoiceCommand represents a parsed voice command result.
+type VoiceCommand struct {
+	// Text is the message to send to the output text channel.
+	Text string
+	// Option is the option a play command plays, and Match how it was chosen.
+	Option string
+	Match  playstats.Match
+}
@@ -0,0 +1,30 @@
+package bot
+
+import "context"
+
+// ToolDefinition describes a function the LLM may call.
+type ToolDefinition struct {
+	Name        string
+	Description string
+	Parameters  map[string]any // JSON Schema for the arguments object
+}
+
+// ToolCall is a request from the LLM to invoke a tool.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments string // JSON-encoded arguments object
+}
+
+// ActionService defines the port for actions the bot can take on the chat
+// platform on behalf of the user who made a request.
+type ActionService interface {
+	// SendCommand posts text (e.g. "!play wow") to the command output channel.
+	SendCommand(ctx context.Context, req ChatRequest, text string) error
+
+	// JoinVoice joins the voice channel the requesting user is in.
+	JoinVoice(ctx context.Context, req ChatRequest) error
+
+	// LeaveVoice leaves the voice channel in the request's guild.
+	LeaveVoice(ctx context.Context, req ChatRequest) error
+}
@@ -0,0 +1,102 @@
+package conversation
+
+import "unicode/utf8"
+
+// messageOverheadTokens approximates the per-message framing (role, separators)
+// that chat APIs add on top of the content.
+const messageOverheadTokens = 4
+
+// ContextPolicy decides how much history is sent to the LLM, based on a token
+// budget rather than a message count.
+type ContextPolicy struct {
+	// MaxTokens is the context window budget for a request. Zero disables
+	// token budgeting and all stored history is sent.
+	MaxTokens int
+	// ReservedReplyTokens are held back from the budget for the model's reply.
+	ReservedReplyTokens int
+	// Estimate counts the tokens in a string. Defaults to EstimateTokens.
+	Estimate func(text string) int
+
+	// SummarizeAfter is the stored message count above which the oldest
+	// messages are condensed into the conversation summary. Zero disables it.
+	SummarizeAfter int
+	// SummaryKeep is how many of the newest messages stay verbatim when
+	// the rest are summarized.
+	SummaryKeep int
+}
+
+// MessageTokens returns the estimated cost of a message, including framing overhead.
+func (p ContextPolicy) MessageTokens(m Message) int {
+	estimate := p.Estimate
+	if estimate == nil {
+		estimate = EstimateTokens
+	}
+	return estimate(m.Text()) + messageOverheadTokens
+}
+
+// Fit returns the newest suffix of msgs whose estimated size fits in budget
+// tokens. The newest message is always included, even if it alone exceeds the
+// budget, so the user's latest turn is never silently dropped.
+func (p ContextPolicy) Fit(msgs []Message, budget int) []Message {
+	if len(msgs) == 0 {
+		return msgs
+	}
+
+	used := 0
+	start := len(msgs)
+	for i := len(msgs) - 1; i >= 0; i-- {
+		cost := p.MessageTokens(msgs[i])
+		if used+cost > budget && i < len(msgs)-1 {
+			break
+		}
+		used += cost
+		start = i
+	}
+	return msgs[start:]
+}
+
+// EstimateTokens approximates the number of BPE tokens in text. It is
+// calibrated against cl100k-style tokenizers for English prose and code:
+// a run of ASCII letters and digits costs one token plus one per further six
+// characters, runs of ASCII punctuation cost one token per two characters,
+// whitespace is folded into the following token, and every non-ASCII rune
+// costs one token (close for CJK, conservative for accented Latin text).
+func EstimateTokens(text string) int {
+	tokens := 0
+	word, symbols := 0, 0
+	flush := func() {
+		if word > 0 {
+			tokens += 1 + (word-1)/6
+			word = 0
+		}
+		if symbols > 0 {
+			tokens += (symbols + 1) / 2
+			symbols = 0
+		}
+	}
+
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+
+		switch {
+		case r >= utf8.RuneSelf:
+			flush()
+			tokens++
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			if symbols > 0 {
+				flush()
+			}
+			word++
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			flush()
+		default:
+			if word > 0 {
+				flush()
+			}
+			symbols++
+		}
+	}
+	flush()
+	return tokens
+}
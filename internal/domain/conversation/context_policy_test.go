@@ -0,0 +1,89 @@
+package conversation
+
+import (
+	"strings"
+	"testing"
+)
+
+// fixedEstimate counts one token per word, keeping budgets easy to reason about.
+func fixedEstimate(text string) int {
+	return len(strings.Fields(text))
+}
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"empty", "", 0},
+		{"short words", "hi there", 2},
+		{"long word", "internationalization", 4},
+		{"punctuation", "Hello, world!", 4},
+		{"code", "x := f(a, b)", 8},
+		{"cjk", "你好世界", 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EstimateTokens(tt.text); got != tt.want {
+				t.Errorf("EstimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllMessages_TokenBudget(t *testing.T) {
+	// Each message costs its word count plus messageOverheadTokens.
+	policy := ContextPolicy{MaxTokens: 40, ReservedReplyTokens: 10, Estimate: fixedEstimate}
+	c := NewConversation("ch", "be brief", 0, policy) // system: 2 + 4 = 6 tokens
+
+	c.AddMessage(NewMessage(RoleUser, strings.Repeat("old ", 20))) // 24 tokens
+	c.AddMessage(NewMessage(RoleAssistant, "a b c d e f"))         // 10 tokens
+	c.AddMessage(NewMessage(RoleUser, "x y z w v u"))              // 10 tokens
+
+	// Budget: 40 - 10 reserved - 6 system = 24, so only the two newest fit.
+	got := c.AllMessages()
+	if len(got) != 3 {
+		t.Fatalf("AllMessages returned %d messages, want 3 (system + 2 newest)", len(got))
+	}
+	if got[0].Role != RoleSystem {
+		t.Errorf("first message role = %q, want system", got[0].Role)
+	}
+	if got[1].Content != "a b c d e f" || got[2].Content != "x y z w v u" {
+		t.Errorf("history = %q, %q; want the two newest messages", got[1].Content, got[2].Content)
+	}
+}
+
+func TestAllMessages_NewestAlwaysIncluded(t *testing.T) {
+	policy := ContextPolicy{MaxTokens: 10, Estimate: fixedEstimate}
+	c := NewConversation("ch", "", 0, policy)
+
+	c.AddMessage(NewMessage(RoleUser, "short"))
+	c.AddMessage(NewMessage(RoleUser, strings.Repeat("huge ", 100)))
+
+	got := c.AllMessages()
+	if len(got) != 1 || !strings.HasPrefix(got[0].Content, "huge") {
+		t.Errorf("AllMessages = %d messages, want only the oversized newest message", len(got))
+	}
+}
+
+func TestAllMessages_NoBudgetSendsEverything(t *testing.T) {
+	c := NewConversation("ch", "sys", 0, ContextPolicy{})
+	for i := 0; i < 100; i++ {
+		c.AddMessage(NewMessage(RoleUser, strings.Repeat("word ", 50)))
+	}
+
+	if got := len(c.AllMessages()); got != 101 {
+		t.Errorf("AllMessages returned %d messages, want 101", got)
+	}
+}
+
+func TestContextPolicy_ImagesCountAsPlaceholders(t *testing.T) {
+	p := ContextPolicy{}
+	text := Message{Role: RoleUser, Content: "look\n[image: a.png]"}
+	withImage := Message{Role: RoleUser, Content: "look", Images: []Image{{Name: "a.png"}}}
+	if got, want := p.MessageTokens(withImage), p.MessageTokens(text); got != want {
+		t.Errorf("message with image = %d tokens, want %d like its placeholder", got, want)
+	}
+}
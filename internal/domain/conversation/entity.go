@@ -1,21 +1,26 @@
 package conversation
 
+import "strings"
+
 // Conversation is the aggregate root for a chat conversation.
 // Each conversation is scoped to a Discord channel.
 type Conversation struct {
 	ChannelID    string
 	SystemPrompt string
 	Messages     []Message
-	MaxHistory   int
+	MaxHistory   int           // hard cap on stored messages
+	Policy       ContextPolicy // decides how much stored history is sent to the LLM
+	Summary      string        // running summary of messages condensed out of Messages
 }
 
 // NewConversation creates a new Conversation for the given channel.
-func NewConversation(channelID string, systemPrompt string, maxHistory int) *Conversation {
+func NewConversation(channelID string, systemPrompt string, maxHistory int, policy ContextPolicy) *Conversation {
 	c := &Conversation{
 		ChannelID:    channelID,
 		SystemPrompt: systemPrompt,
 		Messages:     make([]Message, 0),
 		MaxHistory:   maxHistory,
+		Policy:       policy,
 	}
 	return c
 }
@@ -28,18 +33,90 @@ func (c *Conversation) AddMessage(msg Message) {
 	}
 }
 
-// AllMessages returns the system prompt plus conversation messages
-// in the format expected by LLM APIs.
+// AllMessages returns the system prompt plus the newest conversation messages
+// that fit the context policy's token budget (after the system prompt and
+// reserved reply tokens), in the format expected by LLM APIs.
 func (c *Conversation) AllMessages() []Message {
-	msgs := make([]Message, 0, len(c.Messages)+1)
-	if c.SystemPrompt != "" {
-		msgs = append(msgs, NewMessage(RoleSystem, c.SystemPrompt))
+	history := c.Messages
+	msgs := make([]Message, 0, len(history)+1)
+
+	budget := c.Policy.MaxTokens - c.Policy.ReservedReplyTokens
+	if prompt := c.systemPromptWithParticipants(); prompt != "" {
+		system := NewMessage(RoleSystem, prompt)
+		msgs = append(msgs, system)
+		budget -= c.Policy.MessageTokens(system)
+	}
+	if c.Summary != "" {
+		summary := NewMessage(RoleSystem, "Summary of the earlier conversation:\n"+c.Summary)
+		msgs = append(msgs, summary)
+		budget -= c.Policy.MessageTokens(summary)
 	}
-	msgs = append(msgs, c.Messages...)
+
+	if c.Policy.MaxTokens > 0 {
+		history = c.Policy.Fit(history, budget)
+	}
+	msgs = append(msgs, history...)
 	return msgs
 }
 
-// Clear resets the conversation history.
+// Participants returns the distinct names of users who have spoken in the
+// stored history, in order of first appearance.
+func (c *Conversation) Participants() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, m := range c.Messages {
+		if m.Role != RoleUser || m.AuthorName == "" || seen[m.AuthorName] {
+			continue
+		}
+		seen[m.AuthorName] = true
+		names = append(names, m.AuthorName)
+	}
+	return names
+}
+
+// systemPromptWithParticipants appends a note about who is in the channel, so
+// the model can attribute earlier questions to the right person.
+func (c *Conversation) systemPromptWithParticipants() string {
+	participants := c.Participants()
+	if len(participants) == 0 {
+		return c.SystemPrompt
+	}
+
+	note := "Several people may talk to you in this channel; each user message is attributed to its author. " +
+		"Participants so far: " + strings.Join(participants, ", ") + "."
+	if c.SystemPrompt == "" {
+		return note
+	}
+	return c.SystemPrompt + "\n\n" + note
+}
+
+// PendingSummary returns the oldest messages that should be condensed into the
+// summary, or nil if history hasn't grown past the policy's SummarizeAfter.
+func (c *Conversation) PendingSummary() []Message {
+	if c.Policy.SummarizeAfter <= 0 || len(c.Messages) <= c.Policy.SummarizeAfter {
+		return nil
+	}
+	keep := c.Policy.SummaryKeep
+	if keep < 0 {
+		keep = 0
+	}
+	if keep >= len(c.Messages) {
+		return nil
+	}
+	return c.Messages[:len(c.Messages)-keep]
+}
+
+// ApplySummary replaces the summary and drops the n oldest messages it covers.
+func (c *Conversation) ApplySummary(summary string, n int) {
+	if n > len(c.Messages) {
+		n = len(c.Messages)
+	}
+	c.Summary = summary
+	c.Messages = append(make([]Message, 0, len(c.Messages)-n), c.Messages[n:]...)
+}
+
+// Clear resets the conversation history and summary.
 func (c *Conversation) Clear() {
 	c.Messages = c.Messages[:0]
+	c.Summary = ""
 }
@@ -0,0 +1,77 @@
+package conversation
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func addMessages(c *Conversation, n int) {
+	for i := 0; i < n; i++ {
+		c.AddMessage(NewMessage(RoleUser, fmt.Sprintf("m%d", i)))
+	}
+}
+
+func TestPendingSummary_BelowThreshold(t *testing.T) {
+	c := NewConversation("ch", "", 0, ContextPolicy{SummarizeAfter: 10, SummaryKeep: 4})
+	addMessages(c, 10)
+
+	if got := c.PendingSummary(); got != nil {
+		t.Errorf("PendingSummary = %d messages, want nil at the threshold", len(got))
+	}
+}
+
+func TestPendingSummary_ReturnsOldestBeyondKeep(t *testing.T) {
+	c := NewConversation("ch", "", 0, ContextPolicy{SummarizeAfter: 10, SummaryKeep: 4})
+	addMessages(c, 11)
+
+	got := c.PendingSummary()
+	if len(got) != 7 {
+		t.Fatalf("PendingSummary = %d messages, want 7", len(got))
+	}
+	if got[0].Content != "m0" || got[6].Content != "m6" {
+		t.Errorf("PendingSummary = %q..%q, want m0..m6", got[0].Content, got[6].Content)
+	}
+}
+
+func TestPendingSummary_Disabled(t *testing.T) {
+	c := NewConversation("ch", "", 0, ContextPolicy{})
+	addMessages(c, 100)
+
+	if got := c.PendingSummary(); got != nil {
+		t.Errorf("PendingSummary = %d messages, want nil when disabled", len(got))
+	}
+}
+
+func TestApplySummary_TrimsAndInjects(t *testing.T) {
+	c := NewConversation("ch", "system prompt", 0, ContextPolicy{SummarizeAfter: 10, SummaryKeep: 4})
+	addMessages(c, 11)
+
+	c.ApplySummary("they talked about m0 through m6", len(c.PendingSummary()))
+
+	if len(c.Messages) != 4 || c.Messages[0].Content != "m7" {
+		t.Fatalf("Messages after summary = %d starting %q, want 4 starting m7", len(c.Messages), c.Messages[0].Content)
+	}
+
+	all := c.AllMessages()
+	if all[0].Content != "system prompt" {
+		t.Errorf("AllMessages[0] = %q, want the system prompt", all[0].Content)
+	}
+	if all[1].Role != RoleSystem || !strings.Contains(all[1].Content, "m0 through m6") {
+		t.Errorf("AllMessages[1] = %+v, want the summary right after the system prompt", all[1])
+	}
+	if len(all) != 6 {
+		t.Errorf("AllMessages = %d messages, want 6 (system + summary + 4 kept)", len(all))
+	}
+}
+
+func TestClear_ResetsSummary(t *testing.T) {
+	c := NewConversation("ch", "", 0, ContextPolicy{})
+	addMessages(c, 3)
+	c.ApplySummary("old", 1)
+
+	c.Clear()
+	if len(c.Messages) != 0 || c.Summary != "" {
+		t.Errorf("after Clear: %d messages, summary %q; want empty", len(c.Messages), c.Summary)
+	}
+}
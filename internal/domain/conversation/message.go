@@ -1,6 +1,9 @@
 package conversation
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Role represents the sender role in a conversation message.
 type Role string
@@ -16,6 +19,20 @@ type Message struct {
 	Role      Role
 	Content   string
 	Timestamp time.Time
+
+	// Author attribution for user messages; empty for assistant and system messages.
+	AuthorID   string
+	AuthorName string
+	MessageID  string // Discord message ID
+
+	// Images attached to a user message. Only the turn they arrive with
+	// sends them; history shows a placeholder for each.
+	Images []Image
+}
+
+// Image records an image attached to a message.
+type Image struct {
+	Name string // file name, shown in the placeholder; may be empty
 }
 
 // NewMessage creates a new Message value object.
@@ -26,3 +43,40 @@ func NewMessage(role Role, content string) Message {
 		Timestamp: time.Now(),
 	}
 }
+
+// NewUserMessage creates a user Message attributed to its author.
+func NewUserMessage(authorID, authorName, messageID, content string) Message {
+	m := NewMessage(RoleUser, content)
+	m.AuthorID = authorID
+	m.AuthorName = authorName
+	m.MessageID = messageID
+	return m
+}
+
+// Speaker returns the name to attribute the message to: the author's name
+// for user messages, falling back to the role.
+func (m Message) Speaker() string {
+	if m.AuthorName != "" {
+		return m.AuthorName
+	}
+	return string(m.Role)
+}
+
+// Text returns the message content with a placeholder line for each image.
+func (m Message) Text() string {
+	if len(m.Images) == 0 {
+		return m.Content
+	}
+	lines := make([]string, 0, len(m.Images)+1)
+	if m.Content != "" {
+		lines = append(lines, m.Content)
+	}
+	for _, img := range m.Images {
+		if img.Name == "" {
+			lines = append(lines, "[image]")
+			continue
+		}
+		lines = append(lines, "[image: "+img.Name+"]")
+	}
+	return strings.Join(lines, "\n")
+}
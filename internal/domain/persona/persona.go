@@ -0,0 +1,18 @@
+package persona
+
+import "time"
+
+// Persona is the system prompt chosen for a channel, either a named preset
+// from config or custom text.
+type Persona struct {
+	ChannelID string
+	Preset    string // preset name; empty for a custom prompt
+	Prompt    string // prompt text, kept for presets too in case the preset is removed
+	SetBy     string // user ID of whoever set it
+	UpdatedAt time.Time
+}
+
+// IsPreset reports whether the persona refers to a named preset.
+func (p Persona) IsPreset() bool {
+	return p.Preset != ""
+}
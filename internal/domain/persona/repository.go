@@ -0,0 +1,13 @@
+package persona
+
+// Repository defines the interface for per-channel persona persistence.
+type Repository interface {
+	// FindByChannel retrieves the persona set for a Discord channel.
+	FindByChannel(channelID string) (Persona, bool)
+
+	// Save persists a persona, replacing any previous one for its channel.
+	Save(p Persona) error
+
+	// Delete removes the persona for a channel.
+	Delete(channelID string) error
+}
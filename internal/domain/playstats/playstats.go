@@ -0,0 +1,61 @@
+package playstats
+
+import (
+	"sort"
+	"time"
+)
+
+// Match is how a played option was chosen.
+type Match string
+
+const (
+	MatchExact   Match = "exact"   // the request named the option or an alias
+	MatchHistory Match = "history" // tied candidates were decided by play history
+	MatchLLM     Match = "llm"     // the LLM picked it
+	MatchRaw     Match = "raw"     // nothing matched; the request was played as said
+	MatchTool    Match = "tool"    // the chat LLM played it with a tool
+)
+
+// Play records one dispatched play command.
+type Play struct {
+	Time      time.Time
+	GuildID   string
+	ChannelID string
+	UserID    string
+	Option    string
+	Match     Match
+}
+
+// Count is how many times an option was played.
+type Count struct {
+	Option string
+	Plays  int
+}
+
+// Ranked returns the counts in a map, most played first and then by name,
+// keeping at most n (all for n <= 0).
+func Ranked(counts map[string]int, n int) []Count {
+	ranked := make([]Count, 0, len(counts))
+	for option, plays := range counts {
+		ranked = append(ranked, Count{Option: option, Plays: plays})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Plays != ranked[j].Plays {
+			return ranked[i].Plays > ranked[j].Plays
+		}
+		return ranked[i].Option < ranked[j].Option
+	})
+	if n > 0 && len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
+
+// Repository defines the interface for the play log.
+type Repository interface {
+	// Append adds a play to the log.
+	Append(p Play) error
+
+	// All returns every logged play, oldest first.
+	All() ([]Play, error)
+}
@@ -0,0 +1,65 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Data holds the variables available to system prompt templates.
+type Data struct {
+	GuildName         string
+	ChannelName       string
+	Requester         string    // display name of the user who sent the message
+	Now               time.Time // current time in the configured time zone
+	Date              string    // e.g. "Monday, 2 January 2006"
+	Time              string    // e.g. "15:04 MST"
+	VoiceParticipants []string  // display names of users in the bot's voice channel
+	BotVersion        string
+	PlayOptionCount   int
+}
+
+// NewData fills the date and time fields of Data from now.
+func NewData(now time.Time) Data {
+	return Data{
+		Now:  now,
+		Date: now.Format("Monday, 2 January 2006"),
+		Time: now.Format("15:04 MST"),
+	}
+}
+
+var funcs = template.FuncMap{
+	"join": func(items []string, sep string) string { return strings.Join(items, sep) },
+}
+
+// Template is a parsed system prompt template.
+type Template struct {
+	tmpl *template.Template
+}
+
+// Parse parses a system prompt template and checks that it renders against
+// sample data, so references to unknown variables are caught up front.
+func Parse(text string) (*Template, error) {
+	tmpl, err := template.New("prompt").Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	t := &Template{tmpl: tmpl}
+
+	sample := NewData(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC))
+	sample.VoiceParticipants = []string{"Sam"}
+	if _, err := t.Render(sample); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Render executes the template with data.
+func (t *Template) Render(data Data) (string, error) {
+	var sb strings.Builder
+	if err := t.tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("render prompt: %w", err)
+	}
+	return sb.String(), nil
+}
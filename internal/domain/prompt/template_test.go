@@ -0,0 +1,49 @@
+package prompt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTemplate_RendersVariables(t *testing.T) {
+	tmpl, err := Parse(`You are in #{{.ChannelName}} on {{.GuildName}}. It is {{.Date}}, {{.Time}}. ` +
+		`{{.Requester}} is asking.{{if .VoiceParticipants}} In voice: {{join .VoiceParticipants ", "}}.{{end}} ` +
+		`v{{.BotVersion}}, {{.PlayOptionCount}} sounds.`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	data := NewData(time.Date(2025, 3, 14, 9, 26, 0, 0, time.UTC))
+	data.GuildName = "Lasers"
+	data.ChannelName = "general"
+	data.Requester = "Sam"
+	data.VoiceParticipants = []string{"Sam", "Alex"}
+	data.BotVersion = "1.2.3"
+	data.PlayOptionCount = 42
+
+	got, err := tmpl.Render(data)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	want := "You are in #general on Lasers. It is Friday, 14 March 2025, 09:26 UTC. Sam is asking. In voice: Sam, Alex. v1.2.3, 42 sounds."
+	if got != want {
+		t.Errorf("Render =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, text := range []string{
+		"Hello {{.Requester",       // syntax error
+		"Hello {{.Nickname}}",      // unknown variable
+		`{{.Now.Format}}`,          // wrong arity
+		`{{join .GuildName ", "}}`, // wrong argument type
+	} {
+		if _, err := Parse(text); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", text)
+		}
+	}
+
+	if _, err := Parse("Plain prompt, no variables."); err != nil {
+		t.Errorf("Parse(plain) error: %v", err)
+	}
+}
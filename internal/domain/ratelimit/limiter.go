@@ -0,0 +1,231 @@
+package ratelimit
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Kind identifies what is being rate limited.
+type Kind string
+
+const (
+	KindChat  Kind = "chat"  // LLM chat requests
+	KindVoice Kind = "voice" // dispatched voice commands
+	KindSTT   Kind = "stt"   // seconds of audio sent for transcription
+)
+
+// Scope identifies which identity a bucket is keyed by.
+type Scope string
+
+const (
+	ScopeUser    Scope = "user"
+	ScopeChannel Scope = "channel"
+	ScopeGuild   Scope = "guild"
+)
+
+// scopes lists all scopes in the order they are checked and reported.
+var scopes = []Scope{ScopeUser, ScopeChannel, ScopeGuild}
+
+// Rule configures a single token bucket. A rule with a zero Burst or Refill is disabled.
+type Rule struct {
+	Burst  float64       // maximum tokens the bucket can hold
+	Refill time.Duration // time to regain one token
+}
+
+func (r Rule) enabled() bool {
+	return r.Burst > 0 && r.Refill > 0
+}
+
+// Rules maps each scope to its bucket configuration for one Kind.
+type Rules map[Scope]Rule
+
+// Key identifies the requester of a rate-limited action.
+type Key struct {
+	GuildID   string
+	ChannelID string
+	UserID    string
+}
+
+func (k Key) id(scope Scope) string {
+	switch scope {
+	case ScopeUser:
+		return k.UserID
+	case ScopeChannel:
+		return k.ChannelID
+	case ScopeGuild:
+		return k.GuildID
+	}
+	return ""
+}
+
+// Decision is the result of a rate limit check.
+type Decision struct {
+	Allowed bool
+	// Scope is the scope that refused the request (empty when allowed).
+	Scope Scope
+	// RetryAfter is how long until the request would be allowed.
+	RetryAfter time.Duration
+	// Notify is true for the first refusal since the bucket last allowed a request,
+	// so callers can send a single cooldown message instead of one per attempt.
+	Notify bool
+}
+
+// BucketStatus is a point-in-time view of a single bucket.
+type BucketStatus struct {
+	Kind   Kind
+	Scope  Scope
+	Tokens float64
+	Rule   Rule
+}
+
+type bucketKey struct {
+	kind  Kind
+	scope Scope
+	id    string
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	warned bool
+}
+
+// pruneInterval is how often idle, fully refilled buckets are dropped.
+const pruneInterval = time.Minute
+
+// Limiter enforces token-bucket limits keyed by user, channel and guild.
+// It is safe for concurrent use.
+type Limiter struct {
+	mu        sync.Mutex
+	rules     map[Kind]Rules
+	buckets   map[bucketKey]*bucket
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+// NewLimiter creates a Limiter with the given rules per kind.
+func NewLimiter(rules map[Kind]Rules) *Limiter {
+	return &Limiter{
+		rules:   rules,
+		buckets: make(map[bucketKey]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow checks whether an action of the given kind and cost may proceed for key.
+// Tokens are only deducted when every applicable scope has capacity, so a refusal
+// by the guild bucket doesn't also drain the user's bucket. A cost larger than a
+// bucket's burst is allowed from a full bucket and leaves it in debt.
+func (l *Limiter) Allow(kind Kind, key Key, cost float64) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.pruneLocked(now)
+
+	type candidate struct {
+		b    *bucket
+		rule Rule
+	}
+	var candidates []candidate
+	var refused Decision
+
+	for _, scope := range scopes {
+		rule, ok := l.rules[kind][scope]
+		id := key.id(scope)
+		if !ok || !rule.enabled() || id == "" {
+			continue
+		}
+
+		b := l.bucketLocked(bucketKey{kind, scope, id}, rule, now)
+		need := math.Min(cost, rule.Burst)
+		if b.tokens < need {
+			wait := time.Duration((need - b.tokens) * float64(rule.Refill))
+			if wait > refused.RetryAfter {
+				refused = Decision{Scope: scope, RetryAfter: wait, Notify: !b.warned}
+			}
+			b.warned = true
+			continue
+		}
+		candidates = append(candidates, candidate{b, rule})
+	}
+
+	if refused.Scope != "" {
+		return refused
+	}
+
+	for _, c := range candidates {
+		c.b.tokens -= cost
+		c.b.warned = false
+	}
+	return Decision{Allowed: true}
+}
+
+// Status returns the current state of every bucket that applies to key.
+func (l *Limiter) Status(key Key) []BucketStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	kinds := make([]Kind, 0, len(l.rules))
+	for kind := range l.rules {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+
+	var statuses []BucketStatus
+	for _, kind := range kinds {
+		for _, scope := range scopes {
+			rule, ok := l.rules[kind][scope]
+			id := key.id(scope)
+			if !ok || !rule.enabled() || id == "" {
+				continue
+			}
+			tokens := rule.Burst
+			if b, ok := l.buckets[bucketKey{kind, scope, id}]; ok {
+				tokens = refilled(b, rule, now)
+			}
+			statuses = append(statuses, BucketStatus{Kind: kind, Scope: scope, Tokens: tokens, Rule: rule})
+		}
+	}
+	return statuses
+}
+
+// bucketLocked returns the bucket for k, creating a full one if needed,
+// and brings its token count up to date. Caller must hold l.mu.
+func (l *Limiter) bucketLocked(k bucketKey, rule Rule, now time.Time) *bucket {
+	b, ok := l.buckets[k]
+	if !ok {
+		b = &bucket{tokens: rule.Burst, last: now}
+		l.buckets[k] = b
+		return b
+	}
+	b.tokens = refilled(b, rule, now)
+	b.last = now
+	return b
+}
+
+// pruneLocked drops buckets that have refilled completely, since they are
+// indistinguishable from a freshly created one. Caller must hold l.mu.
+func (l *Limiter) pruneLocked(now time.Time) {
+	if now.Sub(l.lastPrune) < pruneInterval {
+		return
+	}
+	l.lastPrune = now
+	for k, b := range l.buckets {
+		if refilled(b, l.rules[k.kind][k.scope], now) >= l.rules[k.kind][k.scope].Burst {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// refilled computes a bucket's tokens at now without mutating it.
+func refilled(b *bucket, rule Rule, now time.Time) float64 {
+	elapsed := now.Sub(b.last)
+	if elapsed <= 0 {
+		return b.tokens
+	}
+	return math.Min(rule.Burst, b.tokens+float64(elapsed)/float64(rule.Refill))
+}
@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestLimiter returns a Limiter whose clock is controlled by the returned pointer.
+func newTestLimiter(rules map[Kind]Rules) (*Limiter, *time.Time) {
+	now := time.Unix(1_700_000_000, 0)
+	l := NewLimiter(rules)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+var alice = Key{GuildID: "g1", ChannelID: "c1", UserID: "alice"}
+
+func TestAllow_BurstThenRefuse(t *testing.T) {
+	l, _ := newTestLimiter(map[Kind]Rules{
+		KindChat: {ScopeUser: {Burst: 3, Refill: 10 * time.Second}},
+	})
+
+	for i := 0; i < 3; i++ {
+		if d := l.Allow(KindChat, alice, 1); !d.Allowed {
+			t.Fatalf("request %d refused, want allowed", i+1)
+		}
+	}
+
+	d := l.Allow(KindChat, alice, 1)
+	if d.Allowed {
+		t.Fatal("4th request allowed, want refused")
+	}
+	if d.Scope != ScopeUser {
+		t.Errorf("Scope = %q, want %q", d.Scope, ScopeUser)
+	}
+	if d.RetryAfter != 10*time.Second {
+		t.Errorf("RetryAfter = %s, want 10s", d.RetryAfter)
+	}
+}
+
+func TestAllow_Refill(t *testing.T) {
+	l, now := newTestLimiter(map[Kind]Rules{
+		KindChat: {ScopeUser: {Burst: 1, Refill: 5 * time.Second}},
+	})
+
+	l.Allow(KindChat, alice, 1)
+	if d := l.Allow(KindChat, alice, 1); d.Allowed {
+		t.Fatal("second request allowed before refill")
+	}
+
+	*now = now.Add(5 * time.Second)
+	if d := l.Allow(KindChat, alice, 1); !d.Allowed {
+		t.Fatal("request refused after refill interval")
+	}
+}
+
+func TestAllow_KeyedIndependently(t *testing.T) {
+	l, _ := newTestLimiter(map[Kind]Rules{
+		KindChat: {ScopeUser: {Burst: 1, Refill: time.Minute}},
+	})
+	bob := Key{GuildID: "g1", ChannelID: "c1", UserID: "bob"}
+
+	l.Allow(KindChat, alice, 1)
+	if d := l.Allow(KindChat, bob, 1); !d.Allowed {
+		t.Error("bob refused because of alice's usage")
+	}
+	if d := l.Allow(KindVoice, alice, 1); !d.Allowed {
+		t.Error("voice refused although only chat is limited")
+	}
+}
+
+func TestAllow_RefusalDoesNotDrainOtherScopes(t *testing.T) {
+	l, _ := newTestLimiter(map[Kind]Rules{
+		KindChat: {
+			ScopeUser:  {Burst: 5, Refill: time.Second},
+			ScopeGuild: {Burst: 1, Refill: time.Minute},
+		},
+	})
+
+	l.Allow(KindChat, alice, 1)
+	d := l.Allow(KindChat, alice, 1)
+	if d.Allowed || d.Scope != ScopeGuild {
+		t.Fatalf("Allow = %+v, want refused by guild", d)
+	}
+
+	for _, st := range l.Status(alice) {
+		if st.Scope == ScopeUser && st.Tokens != 4 {
+			t.Errorf("user tokens = %v, want 4 (refused request must not be charged)", st.Tokens)
+		}
+	}
+}
+
+func TestAllow_NotifyOncePerCooldown(t *testing.T) {
+	l, now := newTestLimiter(map[Kind]Rules{
+		KindChat: {ScopeUser: {Burst: 1, Refill: time.Second}},
+	})
+
+	l.Allow(KindChat, alice, 1)
+	if d := l.Allow(KindChat, alice, 1); !d.Notify {
+		t.Error("first refusal should notify")
+	}
+	if d := l.Allow(KindChat, alice, 1); d.Notify {
+		t.Error("repeated refusal should not notify")
+	}
+
+	*now = now.Add(time.Second)
+	l.Allow(KindChat, alice, 1)
+	if d := l.Allow(KindChat, alice, 1); !d.Notify {
+		t.Error("refusal after an allowed request should notify again")
+	}
+}
+
+func TestAllow_CostAboveBurstLeavesDebt(t *testing.T) {
+	l, now := newTestLimiter(map[Kind]Rules{
+		KindSTT: {ScopeUser: {Burst: 10, Refill: time.Second}},
+	})
+
+	if d := l.Allow(KindSTT, alice, 15); !d.Allowed {
+		t.Fatal("oversized cost refused from a full bucket")
+	}
+
+	*now = now.Add(4 * time.Second)
+	d := l.Allow(KindSTT, alice, 1)
+	if d.Allowed {
+		t.Fatal("request allowed while bucket still in debt")
+	}
+	if d.RetryAfter != 2*time.Second {
+		t.Errorf("RetryAfter = %s, want 2s", d.RetryAfter)
+	}
+}
+
+func TestAllow_DisabledRules(t *testing.T) {
+	l, _ := newTestLimiter(map[Kind]Rules{
+		KindChat: {ScopeUser: {Burst: 0, Refill: time.Second}},
+	})
+
+	for i := 0; i < 100; i++ {
+		if d := l.Allow(KindChat, alice, 1); !d.Allowed {
+			t.Fatal("disabled rule refused a request")
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package usage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Period is the window a quota applies to.
+type Period string
+
+const (
+	PeriodDaily   Period = "daily"
+	PeriodMonthly Period = "monthly"
+)
+
+// Periods lists all periods in reporting order.
+var Periods = []Period{PeriodDaily, PeriodMonthly}
+
+// Start returns the beginning of the period containing t, in t's location.
+func (p Period) Start(t time.Time) time.Time {
+	y, m, d := t.Date()
+	if p == PeriodMonthly {
+		d = 1
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// End returns the beginning of the period after the one containing t.
+func (p Period) End(t time.Time) time.Time {
+	start := p.Start(t)
+	if p == PeriodMonthly {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// Quota caps the usage of each user, channel or guild over a period. Zero
+// limits are not enforced.
+type Quota struct {
+	Scope        Scope
+	Period       Period
+	Tokens       int
+	AudioSeconds float64
+	Cost         float64 // USD
+}
+
+// Exceeded reports whether totals have reached any of the quota's limits.
+func (q Quota) Exceeded(t Totals) bool {
+	return (q.Tokens > 0 && t.Tokens() >= q.Tokens) ||
+		(q.AudioSeconds > 0 && t.AudioSeconds >= q.AudioSeconds) ||
+		(q.Cost > 0 && t.Cost >= q.Cost)
+}
+
+// Validate checks the quota names a known scope and period and sets a limit.
+func (q Quota) Validate() error {
+	switch q.Scope {
+	case ScopeUser, ScopeChannel, ScopeGuild:
+	default:
+		return fmt.Errorf("unknown scope %q (want user, channel or guild)", q.Scope)
+	}
+	switch q.Period {
+	case PeriodDaily, PeriodMonthly:
+	default:
+		return fmt.Errorf("unknown period %q (want daily or monthly)", q.Period)
+	}
+	if q.Tokens < 0 || q.AudioSeconds < 0 || q.Cost < 0 {
+		return errors.New("limits must not be negative")
+	}
+	if q.Tokens == 0 && q.AudioSeconds == 0 && q.Cost == 0 {
+		return errors.New("set at least one of tokens, audioseconds or cost")
+	}
+	return nil
+}
+
+// ErrQuotaExceeded is matched by every QuotaError.
+var ErrQuotaExceeded = errors.New("usage quota exceeded")
+
+// QuotaError reports the quota that blocked a request.
+type QuotaError struct {
+	Quota Quota
+	Reset time.Time // when the quota's period ends
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s %s usage quota exceeded until %s", e.Quota.Period, e.Quota.Scope, e.Reset.Format(time.RFC3339))
+}
+
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
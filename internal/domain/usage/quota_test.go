@@ -0,0 +1,74 @@
+package usage
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPeriodBounds(t *testing.T) {
+	loc := time.FixedZone("test", -5*3600)
+	now := time.Date(2026, 1, 31, 23, 30, 0, 0, loc)
+
+	if got, want := PeriodDaily.Start(now), time.Date(2026, 1, 31, 0, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("daily start = %s, want %s", got, want)
+	}
+	if got, want := PeriodDaily.End(now), time.Date(2026, 2, 1, 0, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("daily end = %s, want %s", got, want)
+	}
+	if got, want := PeriodMonthly.Start(now), time.Date(2026, 1, 1, 0, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("monthly start = %s, want %s", got, want)
+	}
+	if got, want := PeriodMonthly.End(now), time.Date(2026, 2, 1, 0, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("monthly end = %s, want %s", got, want)
+	}
+}
+
+func TestQuotaExceeded(t *testing.T) {
+	q := Quota{Scope: ScopeUser, Period: PeriodDaily, Tokens: 1000, Cost: 0.5}
+
+	if q.Exceeded(Totals{PromptTokens: 600, CompletionTokens: 399, Cost: 0.49}) {
+		t.Error("quota exceeded below both limits")
+	}
+	if !q.Exceeded(Totals{PromptTokens: 600, CompletionTokens: 400}) {
+		t.Error("token limit not enforced")
+	}
+	if !q.Exceeded(Totals{Cost: 0.5}) {
+		t.Error("cost limit not enforced")
+	}
+	if q.Exceeded(Totals{AudioSeconds: 1e6}) {
+		t.Error("unset audio limit enforced")
+	}
+}
+
+func TestQuotaValidate(t *testing.T) {
+	if err := (Quota{Scope: ScopeGuild, Period: PeriodMonthly, AudioSeconds: 3600}).Validate(); err != nil {
+		t.Errorf("valid quota: %v", err)
+	}
+	for name, q := range map[string]Quota{
+		"scope":    {Scope: "server", Period: PeriodDaily, Tokens: 1},
+		"period":   {Scope: ScopeUser, Period: "weekly", Tokens: 1},
+		"no limit": {Scope: ScopeUser, Period: PeriodDaily},
+		"negative": {Scope: ScopeUser, Period: PeriodDaily, Tokens: -1},
+	} {
+		if err := q.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestPriceCost(t *testing.T) {
+	p := Price{Input: 2.5, Output: 10, AudioMinute: 0.006}
+	got := p.Cost(Record{PromptTokens: 1000, CompletionTokens: 500, AudioSeconds: 30})
+	if want := 0.0025 + 0.005 + 0.003; math.Abs(got-want) > 1e-12 {
+		t.Errorf("Cost = %v, want %v", got, want)
+	}
+}
+
+func TestQuotaErrorIs(t *testing.T) {
+	var err error = &QuotaError{Quota: Quota{Scope: ScopeUser, Period: PeriodDaily}, Reset: time.Now()}
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Error("QuotaError should match ErrQuotaExceeded")
+	}
+}
@@ -0,0 +1,12 @@
+package usage
+
+import "time"
+
+// Repository defines the interface for the usage ledger.
+type Repository interface {
+	// Append adds a record to the ledger.
+	Append(r Record) error
+
+	// Since returns the records at or after t, oldest first.
+	Since(t time.Time) ([]Record, error)
+}
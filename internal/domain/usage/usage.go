@@ -0,0 +1,106 @@
+package usage
+
+import (
+	"context"
+	"time"
+)
+
+// Record is the usage of one metered API call.
+type Record struct {
+	Time             time.Time
+	GuildID          string
+	ChannelID        string
+	UserID           string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	AudioSeconds     float64 // speech-to-text
+	Cost             float64 // USD, from the configured prices
+}
+
+// Recorder receives usage reported by API adapters. The request the usage
+// belongs to is identified by the Attribution in ctx.
+type Recorder interface {
+	Record(ctx context.Context, r Record)
+}
+
+// Scope identifies which identity usage is totalled by.
+type Scope string
+
+const (
+	ScopeUser    Scope = "user"
+	ScopeChannel Scope = "channel"
+	ScopeGuild   Scope = "guild"
+)
+
+// Scopes lists all scopes in reporting order.
+var Scopes = []Scope{ScopeUser, ScopeChannel, ScopeGuild}
+
+// Attribution identifies who a metered request is made for.
+type Attribution struct {
+	GuildID   string
+	ChannelID string
+	UserID    string
+}
+
+// ID returns the identity for a scope, or "" if unknown.
+func (a Attribution) ID(scope Scope) string {
+	switch scope {
+	case ScopeUser:
+		return a.UserID
+	case ScopeChannel:
+		return a.ChannelID
+	case ScopeGuild:
+		return a.GuildID
+	}
+	return ""
+}
+
+type attributionKey struct{}
+
+// WithAttribution returns a context whose metered calls are attributed to a.
+func WithAttribution(ctx context.Context, a Attribution) context.Context {
+	return context.WithValue(ctx, attributionKey{}, a)
+}
+
+// AttributionFrom returns the attribution stored in ctx, if any.
+func AttributionFrom(ctx context.Context) Attribution {
+	a, _ := ctx.Value(attributionKey{}).(Attribution)
+	return a
+}
+
+// Totals sums usage over a number of records.
+type Totals struct {
+	Requests         int
+	PromptTokens     int
+	CompletionTokens int
+	AudioSeconds     float64
+	Cost             float64
+}
+
+// Add counts a record.
+func (t *Totals) Add(r Record) {
+	t.Requests++
+	t.PromptTokens += r.PromptTokens
+	t.CompletionTokens += r.CompletionTokens
+	t.AudioSeconds += r.AudioSeconds
+	t.Cost += r.Cost
+}
+
+// Tokens returns prompt and completion tokens combined.
+func (t Totals) Tokens() int {
+	return t.PromptTokens + t.CompletionTokens
+}
+
+// Price is what a model costs, in USD.
+type Price struct {
+	Input       float64 // per million prompt tokens
+	Output      float64 // per million completion tokens
+	AudioMinute float64 // per minute of transcribed audio
+}
+
+// Cost prices a record.
+func (p Price) Cost(r Record) float64 {
+	return (float64(r.PromptTokens)*p.Input+float64(r.CompletionTokens)*p.Output)/1e6 +
+		r.AudioSeconds/60*p.AudioMinute
+}
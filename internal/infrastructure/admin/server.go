@@ -0,0 +1,101 @@
+// Package admin serves health, readiness and metrics endpoints over HTTP for
+// orchestrators and Prometheus.
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// checkTimeout bounds each readiness check.
+const checkTimeout = 5 * time.Second
+
+// Check is a named readiness check, passing when it returns nil.
+type Check struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// Server serves /healthz, /readyz and /metrics.
+type Server struct {
+	server   *http.Server
+	listener net.Listener
+	checks   []Check
+}
+
+// NewServer creates a Server listening on addr. /metrics is served by
+// metrics, and /readyz passes when every check does.
+func NewServer(addr string, metrics http.Handler, checks ...Check) *Server {
+	s := &Server{checks: checks}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	mux.HandleFunc("GET /readyz", s.handleReady)
+	mux.Handle("GET /metrics", metrics)
+	s.server = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Start listens on the server's address and serves in the background.
+func (s *Server) Start() error {
+	ln, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", s.server.Addr, err)
+	}
+	s.listener = ln
+	go func() {
+		if err := s.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("admin server stopped", "err", err)
+		}
+	}()
+	return nil
+}
+
+// Addr returns the address the server is listening on, once started.
+func (s *Server) Addr() string {
+	if s.listener == nil {
+		return s.server.Addr
+	}
+	return s.listener.Addr().String()
+}
+
+// Stop shuts the server down, waiting for requests in flight until ctx is done.
+func (s *Server) Stop(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+// handleHealth reports that the process is up.
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "ok")
+}
+
+// handleReady runs every check, answering 503 with the failures when any fail.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+	defer cancel()
+
+	var failures []string
+	for _, c := range s.checks {
+		if err := c.Check(ctx); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", c.Name, err))
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if len(failures) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, strings.Join(failures, "\n"))
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
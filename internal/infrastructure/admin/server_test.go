@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, h http.Handler, path string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	body, _ := io.ReadAll(rec.Body)
+	return rec.Code, string(body)
+}
+
+func TestServer_Endpoints(t *testing.T) {
+	gatewayErr := errors.New("not connected")
+	metrics := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		io.WriteString(w, "laserbeak_up 1\n")
+	})
+	s := NewServer(":0", metrics,
+		Check{Name: "discord", Check: func(context.Context) error { return gatewayErr }},
+		Check{Name: "play options", Check: func(context.Context) error { return nil }},
+	)
+	h := s.server.Handler
+
+	if code, body := get(t, h, "/healthz"); code != http.StatusOK || body != "ok\n" {
+		t.Errorf("/healthz = %d %q", code, body)
+	}
+	if code, body := get(t, h, "/readyz"); code != http.StatusServiceUnavailable || body != "discord: not connected\n" {
+		t.Errorf("/readyz = %d %q, want 503 naming the failed check", code, body)
+	}
+	gatewayErr = nil
+	if code, _ := get(t, h, "/readyz"); code != http.StatusOK {
+		t.Errorf("/readyz = %d once every check passes, want 200", code)
+	}
+	if code, body := get(t, h, "/metrics"); code != http.StatusOK || !strings.Contains(body, "laserbeak_up") {
+		t.Errorf("/metrics = %d %q", code, body)
+	}
+}
+
+func TestServer_StartAndStop(t *testing.T) {
+	s := NewServer("127.0.0.1:0", http.NotFoundHandler())
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get("http://" + s.Addr() + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if err := s.Stop(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,150 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// maxPacketSamples is the most samples per channel in one Opus packet (120ms).
+const maxPacketSamples = 5760
+
+// OggOpus is an Ogg Opus stream split into its audio packets.
+type OggOpus struct {
+	Channels int // channel count from the OpusHead header
+	PreSkip  int // samples per channel to drop from the start of the decoded audio
+	Packets  [][]byte
+}
+
+// ParseOggOpus demuxes an Ogg Opus file, such as a Discord voice message,
+// into Opus packets. Only the first logical stream is read; page checksums
+// aren't verified.
+func ParseOggOpus(data []byte) (*OggOpus, error) {
+	var (
+		packets [][]byte
+		partial []byte
+		serial  uint32
+		first   = true
+	)
+
+	for len(data) > 0 {
+		if len(data) < 27 || !bytes.Equal(data[:4], []byte("OggS")) {
+			return nil, errors.New("invalid ogg page header")
+		}
+		headerType := data[5]
+		pageSerial := binary.LittleEndian.Uint32(data[14:18])
+		segments := int(data[26])
+		if len(data) < 27+segments {
+			return nil, errors.New("truncated ogg segment table")
+		}
+		lacing := data[27 : 27+segments]
+		body := data[27+segments:]
+
+		if first {
+			serial, first = pageSerial, false
+		}
+		size := 0
+		for _, l := range lacing {
+			size += int(l)
+		}
+		if len(body) < size {
+			return nil, errors.New("truncated ogg page")
+		}
+		data = body[size:]
+		if pageSerial != serial {
+			continue
+		}
+		if headerType&0x01 == 0 {
+			// Not a continuation: drop any packet left unfinished.
+			partial = nil
+		}
+
+		for _, l := range lacing {
+			partial = append(partial, body[:l]...)
+			body = body[l:]
+			if l < 255 {
+				packets = append(packets, partial)
+				partial = nil
+			}
+		}
+	}
+
+	if len(packets) < 2 {
+		return nil, errors.New("missing opus headers")
+	}
+	head := packets[0]
+	if len(head) < 19 || !bytes.Equal(head[:8], []byte("OpusHead")) {
+		return nil, errors.New("not an ogg opus stream")
+	}
+	if !bytes.HasPrefix(packets[1], []byte("OpusTags")) {
+		return nil, errors.New("missing OpusTags header")
+	}
+
+	return &OggOpus{
+		Channels: int(head[9]),
+		PreSkip:  int(binary.LittleEndian.Uint16(head[10:12])),
+		Packets:  packets[2:],
+	}, nil
+}
+
+// PacketDuration returns the audio length of an Opus packet from its TOC
+// byte (RFC 6716, section 3.1), or zero for a malformed packet.
+func PacketDuration(packet []byte) time.Duration {
+	if len(packet) == 0 {
+		return 0
+	}
+	config := int(packet[0] >> 3)
+	var frame time.Duration
+	switch {
+	case config < 12: // SILK: 10, 20, 40 or 60ms
+		frame = [...]time.Duration{10, 20, 40, 60}[config%4] * time.Millisecond
+	case config < 16: // hybrid: 10 or 20ms
+		frame = [...]time.Duration{10, 20}[config%2] * time.Millisecond
+	default: // CELT: 2.5, 5, 10 or 20ms
+		frame = [...]time.Duration{2500, 5000, 10000, 20000}[config%4] * time.Microsecond
+	}
+
+	frames := 1
+	switch packet[0] & 0x03 {
+	case 1, 2:
+		frames = 2
+	case 3:
+		if len(packet) < 2 {
+			return 0
+		}
+		frames = int(packet[1] & 0x3F)
+	}
+	return time.Duration(frames) * frame
+}
+
+// OggOpusToWAV decodes an Ogg Opus file to a 48kHz stereo WAV, the same
+// format as audio captured from voice channels.
+func OggOpusToWAV(data []byte) ([]byte, error) {
+	stream, err := ParseOggOpus(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse ogg: %w", err)
+	}
+
+	dec, err := NewOpusDecoder()
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []int16
+	buf := make([]int16, maxPacketSamples*Channels)
+	for _, packet := range stream.Packets {
+		n, err := dec.DecodeInto(packet, buf)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, buf[:n]...)
+	}
+
+	skip := stream.PreSkip * Channels
+	if skip > len(samples) {
+		skip = len(samples)
+	}
+	return PCMToWAV(samples[skip:], SampleRate, Channels)
+}
@@ -0,0 +1,94 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// oggPage builds an Ogg page holding the given packet data, split into
+// lacing segments. continued marks the first packet as carried over from the
+// previous page; a final segment of 255 leaves the last packet unfinished.
+func oggPage(serial uint32, continued bool, lacing []byte, body []byte) []byte {
+	page := make([]byte, 27, 27+len(lacing)+len(body))
+	copy(page, "OggS")
+	if continued {
+		page[5] = 0x01
+	}
+	binary.LittleEndian.PutUint32(page[14:18], serial)
+	page[26] = byte(len(lacing))
+	page = append(page, lacing...)
+	return append(page, body...)
+}
+
+func opusHead(channels byte, preSkip uint16) []byte {
+	head := []byte("OpusHead\x01")
+	head = append(head, channels)
+	head = binary.LittleEndian.AppendUint16(head, preSkip)
+	head = binary.LittleEndian.AppendUint32(head, 48000)
+	return append(head, 0, 0, 0)
+}
+
+func TestParseOggOpus(t *testing.T) {
+	head := opusHead(1, 312)
+	tags := []byte("OpusTags\x00\x00\x00\x00\x00\x00\x00\x00")
+	long := bytes.Repeat([]byte{0xAB}, 300) // spans two pages
+
+	var file []byte
+	file = append(file, oggPage(7, false, []byte{byte(len(head))}, head)...)
+	file = append(file, oggPage(7, false, []byte{byte(len(tags))}, tags)...)
+	file = append(file, oggPage(9, false, []byte{3}, []byte("xyz"))...) // another stream
+	file = append(file, oggPage(7, false, []byte{2, 255}, append([]byte("p1"), long[:255]...))...)
+	file = append(file, oggPage(7, true, []byte{45, 1}, append(long[255:], 'z'))...)
+
+	stream, err := ParseOggOpus(file)
+	if err != nil {
+		t.Fatalf("ParseOggOpus: %v", err)
+	}
+	if stream.Channels != 1 || stream.PreSkip != 312 {
+		t.Errorf("channels=%d preSkip=%d, want 1 and 312", stream.Channels, stream.PreSkip)
+	}
+	if len(stream.Packets) != 3 {
+		t.Fatalf("packets = %d, want 3", len(stream.Packets))
+	}
+	if string(stream.Packets[0]) != "p1" || !bytes.Equal(stream.Packets[1], long) || string(stream.Packets[2]) != "z" {
+		t.Errorf("packets = %q", stream.Packets)
+	}
+}
+
+func TestParseOggOpus_Invalid(t *testing.T) {
+	vorbis := []byte("\x01vorbis-header-data")
+	for name, data := range map[string][]byte{
+		"not ogg":   []byte("RIFF....WAVE"),
+		"truncated": oggPage(1, false, []byte{10}, []byte("short")),
+		"vorbis": append(oggPage(1, false, []byte{byte(len(vorbis))}, vorbis),
+			oggPage(1, false, []byte{4}, []byte("tags"))...),
+	} {
+		if _, err := ParseOggOpus(data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestPacketDuration(t *testing.T) {
+	tests := []struct {
+		name   string
+		packet []byte
+		want   time.Duration
+	}{
+		{"CELT 20ms", []byte{31 << 3}, 20 * time.Millisecond},
+		{"CELT 2.5ms", []byte{16 << 3}, 2500 * time.Microsecond},
+		{"SILK 60ms", []byte{3 << 3}, 60 * time.Millisecond},
+		{"hybrid 10ms", []byte{12 << 3}, 10 * time.Millisecond},
+		{"two frames", []byte{31<<3 | 1}, 40 * time.Millisecond},
+		{"frame count byte", []byte{31<<3 | 3, 3}, 60 * time.Millisecond},
+		{"missing frame count", []byte{31<<3 | 3}, 0},
+		{"empty", nil, 0},
+	}
+	for _, tt := range tests {
+		if got := PacketDuration(tt.packet); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
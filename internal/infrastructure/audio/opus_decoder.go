@@ -3,6 +3,7 @@ package audio
 import (
 	"encoding/binary"
 	"fmt"
+	"time"
 	"unsafe"
 
 	"gopkg.in/hraban/opus.v2"
@@ -84,3 +85,17 @@ func PCMToWAV(samples []int16, sampleRate, channels int) ([]byte, error) {
 
 	return buf, nil
 }
+
+// WAVDuration returns the playback length of a WAV byte slice produced by PCMToWAV.
+// Returns 0 if the header is missing or malformed.
+func WAVDuration(wav []byte) time.Duration {
+	if len(wav) < 44 {
+		return 0
+	}
+	byteRate := binary.LittleEndian.Uint32(wav[28:32])
+	if byteRate == 0 {
+		return 0
+	}
+	dataSize := binary.LittleEndian.Uint32(wav[40:44])
+	return time.Duration(float64(dataSize) / float64(byteRate) * float64(time.Second))
+}
@@ -0,0 +1,62 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/ratelimit"
+)
+
+var _ bot.ActionService = (*Bot)(nil)
+
+// errVoiceRateLimited tells the chat LLM a command was refused by the voice
+// rate limit, so it can say so instead of retrying.
+var errVoiceRateLimited = errors.New("voice commands are rate limited right now; tell the user to try again shortly")
+
+// SendCommand implements bot.ActionService by posting text to the command
+// output channel, or the request's channel if none is configured. In
+// soundboard mode, play and stop commands are carried out instead and their
+// outcome posted. Commands count toward the voice rate limit, like spoken ones.
+func (b *Bot) SendCommand(ctx context.Context, req bot.ChatRequest, text string) error {
+	outputCh := b.outputChannel(req.ChannelID)
+	key := ratelimit.Key{GuildID: req.GuildID, ChannelID: req.ChannelID, UserID: req.UserID}
+	if !b.allow(ratelimit.KindVoice, key, 1, outputCh) {
+		return errVoiceRateLimited
+	}
+
+	b.countCommand(text, "tool")
+	reply, handled, err := b.soundCommand(ctx, req.GuildID, req.UserID, req.ChannelID, text)
+	if err != nil {
+		return err
+	}
+	if handled {
+		text = reply
+	}
+	if _, err := b.session.ChannelMessageSend(outputCh, text); err != nil {
+		return fmt.Errorf("send message: %w", err)
+	}
+	return nil
+}
+
+// JoinVoice implements bot.ActionService by joining the requesting user's
+// voice channel.
+func (b *Bot) JoinVoice(_ context.Context, req bot.ChatRequest) error {
+	voiceChannelID := userVoiceChannel(b.session, req.GuildID, req.UserID)
+	if voiceChannelID == "" {
+		return errors.New("the user is not in a voice channel")
+	}
+	if err := b.voiceListener.Join(b.session, req.GuildID, voiceChannelID, b.outputChannel(req.ChannelID)); err != nil {
+		return fmt.Errorf("join voice channel: %w", err)
+	}
+	return nil
+}
+
+// LeaveVoice implements bot.ActionService.
+func (b *Bot) LeaveVoice(_ context.Context, req bot.ChatRequest) error {
+	if !b.leaveVoice(req.GuildID) {
+		return errors.New("not in a voice channel")
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"github.com/bwmarrin/discordgo"
+)
+
+// imageTypes are the image formats passed to the LLM.
+var imageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/webp": true,
+	"image/gif":  true,
+}
+
+// maxTextFiles is the most text attachments read per message.
+const maxTextFiles = 5
+
+// attachmentInput splits a message's attachments into images to send to the
+// LLM and text files to read, within the configured count and size limits,
+// and text notes describing everything else, so the LLM knows what was
+// attached.
+func (b *Bot) attachmentInput(attachments []*discordgo.MessageAttachment) (images []bot.Image, texts []*discordgo.MessageAttachment, notes []string) {
+	maxBytes := b.config.MaxImageMB << 20
+	for _, a := range attachments {
+		mimeType, _, _ := strings.Cut(a.ContentType, ";")
+		if !imageTypes[mimeType] && isTextAttachment(a) && b.config.MaxTextKB > 0 {
+			switch {
+			case len(texts) >= maxTextFiles:
+				notes = append(notes, skippedAttachment(a, fmt.Sprintf("only %d files are read per message", maxTextFiles)))
+			case a.Size > b.config.MaxTextKB<<10:
+				notes = append(notes, skippedAttachment(a, fmt.Sprintf("larger than %d KB", b.config.MaxTextKB)))
+			default:
+				texts = append(texts, a)
+			}
+			continue
+		}
+
+		switch {
+		case !imageTypes[mimeType]:
+			notes = append(notes, summarizeAttachment(a))
+		case b.config.MaxImages <= 0:
+			notes = append(notes, summarizeAttachment(a))
+		case len(images) >= b.config.MaxImages:
+			notes = append(notes, skippedAttachment(a, fmt.Sprintf("only %d images are sent per message", b.config.MaxImages)))
+		case maxBytes > 0 && a.Size > maxBytes:
+			notes = append(notes, skippedAttachment(a, fmt.Sprintf("larger than %d MB", b.config.MaxImageMB)))
+		default:
+			images = append(images, bot.Image{URL: a.URL, MIMEType: mimeType})
+		}
+	}
+	return images, texts, notes
+}
+
+// skippedAttachment describes an attachment that wasn't sent and why.
+func skippedAttachment(a *discordgo.MessageAttachment, reason string) string {
+	summary := summarizeAttachment(a)
+	return strings.TrimSuffix(summary, "]") + " — not sent: " + reason + "]"
+}
@@ -0,0 +1,62 @@
+package discord
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestAttachmentInput_Limits(t *testing.T) {
+	b := &Bot{config: BotConfig{MaxImages: 2, MaxImageMB: 1}}
+	attachments := []*discordgo.MessageAttachment{
+		{Filename: "a.png", URL: "https://cdn.example/a.png", ContentType: "image/png", Size: 1000},
+		{Filename: "huge.jpg", URL: "https://cdn.example/huge.jpg", ContentType: "image/jpeg", Size: 5 << 20},
+		{Filename: "b.webp", URL: "https://cdn.example/b.webp", ContentType: "image/webp", Size: 1000},
+		{Filename: "c.gif", URL: "https://cdn.example/c.gif", ContentType: "image/gif", Size: 1000},
+		{Filename: "d.svg", URL: "https://cdn.example/d.svg", ContentType: "image/svg+xml", Size: 1000},
+	}
+
+	images, _, notes := b.attachmentInput(attachments)
+	if len(images) != 2 || images[0].URL != "https://cdn.example/a.png" || images[1].MIMEType != "image/webp" {
+		t.Errorf("images = %+v, want a.png and b.webp", images)
+	}
+
+	joined := strings.Join(notes, "\n")
+	for _, want := range []string{
+		"huge.jpg (image/jpeg, 5.0 MB) — not sent: larger than 1 MB",
+		"c.gif (image/gif, 1000 B) — not sent: only 2 images are sent per message",
+		"[attachment: d.svg (image/svg+xml, 1000 B)]",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("notes missing %q:\n%s", want, joined)
+		}
+	}
+}
+
+func TestAttachmentInput_VisionDisabled(t *testing.T) {
+	b := &Bot{config: BotConfig{MaxImages: 0}}
+	images, _, notes := b.attachmentInput([]*discordgo.MessageAttachment{
+		{Filename: "a.png", ContentType: "image/png", Size: 10},
+	})
+	if len(images) != 0 || len(notes) != 1 {
+		t.Errorf("images=%v notes=%v, want the image described, not sent", images, notes)
+	}
+}
+
+func TestAttachmentInput_TextFiles(t *testing.T) {
+	b := &Bot{config: BotConfig{MaxImages: 4, MaxTextKB: 1}}
+	_, texts, notes := b.attachmentInput([]*discordgo.MessageAttachment{
+		{Filename: "main.go", ContentType: "application/octet-stream", Size: 100},
+		{Filename: "notes", ContentType: "text/plain; charset=utf-8", Size: 100},
+		{Filename: "big.log", ContentType: "text/plain", Size: 4096},
+		{Filename: "app.exe", ContentType: "application/octet-stream", Size: 100},
+	})
+	if len(texts) != 2 || texts[0].Filename != "main.go" || texts[1].Filename != "notes" {
+		t.Errorf("texts = %+v, want main.go and notes", texts)
+	}
+	joined := strings.Join(notes, "\n")
+	if !strings.Contains(joined, "big.log (text/plain, 4 KB) — not sent: larger than 1 KB") || !strings.Contains(joined, "app.exe") {
+		t.Errorf("notes = %s", joined)
+	}
+}
@@ -2,28 +2,56 @@ package discord
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/ratelimit"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/usage"
+	"github.com/adrock-miles/go-laserbeak/internal/infrastructure/audio"
+	"github.com/adrock-miles/go-laserbeak/internal/logging"
 	"github.com/bwmarrin/discordgo"
 )
 
 // ChatHandler defines the callback for processing a chat message and returning a response.
-type ChatHandler func(ctx context.Context, channelID, userID, content string) (string, error)
+type ChatHandler func(ctx context.Context, req bot.ChatRequest) (string, error)
 
-// VoiceCommandHandler defines the callback for processing voice audio into a command string.
-type VoiceCommandHandler func(ctx context.Context, channelID, userID string, audioWAV []byte) (string, error)
+// ChatQueue reserves a channel's next chat turn as a message arrives,
+// returning the handler that answers it in that turn.
+type ChatQueue func(channelID string) ChatHandler
+
+// VoiceCommandHandler defines the callback for processing voice audio into a command.
+type VoiceCommandHandler func(ctx context.Context, channelID, userID string, audioWAV []byte) (bot.VoiceCommand, error)
+
+// VoiceMessageHandler transcribes a voice message, returning the transcript and
+// the command parsed from it, if any.
+type VoiceMessageHandler func(ctx context.Context, channelID, userID string, audioWAV []byte) (transcript string, command bot.VoiceCommand, err error)
 
 // BotConfig holds Discord bot configuration.
 type BotConfig struct {
-	Token          string
-	CommandPrefix  string
-	GuildID        string // guild for auto-join
-	VoiceChannelID string // voice channel to auto-join
-	TextChannelID  string // text channel for voice command output
+	Token            string
+	CommandPrefix    string
+	GuildID          string        // guild for auto-join
+	VoiceChannelID   string        // voice channel to auto-join
+	TextChannelID    string        // text channel for voice command output
+	Threads          bool          // start a thread for each chat in a regular channel
+	ThreadArchive    time.Duration // inactivity before the bot's threads auto-archive
+	ReplyDepth       int           // referenced messages to include when a chat message is a reply; 0 disables
+	MaxImages        int           // image attachments sent to the LLM per message; 0 disables vision
+	MaxImageMB       int           // largest image attachment sent, in megabytes; 0 means no limit
+	MaxTextKB        int           // largest text attachment read into the chat, in kilobytes; 0 disables
+	TextTokens       int           // token budget shared by a message's text attachments; 0 means no limit
+	MaxReplyChunks   int           // replies needing more messages are sent as a file; 0 disables
+	VoiceMessages    bool          // transcribe voice messages for wake-phrase commands
+	VoiceMessageChat bool          // answer voice messages in bot channels that aren't commands as chat
+	OptionsRoles     []string      // role IDs or names allowed to change play options and personas; empty means Manage Server
 }
 
 // Bot wraps the Discord session and routes messages to application-layer handlers.
@@ -31,14 +59,23 @@ type Bot struct {
 	session       *discordgo.Session
 	config        BotConfig
 	chatHandler   ChatHandler
+	chatQueue     ChatQueue
 	voiceHandler  VoiceCommandHandler
+	voiceMessages VoiceMessageHandler
 	voiceListener *VoiceListener
+	limiter       *ratelimit.Limiter
+	personas      PersonaManager
+	usage         UsageReporter
+	options       OptionsManager
+	playStats     PlayStatsReporter
+	metrics       Metrics
+	sounds        SoundLibrary
+	playback      *playback    // nil unless in soundboard mode
+	httpClient    *http.Client // downloads text attachments
+	connected     atomic.Bool  // gateway session is up
 
 	seenMu sync.Mutex
 	seenID string // last processed message ID to deduplicate gateway redeliveries
-
-	// chatSem limits concurrent LLM requests to avoid overwhelming the API.
-	chatSem chan struct{}
 }
 
 // NewBot creates a new Discord Bot.
@@ -57,10 +94,17 @@ func NewBot(cfg BotConfig) (*Bot, error) {
 		session:       s,
 		config:        cfg,
 		voiceListener: NewVoiceListener(),
-		chatSem:       make(chan struct{}, 10), // up to 10 concurrent LLM requests
+		httpClient:    &http.Client{Timeout: textDownloadTimeout},
 	}
 
+	s.AddHandler(b.onReady)
+	s.AddHandler(b.onResumed)
+	s.AddHandler(b.onDisconnect)
 	s.AddHandler(b.onMessageCreate)
+	if cfg.Threads {
+		s.AddHandler(b.onThreadUpdate)
+		s.AddHandler(b.onThreadDelete)
+	}
 
 	return b, nil
 }
@@ -70,11 +114,29 @@ func (b *Bot) SetChatHandler(h ChatHandler) {
 	b.chatHandler = h
 }
 
+// SetChatQueue answers chat messages in the order they arrived, even when
+// some take longer to prepare. Without it they are handed to the chat
+// handler as soon as they are ready.
+func (b *Bot) SetChatQueue(q ChatQueue) {
+	b.chatQueue = q
+}
+
 // SetVoiceHandler sets the handler for voice command processing.
 func (b *Bot) SetVoiceHandler(h VoiceCommandHandler) {
 	b.voiceHandler = h
 }
 
+// SetVoiceMessageHandler sets the handler for transcribing voice messages.
+func (b *Bot) SetVoiceMessageHandler(h VoiceMessageHandler) {
+	b.voiceMessages = h
+}
+
+// SetRateLimiter enables per-user, per-channel and per-guild limits on chat
+// requests, voice commands and STT audio.
+func (b *Bot) SetRateLimiter(l *ratelimit.Limiter) {
+	b.limiter = l
+}
+
 // Start opens the Discord websocket connection and begins listening.
 func (b *Bot) Start() error {
 	if err := b.session.Open(); err != nil {
@@ -86,7 +148,7 @@ func (b *Bot) Start() error {
 		go b.processVoiceResults()
 	}
 
-	log.Println("Bot is online and listening. Use the join command to connect to a voice channel.")
+	slog.Info("bot is online and listening")
 	return nil
 }
 
@@ -102,24 +164,47 @@ func (b *Bot) onMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate)
 		return
 	}
 
-	if !strings.HasPrefix(m.Content, b.config.CommandPrefix) {
+	if m.Flags&discordgo.MessageFlagsIsVoiceMessage != 0 {
+		b.handleVoiceMessage(s, m)
 		return
 	}
 
-	// Deduplicate: gateway reconnects can redeliver the same event
-	b.seenMu.Lock()
-	if m.ID == b.seenID {
-		b.seenMu.Unlock()
+	// In soundboard mode the bot plays the commands it would otherwise relay,
+	// from people in its own channels.
+	if b.playback != nil && isSoundCommand(m.Content) {
+		if m.Author.Bot || !b.isBotChannel(m.ChannelID) || !b.firstDelivery(m.ID) {
+			return
+		}
+		key := ratelimit.Key{GuildID: m.GuildID, ChannelID: m.ChannelID, UserID: m.Author.ID}
+		if b.allow(ratelimit.KindVoice, key, 1, m.ChannelID) {
+			b.handleSoundCommand(s, m, m.Content)
+		}
+		return
+	}
+
+	// People's messages in a thread the bot started continue its conversation
+	// without the prefix. Looking the thread up may call the API, so it's left
+	// until a message isn't prefixed.
+	content, prefixed := strings.CutPrefix(m.Content, b.config.CommandPrefix)
+	if !prefixed && (m.Author.Bot || !b.isConversationThread(m.ChannelID)) {
+		return
+	}
+
+	if !b.firstDelivery(m.ID) {
 		return
 	}
-	b.seenID = m.ID
-	b.seenMu.Unlock()
 
-	content := strings.TrimPrefix(m.Content, b.config.CommandPrefix)
 	content = strings.TrimSpace(content)
 
-	if content == "" {
-		b.handleHelp(s, m)
+	if content == "" && len(m.Attachments) == 0 {
+		if prefixed {
+			b.handleHelp(s, m)
+		}
+		return
+	}
+
+	if !prefixed {
+		b.routeChat(s, m, content)
 		return
 	}
 
@@ -134,70 +219,257 @@ func (b *Bot) onMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate)
 	case content == "clear":
 		b.handleClear(s, m)
 		return
+	case content == "summary":
+		b.handleSummary(s, m)
+		return
 	case content == "help":
 		b.handleHelp(s, m)
 		return
+	case content == "limits":
+		b.handleLimits(s, m)
+		return
+	case content == "usage" || strings.HasPrefix(content, "usage "):
+		b.handleUsage(s, m)
+		return
+	case content == "persona" || strings.HasPrefix(content, "persona "):
+		b.handlePersona(s, m, strings.TrimPrefix(content, "persona"))
+		return
+	case content == "top" || strings.HasPrefix(content, "top "):
+		b.handleTop(s, m, strings.TrimPrefix(content, "top"))
+		return
+	case content == "favourites" || strings.HasPrefix(content, "favourites ") ||
+		content == "favorites" || strings.HasPrefix(content, "favorites "):
+		b.handleFavourites(s, m)
+		return
+	case content == "options" || strings.HasPrefix(content, "options "):
+		b.handleOptions(s, m, strings.TrimPrefix(content, "options"))
+		return
+	case b.playback != nil && (content == "stop" || content == "skip" || content == "queue"):
+		b.handleSoundCommand(s, m, "!"+content)
+		return
+	}
+
+	b.routeChat(s, m, content)
+}
+
+// firstDelivery records a message as processed, returning false if it already
+// was: gateway reconnects can redeliver the same event.
+func (b *Bot) firstDelivery(messageID string) bool {
+	b.seenMu.Lock()
+	defer b.seenMu.Unlock()
+	if messageID == b.seenID {
+		return false
 	}
+	b.seenID = messageID
+	return true
+}
 
-	// Route to chat handler
+// messageContext returns the context for handling a message: usage and log
+// records are attributed to its sender, under a new correlation ID.
+func messageContext(m *discordgo.MessageCreate) context.Context {
+	ctx := logging.WithCorrelationID(context.Background(), logging.NewCorrelationID())
+	return withAttribution(ctx, m.GuildID, m.ChannelID, m.Author.ID)
+}
+
+// routeChat sends a chat message to the chat handler, starting a thread for
+// it first when thread mode is on and the message is in a regular channel.
+func (b *Bot) routeChat(s *discordgo.Session, m *discordgo.MessageCreate, content string) {
 	if b.chatHandler == nil {
 		return
 	}
 
+	ctx := messageContext(m)
+	req := b.chatRequest(m, content)
+	images, texts, notes := b.attachmentInput(m.Attachments)
+	req.Images = images
+	if len(notes) > 0 {
+		req.Content = strings.TrimSpace(req.Content + "\n" + strings.Join(notes, "\n"))
+	}
+
+	// Threads share their parent channel's channel-scope rate limit.
+	key := ratelimit.Key{GuildID: m.GuildID, ChannelID: m.ChannelID, UserID: m.Author.ID}
+	if req.ParentChannelID != "" {
+		key.ChannelID = req.ParentChannelID
+	}
+	if !b.allow(ratelimit.KindChat, key, 1, m.ChannelID) {
+		return
+	}
+
+	if m.Type == discordgo.MessageTypeReply && b.config.ReplyDepth > 0 {
+		req.ReplyChain = b.replyChain(s, m.Message)
+	}
+
+	if b.config.Threads && m.GuildID != "" && req.ParentChannelID == "" {
+		thread, err := b.startThread(s, m, content)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to start thread, replying in channel", "message", m.ID, "err", err)
+		} else {
+			req.ParentChannelID = m.ChannelID
+			req.ChannelID = thread.ID
+			req.ChannelName = thread.Name
+		}
+	}
+
 	// Dispatch asynchronously so the gateway handler returns immediately.
 	// The semaphore bounds concurrent LLM requests.
-	go b.handleChat(s, m.ChannelID, m.Author.ID, content)
+	b.dispatchChat(ctx, s, req, texts)
 }
 
-// handleChat processes a chat message asynchronously with bounded concurrency.
-func (b *Bot) handleChat(s *discordgo.Session, channelID, userID, content string) {
+// dispatchChat answers a chat message in the background. Its turn in the
+// channel is reserved first, so text attachments can be downloaded and
+// appended to the request while earlier messages are still being answered.
+// Only ctx's values, such as the correlation ID, outlive the caller.
+func (b *Bot) dispatchChat(ctx context.Context, s *discordgo.Session, req bot.ChatRequest, texts []*discordgo.MessageAttachment) {
+	ctx = context.WithoutCancel(ctx)
+	handler := b.chatHandler
+	if b.chatQueue != nil {
+		handler = b.chatQueue(req.ChannelID)
+	}
+
+	go func() {
+		if len(texts) > 0 {
+			req.Content = strings.TrimSpace(req.Content + "\n\n" + strings.Join(b.textFiles(ctx, texts), "\n\n"))
+		}
+		b.handleChat(ctx, s, req, handler)
+	}()
+}
+
+// chatRequest builds a ChatRequest for a message, attributing it to the author's
+// display name and describing where it was sent.
+func (b *Bot) chatRequest(m *discordgo.MessageCreate, content string) bot.ChatRequest {
+	req := bot.ChatRequest{
+		GuildID:           m.GuildID,
+		ChannelID:         m.ChannelID,
+		UserID:            m.Author.ID,
+		UserName:          displayName(m),
+		MessageID:         m.ID,
+		Content:           content,
+		VoiceParticipants: b.voiceParticipants(m.GuildID),
+	}
+	if g, err := b.session.State.Guild(m.GuildID); err == nil {
+		req.GuildName = g.Name
+	}
+	if ch := b.channel(m.ChannelID); ch != nil {
+		req.ChannelName = ch.Name
+		if ch.IsThread() {
+			req.ParentChannelID = ch.ParentID
+		}
+	}
+	return req
+}
+
+// voiceParticipants returns the display names of the users in the bot's
+// voice channel in a guild, from the cached guild state.
+func (b *Bot) voiceParticipants(guildID string) []string {
+	channelID, ok := b.voiceListener.ChannelID(guildID)
+	if !ok {
+		return nil
+	}
+	state := b.session.State
+	g, err := state.Guild(guildID)
+	if err != nil {
+		return nil
+	}
+
+	// Copy the states first: State.Member takes the state lock itself.
+	state.RLock()
+	var inChannel []*discordgo.VoiceState
+	for _, vs := range g.VoiceStates {
+		if vs.ChannelID == channelID && (state.User == nil || vs.UserID != state.User.ID) {
+			inChannel = append(inChannel, vs)
+		}
+	}
+	state.RUnlock()
+
+	names := make([]string, 0, len(inChannel))
+	for _, vs := range inChannel {
+		name := vs.UserID
+		if vs.Member != nil {
+			name = memberName(vs.Member)
+		} else if member, err := state.Member(guildID, vs.UserID); err == nil {
+			name = memberName(member)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// displayName returns the author's guild nickname, falling back to their global display name.
+func displayName(m *discordgo.MessageCreate) string {
+	if m.Member != nil && m.Member.Nick != "" {
+		return m.Member.Nick
+	}
+	return m.Author.DisplayName()
+}
+
+// memberName returns a guild member's nickname, falling back to their global display name.
+func memberName(m *discordgo.Member) string {
+	if m.Nick != "" {
+		return m.Nick
+	}
+	if m.User != nil {
+		return m.User.DisplayName()
+	}
+	return ""
+}
+
+// handleChat answers a chat message with handler and sends the reply.
+func (b *Bot) handleChat(ctx context.Context, s *discordgo.Session, req bot.ChatRequest, handler ChatHandler) {
+	channelID := req.ChannelID
+
 	// Fire-and-forget typing indicator (don't block on it).
 	go s.ChannelTyping(channelID)
 
-	// Acquire semaphore slot to bound concurrent LLM calls.
-	b.chatSem <- struct{}{}
-	defer func() { <-b.chatSem }()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
 	defer cancel()
 
-	reply, err := b.chatHandler(ctx, channelID, userID, content)
+	reply, err := handler(ctx, req)
+	result := chatResult(err)
+	if b.metrics != nil {
+		b.metrics.ChatRequest(result)
+	}
 	if err != nil {
-		log.Printf("chat handler error: %v", err)
-		s.ChannelMessageSend(channelID, "Sorry, I encountered an error processing your message.")
+		slog.ErrorContext(ctx, "chat handler failed", "result", result, "err", err)
+		switch result {
+		case "quota":
+			var quotaErr *usage.QuotaError
+			errors.As(err, &quotaErr)
+			s.ChannelMessageSend(channelID, quotaMessage(quotaErr, req.UserID))
+		case "unavailable":
+			s.ChannelMessageSend(channelID, "I can't reach my language model right now. Please try again in a minute.")
+		default:
+			s.ChannelMessageSend(channelID, "Sorry, I encountered an error processing your message.")
+		}
 		return
 	}
 
 	b.sendLongMessage(s, channelID, reply)
 }
 
-// handleJoinVoice joins the voice channel the user is currently in.
-func (b *Bot) handleJoinVoice(s *discordgo.Session, m *discordgo.MessageCreate) {
-	// Try cached state first, fall back to REST API if stale
-	var voiceChannelID string
-	vs, err := s.State.VoiceState(m.GuildID, m.Author.ID)
-	if err == nil {
-		voiceChannelID = vs.ChannelID
-	} else {
-		vs, err := s.UserVoiceState(m.GuildID, m.Author.ID)
-		if err == nil {
-			voiceChannelID = vs.ChannelID
-		}
+// chatResult classifies a chat handler error for metrics.
+func chatResult(err error) string {
+	var quotaErr *usage.QuotaError
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.As(err, &quotaErr):
+		return "quota"
+	case errors.Is(err, bot.ErrServiceUnavailable):
+		return "unavailable"
 	}
+	return "error"
+}
 
+// handleJoinVoice joins the voice channel the user is currently in.
+func (b *Bot) handleJoinVoice(s *discordgo.Session, m *discordgo.MessageCreate) {
+	voiceChannelID := userVoiceChannel(s, m.GuildID, m.Author.ID)
 	if voiceChannelID == "" {
 		s.ChannelMessageSend(m.ChannelID, "You need to be in a voice channel first.")
 		return
 	}
 
-	// Use configured text channel for output, fall back to the channel the command was sent in
-	textCh := b.config.TextChannelID
-	if textCh == "" {
-		textCh = m.ChannelID
-	}
-
-	if err := b.voiceListener.Join(s, m.GuildID, voiceChannelID, textCh); err != nil {
-		log.Printf("error joining voice: %v", err)
+	if err := b.voiceListener.Join(s, m.GuildID, voiceChannelID, b.outputChannel(m.ChannelID)); err != nil {
+		slog.Error("failed to join voice", "guild", m.GuildID, "channel", voiceChannelID, "err", err)
 		s.ChannelMessageSend(m.ChannelID, "Failed to join your voice channel.")
 		return
 	}
@@ -207,28 +479,70 @@ func (b *Bot) handleJoinVoice(s *discordgo.Session, m *discordgo.MessageCreate)
 
 // handleLeaveVoice leaves the voice channel in the current guild.
 func (b *Bot) handleLeaveVoice(s *discordgo.Session, m *discordgo.MessageCreate) {
-	// Try both the message's guild ID and the configured guild ID,
+	if b.leaveVoice(m.GuildID) {
+		s.ChannelMessageSend(m.ChannelID, "Left voice channel.")
+	} else {
+		s.ChannelMessageSend(m.ChannelID, "I'm not in a voice channel.")
+	}
+}
+
+// userVoiceChannel returns the voice channel userID is in, or "" if none.
+func userVoiceChannel(s *discordgo.Session, guildID, userID string) string {
+	// Try cached state first, fall back to REST API if stale
+	if vs, err := s.State.VoiceState(guildID, userID); err == nil {
+		return vs.ChannelID
+	}
+	if vs, err := s.UserVoiceState(guildID, userID); err == nil {
+		return vs.ChannelID
+	}
+	return ""
+}
+
+// leaveVoice leaves the voice channel in guildID, reporting whether the bot
+// was connected.
+func (b *Bot) leaveVoice(guildID string) bool {
+	// Try both the given guild ID and the configured guild ID,
 	// since they may differ if guild state is stale after a reconnect.
-	left := b.voiceListener.Leave(m.GuildID)
-	if !left && b.config.GuildID != "" && b.config.GuildID != m.GuildID {
+	left := b.voiceListener.Leave(guildID)
+	if !left && b.config.GuildID != "" && b.config.GuildID != guildID {
 		left = b.voiceListener.Leave(b.config.GuildID)
 	}
+	if b.playback != nil {
+		b.playback.stop(guildID)
+	}
+	return left
+}
 
-	if left {
-		s.ChannelMessageSend(m.ChannelID, "Left voice channel.")
-	} else {
-		s.ChannelMessageSend(m.ChannelID, "I'm not in a voice channel.")
+// outputChannel returns the configured text channel for command output,
+// falling back to the given channel.
+func (b *Bot) outputChannel(fallback string) string {
+	if b.config.TextChannelID != "" {
+		return b.config.TextChannelID
 	}
+	return fallback
 }
 
 // handleClear resets conversation history for this channel.
 func (b *Bot) handleClear(s *discordgo.Session, m *discordgo.MessageCreate) {
 	if b.chatHandler != nil {
-		b.chatHandler(context.Background(), m.ChannelID, m.Author.ID, "/clear")
+		b.chatHandler(context.Background(), b.chatRequest(m, "/clear"))
 	}
 	s.ChannelMessageSend(m.ChannelID, "Conversation history cleared.")
 }
 
+// handleSummary shows the running summary of this channel's earlier conversation.
+func (b *Bot) handleSummary(s *discordgo.Session, m *discordgo.MessageCreate) {
+	var summary string
+	if b.chatHandler != nil {
+		summary, _ = b.chatHandler(context.Background(), b.chatRequest(m, "/summary"))
+	}
+	if strings.TrimSpace(summary) == "" {
+		s.ChannelMessageSend(m.ChannelID, "No summary yet — the conversation hasn't grown long enough to need one.")
+		return
+	}
+	b.sendLongMessage(s, m.ChannelID, "**Conversation summary**\n"+summary)
+}
+
 // handleHelp sends usage information.
 func (b *Bot) handleHelp(s *discordgo.Session, m *discordgo.MessageCreate) {
 	prefix := b.config.CommandPrefix
@@ -237,11 +551,19 @@ func (b *Bot) handleHelp(s *discordgo.Session, m *discordgo.MessageCreate) {
 		"`%s join` — Join your voice channel and listen\n"+
 		"`%s leave` — Leave voice channel\n"+
 		"`%s clear` — Clear conversation history\n"+
+		"`%s summary` — Show the summary of earlier conversation\n"+
+		"`%s limits` — Show your current rate limits\n"+
+		"`%s persona show|set|reset|list` — Manage this channel's persona\n"+
+		"`%s usage [@user]` — Show API usage and quotas\n"+
+		"`%s options list|search|add|remove` — Browse and manage play options\n"+
+		"`%s top [week|all]` — Show the most played sounds\n"+
+		"`%s favourites [@user]` — Show the sounds you play most\n"+
+		"`%s stop|skip|queue` — Control sounds playing in voice (soundboard mode)\n"+
 		"`%s help` — Show this help\n\n"+
-		"**Voice Commands** (say in voice chat):\n"+
-		"`laser stop` — Sends `!stop` to text chat\n"+
-		"`laser play <query>` — Sends `!play <query>` to text chat",
-		prefix, prefix, prefix, prefix, prefix)
+		"**Voice Commands** (say in voice chat or send a voice message):\n"+
+		"`laser stop` — Sends `!stop` to text chat, or stops the sound in soundboard mode\n"+
+		"`laser play <query>` — Sends `!play <query>` to text chat, or plays it in soundboard mode",
+		prefix, prefix, prefix, prefix, prefix, prefix, prefix, prefix, prefix, prefix, prefix, prefix, prefix)
 	s.ChannelMessageSend(m.ChannelID, help)
 }
 
@@ -253,37 +575,82 @@ func (b *Bot) processVoiceResults() {
 		}
 
 		go func(t VoiceTranscription) {
-			reply, err := b.voiceHandler(context.Background(), t.ChannelID, t.UserID, t.Audio)
+			// Send voice command output to the configured text channel,
+			// falling back to the transcription's associated channel
+			outputCh := b.outputChannel(t.ChannelID)
+
+			// Most utterances aren't commands, so STT refusals are only logged.
+			key := ratelimit.Key{GuildID: t.GuildID, ChannelID: t.ChannelID, UserID: t.UserID}
+			if !b.allow(ratelimit.KindSTT, key, audio.WAVDuration(t.Audio).Seconds(), "") {
+				return
+			}
+
+			ctx := logging.WithCorrelationID(context.Background(), t.CorrelationID)
+			ctx = withAttribution(ctx, t.GuildID, t.ChannelID, t.UserID)
+			cmd, err := b.voiceHandler(ctx, t.ChannelID, t.UserID, t.Audio)
 			if err != nil {
-				log.Printf("voice handler error: %v", err)
+				slog.ErrorContext(ctx, "voice handler failed", "err", err)
 				return
 			}
 
-			if strings.TrimSpace(reply) == "" {
+			if strings.TrimSpace(cmd.Text) == "" {
 				return
 			}
 
-			// Send voice command output to the configured text channel,
-			// falling back to the transcription's associated channel
-			outputCh := b.config.TextChannelID
-			if outputCh == "" {
-				outputCh = t.ChannelID
+			if !b.allow(ratelimit.KindVoice, key, 1, outputCh) {
+				return
 			}
 
-			b.session.ChannelMessageSend(outputCh, reply)
+			b.sendVoiceCommand(ctx, t.GuildID, t.UserID, outputCh, cmd, "voice")
 		}(trans)
 	}
 }
 
+// maxMessageLength is Discord's message length limit, in characters.
+const maxMessageLength = 2000
+
 // sendLongMessage splits messages that exceed Discord's 2000 character limit.
+// Content needing more than the configured number of messages is sent as an
+// attached Markdown file instead.
 func (b *Bot) sendLongMessage(s *discordgo.Session, channelID, content string) {
-	const maxLen = 2000
-	for len(content) > 0 {
-		end := maxLen
-		if end > len(content) {
-			end = len(content)
+	chunks := splitMessage(content, maxMessageLength)
+	if b.config.MaxReplyChunks > 0 && len(chunks) > b.config.MaxReplyChunks {
+		_, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+			Content: "That's a long one, so it's attached as a file.",
+			Files: []*discordgo.File{{
+				Name:        "reply.md",
+				ContentType: "text/markdown",
+				Reader:      strings.NewReader(content),
+			}},
+		})
+		if err == nil {
+			return
+		}
+		slog.Warn("failed to send reply as a file, sending messages", "channel", channelID, "messages", len(chunks), "err", err)
+	}
+	for _, chunk := range chunks {
+		s.ChannelMessageSend(channelID, chunk)
+	}
+}
+
+// splitMessage cuts content into pieces of at most limit characters,
+// preferring to break after a newline and never splitting a character.
+func splitMessage(content string, limit int) []string {
+	var chunks []string
+	for utf8.RuneCountInString(content) > limit {
+		end := 0
+		for i := 0; i < limit; i++ {
+			_, size := utf8.DecodeRuneInString(content[end:])
+			end += size
 		}
-		s.ChannelMessageSend(channelID, content[:end])
+		if nl := strings.LastIndexByte(content[:end], '\n'); nl > 0 {
+			end = nl + 1
+		}
+		chunks = append(chunks, content[:end])
 		content = content[end:]
 	}
+	if content != "" {
+		chunks = append(chunks, content)
+	}
+	return chunks
 }
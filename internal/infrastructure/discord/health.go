@@ -0,0 +1,38 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// onReady records that the gateway connection is up.
+func (b *Bot) onReady(*discordgo.Session, *discordgo.Ready) {
+	b.connected.Store(true)
+}
+
+// onResumed records that a dropped gateway connection has resumed.
+func (b *Bot) onResumed(*discordgo.Session, *discordgo.Resumed) {
+	b.connected.Store(true)
+}
+
+// onDisconnect records that the gateway connection is down.
+func (b *Bot) onDisconnect(*discordgo.Session, *discordgo.Disconnect) {
+	b.connected.Store(false)
+}
+
+// Ready returns an error unless the gateway is connected and, when a voice
+// channel is configured, the bot is in voice in that guild.
+func (b *Bot) Ready(context.Context) error {
+	if !b.connected.Load() {
+		return errors.New("not connected to the Discord gateway")
+	}
+	if b.config.GuildID != "" && b.config.VoiceChannelID != "" {
+		if _, ok := b.voiceListener.ChannelID(b.config.GuildID); !ok {
+			return fmt.Errorf("not in a voice channel in guild %s", b.config.GuildID)
+		}
+	}
+	return nil
+}
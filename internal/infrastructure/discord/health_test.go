@@ -0,0 +1,26 @@
+package discord
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBotReady(t *testing.T) {
+	b := &Bot{voiceListener: NewVoiceListener()}
+	if err := b.Ready(context.Background()); err == nil {
+		t.Error("ready before connecting to the gateway")
+	}
+	b.onResumed(nil, nil)
+	if err := b.Ready(context.Background()); err != nil {
+		t.Errorf("Ready = %v, want nil without a configured voice channel", err)
+	}
+
+	b.config = BotConfig{GuildID: "g1", VoiceChannelID: "v1"}
+	if err := b.Ready(context.Background()); err == nil {
+		t.Error("ready without joining the configured voice channel")
+	}
+	b.onDisconnect(nil, nil)
+	if err := b.Ready(context.Background()); err == nil {
+		t.Error("still ready after a disconnect")
+	}
+}
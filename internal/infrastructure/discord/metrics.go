@@ -0,0 +1,39 @@
+package discord
+
+import "strings"
+
+// Metrics counts what the bot does, for the admin server's /metrics.
+type Metrics interface {
+	// ChatRequest counts an answered chat request by result: "ok", "error",
+	// "quota" or "unavailable".
+	ChatRequest(result string)
+	// VoiceUtterance counts an utterance heard in voice: "emitted" for
+	// transcription, or dropped as "short" (too short to transcribe), "encode"
+	// (WAV conversion failed) or "shutdown" (the bot left voice first).
+	VoiceUtterance(result string)
+	// CommandDispatched counts a play or stop command by where it came from:
+	// "voice", "voice_message", "tool" or "text".
+	CommandDispatched(command, source string)
+	// RateLimited counts a request refused by the rate limiter.
+	RateLimited(kind string)
+}
+
+// SetMetrics enables counting chat requests, voice utterances, commands and
+// rate limit refusals.
+func (b *Bot) SetMetrics(m Metrics) {
+	b.metrics = m
+	b.voiceListener.SetMetrics(m)
+}
+
+// commandName returns the name of a command such as "!play wow", for metrics.
+func commandName(command string) string {
+	name, _, _ := strings.Cut(strings.TrimSpace(command), " ")
+	return strings.ToLower(name)
+}
+
+// countCommand counts a dispatched command when metrics are enabled.
+func (b *Bot) countCommand(command, source string) {
+	if b.metrics != nil {
+		b.metrics.CommandDispatched(commandName(command), source)
+	}
+}
@@ -0,0 +1,206 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"github.com/bwmarrin/discordgo"
+)
+
+// optionsSearchLimit caps the results shown by "options search".
+const optionsSearchLimit = 15
+
+// OptionsManager lists, searches and edits the play options behind the
+// options command.
+type OptionsManager interface {
+	List(ctx context.Context, filter string) ([]bot.PlayOption, error)
+	Search(ctx context.Context, query string) ([]bot.ScoredOption, error)
+	Add(ctx context.Context, name string, aliases []string, userID string) (bot.PlayOption, error)
+	Remove(ctx context.Context, name, userID string) error
+}
+
+// SetOptionsManager enables the options command.
+func (b *Bot) SetOptionsManager(o OptionsManager) {
+	b.options = o
+}
+
+// handleOptions handles "options list [filter]|search <query>|add <name>
+// [aliases...]|remove <name>". Names with spaces are quoted.
+func (b *Bot) handleOptions(s *discordgo.Session, m *discordgo.MessageCreate, args string) {
+	if b.options == nil {
+		s.ChannelMessageSend(m.ChannelID, "Play options are not enabled.")
+		return
+	}
+	ctx, cancel := context.WithTimeout(messageContext(m), 10*time.Second)
+	defer cancel()
+
+	sub, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+	rest = strings.TrimSpace(rest)
+	switch strings.ToLower(sub) {
+	case "", "list":
+		options, err := b.options.List(ctx, strings.Trim(rest, `"`))
+		if err != nil {
+			slog.ErrorContext(ctx, "options list failed", "err", err)
+			s.ChannelMessageSend(m.ChannelID, "Couldn't load the play options.")
+			return
+		}
+		if len(options) == 0 {
+			s.ChannelMessageSend(m.ChannelID, "No play options found.")
+			return
+		}
+		lines := make([]string, len(options))
+		for i, opt := range options {
+			lines[i] = formatOption(opt)
+		}
+		b.sendLongMessage(s, m.ChannelID, fmt.Sprintf("**Play options** (%d)\n%s", len(options), strings.Join(lines, "\n")))
+
+	case "search":
+		if rest == "" {
+			b.optionsUsage(s, m)
+			return
+		}
+		results, err := b.options.Search(ctx, strings.Trim(rest, `"`))
+		if err != nil {
+			slog.ErrorContext(ctx, "options search failed", "err", err)
+			s.ChannelMessageSend(m.ChannelID, "Couldn't load the play options.")
+			return
+		}
+		if len(results) == 0 {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("No play options match %q.", rest))
+			return
+		}
+		if len(results) > optionsSearchLimit {
+			results = results[:optionsSearchLimit]
+		}
+		lines := make([]string, len(results))
+		for i, r := range results {
+			lines[i] = fmt.Sprintf("`%d` %s", r.Score, formatOption(r.Option))
+		}
+		b.sendLongMessage(s, m.ChannelID, "**Matches** (score, option)\n"+strings.Join(lines, "\n"))
+
+	case "add":
+		if !b.canManage(s, m) {
+			s.ChannelMessageSend(m.ChannelID, "You don't have permission to change play options.")
+			return
+		}
+		fields := splitArgs(rest)
+		if len(fields) == 0 {
+			b.optionsUsage(s, m)
+			return
+		}
+		opt, err := b.options.Add(ctx, fields[0], fields[1:], m.Author.ID)
+		if err != nil {
+			slog.WarnContext(ctx, "options add failed", "err", err)
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Couldn't add the option: %v.", err))
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, "Added "+formatOption(opt)+".")
+
+	case "remove":
+		if !b.canManage(s, m) {
+			s.ChannelMessageSend(m.ChannelID, "You don't have permission to change play options.")
+			return
+		}
+		name := strings.Trim(rest, `"`)
+		if name == "" {
+			b.optionsUsage(s, m)
+			return
+		}
+		if err := b.options.Remove(ctx, name, m.Author.ID); err != nil {
+			if !errors.Is(err, bot.ErrOptionNotFound) {
+				slog.ErrorContext(ctx, "options remove failed", "err", err)
+			}
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Couldn't remove the option: %v.", err))
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Removed `%s`.", name))
+
+	default:
+		b.optionsUsage(s, m)
+	}
+}
+
+func (b *Bot) optionsUsage(s *discordgo.Session, m *discordgo.MessageCreate) {
+	prefix := b.config.CommandPrefix
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Usage: `%s options list [filter]`, `%s options search <query>`, "+
+		"`%s options add <name> [aliases...]`, `%s options remove <name>`. Quote names with spaces.",
+		prefix, prefix, prefix, prefix))
+}
+
+// formatOption formats an option with its aliases, tags and source.
+func formatOption(opt bot.PlayOption) string {
+	text := "`" + opt.Name + "`"
+	if len(opt.Aliases) > 0 {
+		text += " aka " + strings.Join(opt.Aliases, ", ")
+	}
+	if len(opt.Tags) > 0 {
+		text += " [" + strings.Join(opt.Tags, ", ") + "]"
+	}
+	if opt.Source != "" {
+		text += " · " + opt.Source
+	}
+	return text
+}
+
+// canManage reports whether the author may change play options or personas:
+// members with one of the configured roles (by ID or name), or, when none are
+// configured, with the Manage Server permission.
+func (b *Bot) canManage(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	if m.GuildID == "" || m.Member == nil {
+		return false
+	}
+	if len(b.config.OptionsRoles) == 0 {
+		perms, err := s.UserChannelPermissions(m.Author.ID, m.ChannelID)
+		if err != nil {
+			slog.Warn("failed to check permissions", "user", m.Author.ID, "channel", m.ChannelID, "err", err)
+			return false
+		}
+		return perms&discordgo.PermissionManageGuild != 0
+	}
+
+	for _, roleID := range m.Member.Roles {
+		name := ""
+		if role, err := s.State.Role(m.GuildID, roleID); err == nil {
+			name = role.Name
+		}
+		for _, allowed := range b.config.OptionsRoles {
+			if allowed == roleID || (name != "" && strings.EqualFold(allowed, name)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitArgs splits a command's arguments on spaces, keeping "quoted text"
+// together.
+func splitArgs(s string) []string {
+	var args []string
+	var current strings.Builder
+	quoted, inArg := false, false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			inArg = true
+		case r == ' ' && !quoted:
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args
+}
@@ -0,0 +1,30 @@
+package discord
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := map[string][]string{
+		`airhorn`:                          {"airhorn"},
+		`"sad trombone" "womp womp"  fail`: {"sad trombone", "womp womp", "fail"},
+		`  rim"shot"  `:                    {"rimshot"},
+		`""`:                               {""},
+		``:                                 nil,
+	}
+	for in, want := range tests {
+		if got := splitArgs(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("splitArgs(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFormatOption(t *testing.T) {
+	opt := bot.PlayOption{Name: "airhorn", Aliases: []string{"fog horn"}, Tags: []string{"loud"}, Source: "file"}
+	if got, want := formatOption(opt), "`airhorn` aka fog horn [loud] · file"; got != want {
+		t.Errorf("formatOption = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,93 @@
+package discord
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/persona"
+	"github.com/bwmarrin/discordgo"
+)
+
+// PersonaManager manages the per-channel personas behind the persona command.
+type PersonaManager interface {
+	Current(channelID string) (persona.Persona, bool)
+	Set(channelID, text, userID string) (persona.Persona, error)
+	Reset(channelID string) error
+	Presets() []string
+}
+
+// SetPersonaManager enables the persona command.
+func (b *Bot) SetPersonaManager(p PersonaManager) {
+	b.personas = p
+}
+
+// handlePersona handles "persona show|set <text or preset>|reset|list".
+func (b *Bot) handlePersona(s *discordgo.Session, m *discordgo.MessageCreate, args string) {
+	if b.personas == nil {
+		s.ChannelMessageSend(m.ChannelID, "Personas are not enabled.")
+		return
+	}
+
+	sub, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+	switch strings.ToLower(sub) {
+	case "", "show":
+		p, custom := b.personas.Current(m.ChannelID)
+		switch {
+		case !custom:
+			b.sendLongMessage(s, m.ChannelID, "**Persona:** default\n"+quote(p.Prompt))
+		case p.IsPreset():
+			b.sendLongMessage(s, m.ChannelID, fmt.Sprintf("**Persona:** preset `%s`\n%s", p.Preset, quote(p.Prompt)))
+		default:
+			b.sendLongMessage(s, m.ChannelID, "**Persona:** custom\n"+quote(p.Prompt))
+		}
+
+	case "set":
+		if !b.canManage(s, m) {
+			s.ChannelMessageSend(m.ChannelID, "You don't have permission to change the persona.")
+			return
+		}
+		p, err := b.personas.Set(m.ChannelID, rest, m.Author.ID)
+		if err != nil {
+			slog.Warn("persona set failed", "channel", m.ChannelID, "err", err)
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Couldn't set the persona: %v. Usage: `%s persona set <preset or prompt>`",
+				err, b.config.CommandPrefix))
+			return
+		}
+		if p.IsPreset() {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Persona set to preset `%s`. Conversation history is kept.", p.Preset))
+		} else {
+			s.ChannelMessageSend(m.ChannelID, "Persona set. Conversation history is kept.")
+		}
+
+	case "reset":
+		if !b.canManage(s, m) {
+			s.ChannelMessageSend(m.ChannelID, "You don't have permission to change the persona.")
+			return
+		}
+		if err := b.personas.Reset(m.ChannelID); err != nil {
+			slog.Warn("persona reset failed", "channel", m.ChannelID, "err", err)
+			s.ChannelMessageSend(m.ChannelID, "Couldn't reset the persona.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, "Persona reset to the default.")
+
+	case "list":
+		presets := b.personas.Presets()
+		if len(presets) == 0 {
+			s.ChannelMessageSend(m.ChannelID, "No persona presets are configured.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, "**Persona presets:** `"+strings.Join(presets, "`, `")+"`")
+
+	default:
+		prefix := b.config.CommandPrefix
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Usage: `%s persona show`, `%s persona set <preset or prompt>`, `%s persona reset`, `%s persona list`",
+			prefix, prefix, prefix, prefix))
+	}
+}
+
+// quote formats text as a Discord block quote.
+func quote(text string) string {
+	return "> " + strings.ReplaceAll(text, "\n", "\n> ")
+}
@@ -0,0 +1,329 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"github.com/adrock-miles/go-laserbeak/internal/infrastructure/audio"
+	"github.com/adrock-miles/go-laserbeak/internal/logging"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// soundFrame is how often an Opus packet is sent: Discord plays one
+	// 20ms frame per packet.
+	soundFrame = 20 * time.Millisecond
+	// sendTimeout gives up on a connection that stops taking packets.
+	sendTimeout = time.Second
+)
+
+// silenceFrames are sent after a sound so clients don't interpolate its
+// last frame.
+var silenceFrames = [][]byte{{0xF8, 0xFF, 0xFE}, {0xF8, 0xFF, 0xFE}, {0xF8, 0xFF, 0xFE}, {0xF8, 0xFF, 0xFE}, {0xF8, 0xFF, 0xFE}}
+
+var (
+	errNotInVoice = errors.New("neither of us is in a voice channel")
+	errQueueFull  = errors.New("the queue is full")
+)
+
+// SoundLibrary finds the local sounds played in soundboard mode.
+type SoundLibrary interface {
+	// Load returns the sound for a name and the name of the option it plays.
+	Load(ctx context.Context, name string) (*audio.OggOpus, string, error)
+	// Random returns the name of a random playable option.
+	Random(ctx context.Context) (string, error)
+}
+
+// SetSoundboard plays sounds from lib in voice instead of relaying play and
+// stop commands to another bot. At most maxQueue sounds wait behind the one
+// playing.
+func (b *Bot) SetSoundboard(lib SoundLibrary, maxQueue int) {
+	b.sounds = lib
+	b.playback = newPlayback(b.streamSound, maxQueue)
+	b.voiceListener.SetSpeaks(true)
+}
+
+// isSoundCommand reports whether text is a play or stop command for the
+// soundboard bot.
+func isSoundCommand(text string) bool {
+	cmd, _, _ := strings.Cut(strings.TrimSpace(text), " ")
+	switch strings.ToLower(cmd) {
+	case "!play", "!pr", "!stop":
+		return true
+	}
+	return false
+}
+
+// soundCommand carries out "!play <name>", "!pr" (a random sound) and
+// "!stop" in soundboard mode, returning a reply for the channel. It reports
+// false when soundboard mode is off or text is another command, so the text
+// is relayed as usual.
+func (b *Bot) soundCommand(ctx context.Context, guildID, userID, channelID, text string) (reply string, handled bool, err error) {
+	if b.playback == nil || !isSoundCommand(text) {
+		return "", false, nil
+	}
+
+	cmd, name, _ := strings.Cut(strings.TrimSpace(text), " ")
+	name = strings.TrimSpace(name)
+	switch strings.ToLower(cmd) {
+	case "!stop":
+		if b.playback.stop(guildID) {
+			return "Stopped.", true, nil
+		}
+		return "Nothing is playing.", true, nil
+	case "!pr":
+		if name, err = b.sounds.Random(ctx); err != nil {
+			return "", true, fmt.Errorf("pick a random sound: %w", err)
+		}
+	}
+	if name == "" {
+		return "", true, errors.New("no sound given")
+	}
+
+	reply, err = b.playSound(ctx, guildID, userID, channelID, name)
+	return reply, true, err
+}
+
+// playSound plays or queues a sound, first joining the user's voice channel
+// if the bot isn't in one.
+func (b *Bot) playSound(ctx context.Context, guildID, userID, channelID, name string) (string, error) {
+	sound, name, err := b.sounds.Load(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	if b.voiceListener.Connection(guildID) == nil {
+		voiceChannelID := userVoiceChannel(b.session, guildID, userID)
+		if voiceChannelID == "" {
+			return "", errNotInVoice
+		}
+		if err := b.voiceListener.Join(b.session, guildID, voiceChannelID, b.outputChannel(channelID)); err != nil {
+			return "", fmt.Errorf("join voice channel: %w", err)
+		}
+	}
+
+	place, err := b.playback.enqueue(guildID, name, sound)
+	if err != nil {
+		return "", err
+	}
+	if place == 0 {
+		return fmt.Sprintf("Playing `%s`.", name), nil
+	}
+	return fmt.Sprintf("Queued `%s` (%d in line).", name, place), nil
+}
+
+// sendVoiceCommand carries out a command parsed from speech: in soundboard
+// mode by playing it, otherwise by posting it for the soundboard bot. source
+// says where the speech came from, for metrics. A play is recorded once it
+// has been played or posted.
+func (b *Bot) sendVoiceCommand(ctx context.Context, guildID, userID, channelID string, cmd bot.VoiceCommand, source string) {
+	slog.DebugContext(ctx, "sending voice command", logging.KeyTranscript, cmd.Text, "source", source)
+	b.countCommand(cmd.Text, source)
+	reply, handled, err := b.soundCommand(ctx, guildID, userID, channelID, cmd.Text)
+	switch {
+	case !handled:
+		reply = cmd.Text
+	case err != nil:
+		reply = fmt.Sprintf("Couldn't play that: %v.", err)
+	}
+	_, sendErr := b.session.ChannelMessageSend(channelID, reply)
+	if handled && err == nil || !handled && sendErr == nil {
+		b.recordPlay(ctx, cmd)
+	}
+}
+
+// handleSoundCommand handles play and stop commands typed in a channel, and
+// "skip" and "queue".
+func (b *Bot) handleSoundCommand(s *discordgo.Session, m *discordgo.MessageCreate, text string) {
+	ctx, cancel := context.WithTimeout(messageContext(m), 10*time.Second)
+	defer cancel()
+
+	switch strings.ToLower(strings.TrimSpace(text)) {
+	case "!skip":
+		if b.playback.skip(m.GuildID) {
+			s.ChannelMessageSend(m.ChannelID, "Skipped.")
+		} else {
+			s.ChannelMessageSend(m.ChannelID, "Nothing is playing.")
+		}
+		return
+	case "!queue":
+		playing, queued := b.playback.status(m.GuildID)
+		if playing == "" {
+			s.ChannelMessageSend(m.ChannelID, "Nothing is playing.")
+			return
+		}
+		msg := fmt.Sprintf("Playing `%s`.", playing)
+		for i, name := range queued {
+			msg += fmt.Sprintf("\n%d. `%s`", i+1, name)
+		}
+		s.ChannelMessageSend(m.ChannelID, msg)
+		return
+	}
+
+	b.countCommand(text, "text")
+	reply, _, err := b.soundCommand(ctx, m.GuildID, m.Author.ID, m.ChannelID, text)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Couldn't play that: %v.", err))
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, reply)
+}
+
+// streamSound sends a sound's packets to the guild's voice connection.
+func (b *Bot) streamSound(ctx context.Context, guildID string, sound *audio.OggOpus) error {
+	vc := b.voiceListener.Connection(guildID)
+	if vc == nil {
+		return errNotInVoice
+	}
+	if err := vc.Speaking(true); err != nil {
+		return fmt.Errorf("start speaking: %w", err)
+	}
+	defer vc.Speaking(false)
+
+	err := sendPackets(ctx, vc.OpusSend, sound.Packets, soundFrame)
+	silenceCtx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	sendPackets(silenceCtx, vc.OpusSend, silenceFrames, soundFrame)
+	return err
+}
+
+// sendPackets sends packets to out, one per interval, until they run out or
+// ctx is done. One timer, reset for each packet, bounds every send.
+func sendPackets(ctx context.Context, out chan<- []byte, packets [][]byte, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	timeout := time.NewTimer(sendTimeout)
+	defer timeout.Stop()
+	for _, p := range packets {
+		timeout.Reset(sendTimeout)
+		select {
+		case out <- p:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout.C:
+			return errors.New("voice connection stopped taking audio")
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// playback plays queued sounds, one guild at a time each.
+type playback struct {
+	stream   func(ctx context.Context, guildID string, sound *audio.OggOpus) error
+	maxQueue int
+
+	mu     sync.Mutex
+	guilds map[string]*guildPlayback // only guilds with a sound playing
+}
+
+type guildPlayback struct {
+	playing string
+	cancel  context.CancelFunc // stops the playing sound
+	queue   []queuedSound
+}
+
+type queuedSound struct {
+	name  string
+	sound *audio.OggOpus
+}
+
+func newPlayback(stream func(ctx context.Context, guildID string, sound *audio.OggOpus) error, maxQueue int) *playback {
+	return &playback{stream: stream, maxQueue: maxQueue, guilds: make(map[string]*guildPlayback)}
+}
+
+// enqueue plays a sound in a guild, or queues it behind the one playing. It
+// returns the sound's place in the queue, 0 when it plays now.
+func (p *playback) enqueue(guildID, name string, sound *audio.OggOpus) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	g, ok := p.guilds[guildID]
+	if !ok {
+		g = &guildPlayback{playing: name, queue: []queuedSound{{name, sound}}}
+		p.guilds[guildID] = g
+		go p.run(guildID, g)
+		return 0, nil
+	}
+	if len(g.queue) >= p.maxQueue {
+		return 0, errQueueFull
+	}
+	g.queue = append(g.queue, queuedSound{name, sound})
+	return len(g.queue), nil
+}
+
+// run plays a guild's queue until it's empty.
+func (p *playback) run(guildID string, g *guildPlayback) {
+	for {
+		p.mu.Lock()
+		if len(g.queue) == 0 {
+			delete(p.guilds, guildID)
+			p.mu.Unlock()
+			return
+		}
+		next := g.queue[0]
+		g.queue = g.queue[1:]
+		ctx, cancel := context.WithCancel(context.Background())
+		g.playing, g.cancel = next.name, cancel
+		p.mu.Unlock()
+
+		if err := p.stream(ctx, guildID, next.sound); err != nil && !errors.Is(err, context.Canceled) {
+			slog.Warn("failed to play sound", "sound", next.name, "guild", guildID, "err", err)
+		}
+		cancel()
+	}
+}
+
+// stop interrupts the playing sound and clears the queue, reporting whether
+// anything was playing.
+func (p *playback) stop(guildID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	g, ok := p.guilds[guildID]
+	if !ok {
+		return false
+	}
+	g.queue = nil
+	if g.cancel != nil {
+		g.cancel()
+	}
+	return true
+}
+
+// skip interrupts the playing sound, moving on to the next one.
+func (p *playback) skip(guildID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	g, ok := p.guilds[guildID]
+	if !ok {
+		return false
+	}
+	if g.cancel != nil {
+		g.cancel()
+	}
+	return true
+}
+
+// status returns the sound playing in a guild and those queued behind it.
+func (p *playback) status(guildID string) (playing string, queued []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	g, ok := p.guilds[guildID]
+	if !ok {
+		return "", nil
+	}
+	for _, q := range g.queue {
+		queued = append(queued, q.name)
+	}
+	return g.playing, queued
+}
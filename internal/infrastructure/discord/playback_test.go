@@ -0,0 +1,129 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/infrastructure/audio"
+)
+
+// fakeStream records the sounds played and blocks each until released or
+// stopped.
+type fakeStream struct {
+	started chan string
+	release chan struct{}
+}
+
+func newFakeStream() *fakeStream {
+	return &fakeStream{started: make(chan string, 10), release: make(chan struct{})}
+}
+
+func (f *fakeStream) stream(ctx context.Context, _ string, sound *audio.OggOpus) error {
+	f.started <- string(sound.Packets[0])
+	select {
+	case <-f.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func sound(name string) *audio.OggOpus {
+	return &audio.OggOpus{Packets: [][]byte{[]byte(name)}}
+}
+
+func waitStarted(t *testing.T, f *fakeStream, want string) {
+	t.Helper()
+	select {
+	case got := <-f.started:
+		if got != want {
+			t.Fatalf("started %q, want %q", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("%q never started", want)
+	}
+}
+
+func TestPlayback_QueueSkipAndStop(t *testing.T) {
+	f := newFakeStream()
+	p := newPlayback(f.stream, 2)
+
+	if place, _ := p.enqueue("g1", "one", sound("one")); place != 0 {
+		t.Errorf("first sound place = %d, want 0", place)
+	}
+	waitStarted(t, f, "one")
+	p.enqueue("g1", "two", sound("two"))
+	if place, _ := p.enqueue("g1", "three", sound("three")); place != 2 {
+		t.Errorf("third sound place = %d, want 2", place)
+	}
+	if _, err := p.enqueue("g1", "four", sound("four")); !errors.Is(err, errQueueFull) {
+		t.Errorf("err = %v, want errQueueFull", err)
+	}
+
+	playing, queued := p.status("g1")
+	if playing != "one" || !reflect.DeepEqual(queued, []string{"two", "three"}) {
+		t.Errorf("status = %q %v", playing, queued)
+	}
+
+	f.release <- struct{}{} // "one" finishes
+	waitStarted(t, f, "two")
+	p.skip("g1")
+	waitStarted(t, f, "three")
+
+	if !p.stop("g1") {
+		t.Fatal("stop reported nothing playing")
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		if playing, _ := p.status("g1"); playing == "" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("playback didn't stop")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if p.stop("g1") {
+		t.Error("stop reported a sound after playback ended")
+	}
+}
+
+func TestSendPackets(t *testing.T) {
+	out := make(chan []byte, 10)
+	packets := [][]byte{{1}, {2}, {3}}
+	start := time.Now()
+	if err := sendPackets(context.Background(), out, packets, 5*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("sent in %s, want one packet per interval", elapsed)
+	}
+	if len(out) != 3 {
+		t.Errorf("sent %d packets, want 3", len(out))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sendPackets(ctx, make(chan []byte), packets, time.Millisecond); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestSoundCommand_RelaysWithoutSoundboard(t *testing.T) {
+	b := &Bot{}
+	if _, handled, _ := b.soundCommand(context.Background(), "g1", "u1", "c1", "!play wow"); handled {
+		t.Error("handled a play command without soundboard mode")
+	}
+
+	b.playback = newPlayback(newFakeStream().stream, 1)
+	if _, handled, _ := b.soundCommand(context.Background(), "g1", "u1", "c1", "!stopwatch"); handled {
+		t.Error("handled a command that isn't play or stop")
+	}
+	reply, handled, err := b.soundCommand(context.Background(), "g1", "u1", "c1", "!stop")
+	if !handled || err != nil || reply != "Nothing is playing." {
+		t.Errorf("stop = %q, %v, %v", reply, handled, err)
+	}
+}
@@ -0,0 +1,94 @@
+package discord
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/ratelimit"
+	"github.com/bwmarrin/discordgo"
+)
+
+// allow checks the rate limiter for an action. On the first refusal of a cooldown
+// it posts a friendly message to replyChannelID (skipped when empty).
+// Always returns true when no limiter is configured.
+func (b *Bot) allow(kind ratelimit.Kind, key ratelimit.Key, cost float64, replyChannelID string) bool {
+	if b.limiter == nil {
+		return true
+	}
+
+	d := b.limiter.Allow(kind, key, cost)
+	if d.Allowed {
+		return true
+	}
+
+	if b.metrics != nil {
+		b.metrics.RateLimited(string(kind))
+	}
+	slog.Info("rate limited", "kind", string(kind), "scope", string(d.Scope), "user", key.UserID,
+		"channel", key.ChannelID, "guild", key.GuildID, "retry_after", d.RetryAfter.Round(time.Second))
+
+	if d.Notify && replyChannelID != "" {
+		b.session.ChannelMessageSend(replyChannelID, cooldownMessage(kind, key.UserID, d))
+	}
+	return false
+}
+
+// cooldownMessage builds the user-facing message for a refused request.
+func cooldownMessage(kind ratelimit.Kind, userID string, d ratelimit.Decision) string {
+	what := "chat with me"
+	if kind == ratelimit.KindVoice {
+		what = "use voice commands"
+	}
+
+	wait := formatWait(d.RetryAfter)
+	switch d.Scope {
+	case ratelimit.ScopeChannel:
+		return fmt.Sprintf("This channel is keeping me busy — you can %s again in %s.", what, wait)
+	case ratelimit.ScopeGuild:
+		return fmt.Sprintf("This server is keeping me busy — you can %s again in %s.", what, wait)
+	default:
+		return fmt.Sprintf("<@%s> slow down a little — you can %s again in %s.", userID, what, wait)
+	}
+}
+
+// formatWait rounds a cooldown up to whole seconds for display.
+func formatWait(d time.Duration) string {
+	secs := int((d + time.Second - 1) / time.Second)
+	if secs <= 1 {
+		return "a second"
+	}
+	return fmt.Sprintf("%ds", secs)
+}
+
+// handleLimits shows the caller's current rate limit buckets.
+func (b *Bot) handleLimits(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if b.limiter == nil {
+		s.ChannelMessageSend(m.ChannelID, "Rate limiting is disabled.")
+		return
+	}
+
+	key := ratelimit.Key{GuildID: m.GuildID, ChannelID: m.ChannelID, UserID: m.Author.ID}
+	statuses := b.limiter.Status(key)
+	if len(statuses) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "No rate limits apply here.")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("**Rate limits**\n")
+	for _, st := range statuses {
+		unit := "requests"
+		if st.Kind == ratelimit.KindSTT {
+			unit = "seconds of audio"
+		}
+		tokens := st.Tokens
+		if tokens < 0 {
+			tokens = 0
+		}
+		fmt.Fprintf(&sb, "`%s` · %s — %.0f/%.0f %s left (1 back every %s)\n",
+			st.Kind, st.Scope, tokens, st.Rule.Burst, unit, st.Rule.Refill)
+	}
+	s.ChannelMessageSend(m.ChannelID, sb.String())
+}
@@ -0,0 +1,138 @@
+package discord
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// maxQuotedLength caps the text kept from each referenced message.
+	maxQuotedLength = 1500
+	// maxEmbedTextLength caps each embed description in a summary.
+	maxEmbedTextLength = 300
+)
+
+// replyChain fetches the messages m replies to, following up to
+// config.ReplyDepth references, and returns them oldest first.
+func (b *Bot) replyChain(s *discordgo.Session, m *discordgo.Message) []bot.ReferencedMessage {
+	var chain []bot.ReferencedMessage
+	ref, next := m.MessageReference, m.ReferencedMessage
+	for hops := 0; hops < b.config.ReplyDepth && ref != nil && ref.MessageID != ""; hops++ {
+		if next == nil {
+			channelID := ref.ChannelID
+			if channelID == "" {
+				channelID = m.ChannelID
+			}
+			fetched, err := s.ChannelMessage(channelID, ref.MessageID)
+			if err != nil {
+				// Deleted or inaccessible; keep what we have.
+				slog.Debug("failed to fetch referenced message", "message", ref.MessageID, "err", err)
+				break
+			}
+			next = fetched
+		}
+
+		chain = append(chain, b.referencedMessage(next))
+		ref, next = next.MessageReference, next.ReferencedMessage
+	}
+
+	// Collected newest first; the LLM reads the chain in order.
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
+
+// referencedMessage converts a Discord message to a bot.ReferencedMessage.
+func (b *Bot) referencedMessage(m *discordgo.Message) bot.ReferencedMessage {
+	ref := bot.ReferencedMessage{Content: truncate(messageText(m), maxQuotedLength)}
+	if m.Author != nil {
+		ref.AuthorName = m.Author.DisplayName()
+		if b.session.State.User != nil && m.Author.ID == b.session.State.User.ID {
+			ref.FromBot = true
+		}
+	}
+	if m.Member != nil && m.Member.Nick != "" {
+		ref.AuthorName = m.Member.Nick
+	}
+	return ref
+}
+
+// messageText returns a message's content followed by one-line summaries of
+// its attachments and embeds.
+func messageText(m *discordgo.Message) string {
+	parts := make([]string, 0, 1+len(m.Attachments)+len(m.Embeds))
+	if content := strings.TrimSpace(m.Content); content != "" {
+		parts = append(parts, content)
+	}
+	for _, a := range m.Attachments {
+		parts = append(parts, summarizeAttachment(a))
+	}
+	for _, e := range m.Embeds {
+		if summary := summarizeEmbed(e); summary != "" {
+			parts = append(parts, summary)
+		}
+	}
+	if len(parts) == 0 {
+		return "(no text)"
+	}
+	return strings.Join(parts, "\n")
+}
+
+// summarizeAttachment describes an attachment by name, type and size.
+func summarizeAttachment(a *discordgo.MessageAttachment) string {
+	details := []string{formatBytes(a.Size)}
+	if a.ContentType != "" {
+		details = append([]string{a.ContentType}, details...)
+	}
+	if a.Width > 0 && a.Height > 0 {
+		details = append(details, fmt.Sprintf("%dx%d", a.Width, a.Height))
+	}
+	return fmt.Sprintf("[attachment: %s (%s)]", a.Filename, strings.Join(details, ", "))
+}
+
+// summarizeEmbed describes an embed by its title, description, fields and link.
+func summarizeEmbed(e *discordgo.MessageEmbed) string {
+	var parts []string
+	if e.Title != "" {
+		parts = append(parts, e.Title)
+	}
+	if e.Description != "" {
+		parts = append(parts, truncate(e.Description, maxEmbedTextLength))
+	}
+	for _, f := range e.Fields {
+		parts = append(parts, fmt.Sprintf("%s: %s", f.Name, truncate(f.Value, maxEmbedTextLength)))
+	}
+	if e.URL != "" {
+		parts = append(parts, e.URL)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "[embed: " + strings.Join(parts, " — ") + "]"
+}
+
+// formatBytes formats a size in B, KB or MB.
+func formatBytes(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%d KB", n>>10)
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// truncate shortens s to at most n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
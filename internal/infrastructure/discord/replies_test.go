@@ -0,0 +1,75 @@
+package discord
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestMessageText_SummarizesAttachmentsAndEmbeds(t *testing.T) {
+	m := &discordgo.Message{
+		Content: "look at this",
+		Attachments: []*discordgo.MessageAttachment{
+			{Filename: "cat.png", ContentType: "image/png", Size: 150 << 10, Width: 800, Height: 600},
+			{Filename: "notes.txt", Size: 42},
+		},
+		Embeds: []*discordgo.MessageEmbed{
+			{Title: "Go 1.24 released", Description: "Generic type aliases and more.", URL: "https://go.dev/blog/go1.24"},
+			{}, // empty embeds are skipped
+		},
+	}
+
+	want := "look at this\n" +
+		"[attachment: cat.png (image/png, 150 KB, 800x600)]\n" +
+		"[attachment: notes.txt (42 B)]\n" +
+		"[embed: Go 1.24 released — Generic type aliases and more. — https://go.dev/blog/go1.24]"
+	if got := messageText(m); got != want {
+		t.Errorf("messageText =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := messageText(&discordgo.Message{}); got != "(no text)" {
+		t.Errorf("messageText(empty) = %q", got)
+	}
+}
+
+func TestReplyChain_FollowsReferencesUpToDepth(t *testing.T) {
+	state := discordgo.NewState()
+	state.User = &discordgo.User{ID: "bot"}
+	b := &Bot{session: &discordgo.Session{State: state}, config: BotConfig{ReplyDepth: 2}}
+
+	reply := func(id string, author *discordgo.User, content string, parent *discordgo.Message) *discordgo.Message {
+		m := &discordgo.Message{ID: id, ChannelID: "ch", Author: author, Content: content, ReferencedMessage: parent}
+		if parent != nil {
+			m.MessageReference = &discordgo.MessageReference{MessageID: parent.ID, ChannelID: "ch"}
+		}
+		return m
+	}
+	sam := &discordgo.User{ID: "u1", Username: "sam", GlobalName: "Sam"}
+	laser := &discordgo.User{ID: "bot", Username: "laserbeak"}
+
+	first := reply("1", sam, "what's a goroutine?", nil)
+	second := reply("2", laser, "A lightweight thread.", first)
+	third := reply("3", sam, "how light?", second)
+	current := reply("4", sam, "!laser explain this", third)
+
+	chain := b.replyChain(b.session, current)
+	if len(chain) != 2 {
+		t.Fatalf("chain has %d messages, want 2 (depth limit)", len(chain))
+	}
+	if chain[0].Content != "A lightweight thread." || !chain[0].FromBot {
+		t.Errorf("chain[0] = %+v, want the bot's reply", chain[0])
+	}
+	if chain[1].Content != "how light?" || chain[1].AuthorName != "Sam" || chain[1].FromBot {
+		t.Errorf("chain[1] = %+v, want Sam's question", chain[1])
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate(strings.Repeat("a", 10), 5); got != "aaaa…" {
+		t.Errorf("truncate = %q", got)
+	}
+	if got := truncate("short", 5); got != "short" {
+		t.Errorf("truncate = %q", got)
+	}
+}
@@ -0,0 +1,106 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/playstats"
+	"github.com/bwmarrin/discordgo"
+)
+
+// statsListLength is how many options the top and favourites commands show.
+const statsListLength = 10
+
+// PlayStatsReporter provides play counts for the top and favourites commands,
+// and records the plays the bot dispatches from speech.
+type PlayStatsReporter interface {
+	Top(guildID string, since time.Time, n int) []playstats.Count
+	Favourites(userID string, n int) []playstats.Count
+	Record(ctx context.Context, option string, match playstats.Match)
+}
+
+// SetPlayStats enables the top and favourites commands and records plays.
+func (b *Bot) SetPlayStats(r PlayStatsReporter) {
+	b.playStats = r
+}
+
+// recordPlay records a dispatched play command. Other commands have no Option.
+func (b *Bot) recordPlay(ctx context.Context, cmd bot.VoiceCommand) {
+	if b.playStats != nil && cmd.Option != "" {
+		b.playStats.Record(ctx, cmd.Option, cmd.Match)
+	}
+}
+
+// handleTop handles "top [week|all]", listing the server's most played
+// options over the last seven days or all time.
+func (b *Bot) handleTop(s *discordgo.Session, m *discordgo.MessageCreate, args string) {
+	if b.playStats == nil {
+		s.ChannelMessageSend(m.ChannelID, "Play statistics are disabled.")
+		return
+	}
+
+	var since time.Time
+	title := "**Most played this week**"
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "", "week":
+		since = time.Now().AddDate(0, 0, -7)
+	case "all":
+		title = "**Most played of all time**"
+	default:
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Usage: `%s top [week|all]`", b.config.CommandPrefix))
+		return
+	}
+
+	counts := b.playStats.Top(m.GuildID, since, statsListLength)
+	if len(counts) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "Nothing has been played yet.")
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, title+"\n"+formatCounts(counts))
+}
+
+// handleFavourites lists the options the caller, or the first user
+// mentioned, plays most.
+func (b *Bot) handleFavourites(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if b.playStats == nil {
+		s.ChannelMessageSend(m.ChannelID, "Play statistics are disabled.")
+		return
+	}
+
+	user := m.Author
+	for _, u := range m.Mentions {
+		if u.ID != s.State.User.ID {
+			user = u
+			break
+		}
+	}
+
+	counts := b.playStats.Favourites(user.ID, statsListLength)
+	if len(counts) == 0 {
+		s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+			Content:         fmt.Sprintf("<@%s> hasn't played anything yet.", user.ID),
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		})
+		return
+	}
+	s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Content:         fmt.Sprintf("**Favourites of <@%s>**\n%s", user.ID, formatCounts(counts)),
+		AllowedMentions: &discordgo.MessageAllowedMentions{}, // don't ping the user
+	})
+}
+
+// formatCounts formats a ranked list of play counts.
+func formatCounts(counts []playstats.Count) string {
+	lines := make([]string, len(counts))
+	for i, c := range counts {
+		plays := "plays"
+		if c.Plays == 1 {
+			plays = "play"
+		}
+		lines[i] = fmt.Sprintf("%d. `%s` — %d %s", i+1, c.Option, c.Plays, plays)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,203 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/conversation"
+	"github.com/bwmarrin/discordgo"
+)
+
+// textDownloadTimeout bounds fetching all text attachments of one message.
+const textDownloadTimeout = 15 * time.Second
+
+// textTypes are the non-text/* MIME types read as text.
+var textTypes = map[string]bool{
+	"application/json":       true,
+	"application/xml":        true,
+	"application/yaml":       true,
+	"application/x-yaml":     true,
+	"application/toml":       true,
+	"application/javascript": true,
+	"application/typescript": true,
+	"application/x-sh":       true,
+	"application/sql":        true,
+}
+
+// textExtensions maps the extensions of files read as text, whatever their
+// reported type, to the language tag of their code fence.
+var textExtensions = map[string]string{
+	".txt": "", ".log": "", ".md": "markdown", ".csv": "csv", ".diff": "diff", ".patch": "diff",
+	".go": "go", ".mod": "", ".py": "python", ".js": "javascript", ".ts": "typescript",
+	".jsx": "jsx", ".tsx": "tsx", ".java": "java", ".kt": "kotlin", ".rs": "rust",
+	".c": "c", ".h": "c", ".cpp": "cpp", ".hpp": "cpp", ".cs": "csharp", ".rb": "ruby",
+	".php": "php", ".swift": "swift", ".lua": "lua", ".sh": "bash", ".ps1": "powershell",
+	".sql": "sql", ".html": "html", ".css": "css", ".json": "json", ".yaml": "yaml",
+	".yml": "yaml", ".toml": "toml", ".xml": "xml", ".ini": "ini", ".conf": "", ".env": "",
+}
+
+// isTextAttachment reports whether an attachment should be read as text.
+func isTextAttachment(a *discordgo.MessageAttachment) bool {
+	if _, ok := textExtensions[strings.ToLower(path.Ext(a.Filename))]; ok {
+		return true
+	}
+	mimeType, _, _ := strings.Cut(a.ContentType, ";")
+	return strings.HasPrefix(mimeType, "text/") || textTypes[mimeType]
+}
+
+// textFiles downloads text attachments and renders them as fenced blocks for
+// the chat turn, sharing the configured token budget between them. Files that
+// can't be read are described instead.
+func (b *Bot) textFiles(ctx context.Context, attachments []*discordgo.MessageAttachment) []string {
+	ctx, cancel := context.WithTimeout(ctx, textDownloadTimeout)
+	defer cancel()
+
+	budget := b.config.TextTokens
+	if budget > 0 && len(attachments) > 0 {
+		budget /= len(attachments)
+	}
+
+	var blocks []string
+	for _, a := range attachments {
+		data, err := b.download(ctx, a.URL, int64(b.config.MaxTextKB)<<10)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to download attachment", "file", a.Filename, "err", err)
+			blocks = append(blocks, skippedAttachment(a, "download failed"))
+			continue
+		}
+		text, ok := decodeText(data)
+		if !ok {
+			blocks = append(blocks, skippedAttachment(a, "not a text file"))
+			continue
+		}
+		blocks = append(blocks, fencedFile(a.Filename, truncateTokens(text, budget)))
+	}
+	return blocks
+}
+
+// download fetches an attachment, refusing bodies larger than limit bytes.
+func (b *Bot) download(ctx context.Context, url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("larger than %s", formatBytes(int(limit)))
+	}
+	return data, nil
+}
+
+// decodeText converts file contents to a string. UTF-8 and UTF-16 with a byte
+// order mark are decoded; other non-UTF-8 text is read as Latin-1. Data
+// containing NUL bytes is treated as binary and rejected.
+func decodeText(data []byte) (string, bool) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		data = data[3:]
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return decodeUTF16(data[2:], false), true
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return decodeUTF16(data[2:], true), true
+	}
+
+	if bytes.IndexByte(data, 0) >= 0 {
+		return "", false
+	}
+	if utf8.Valid(data) {
+		return string(data), true
+	}
+
+	runes := make([]rune, len(data))
+	for i, c := range data {
+		runes[i] = rune(c)
+	}
+	return string(runes), true
+}
+
+func decodeUTF16(data []byte, bigEndian bool) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// fencedFile renders a file as a code block headed by its name, using a fence
+// longer than any backtick run in the text.
+func fencedFile(name, text string) string {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	lang := textExtensions[strings.ToLower(path.Ext(name))]
+	return fmt.Sprintf("File %s:\n%s%s\n%s\n%s", name, fence, lang, strings.TrimRight(text, "\n"), fence)
+}
+
+// truncateTokens shortens text to about budget tokens, keeping whole lines
+// from the start and end, where logs usually have what matters. A budget of
+// zero or less means no limit.
+func truncateTokens(text string, budget int) string {
+	if budget <= 0 || conversation.EstimateTokens(text) <= budget {
+		return text
+	}
+
+	lines := strings.Split(text, "\n")
+	headBudget := budget * 2 / 3
+	tailBudget := budget - headBudget
+
+	var head, tail int
+	for used := 0; head < len(lines); head++ {
+		used += conversation.EstimateTokens(lines[head]) + 1
+		if used > headBudget {
+			break
+		}
+	}
+	for used := 0; tail < len(lines)-head; tail++ {
+		used += conversation.EstimateTokens(lines[len(lines)-1-tail]) + 1
+		if used > tailBudget {
+			break
+		}
+	}
+
+	if head == 0 && tail == 0 {
+		// A single huge line, such as minified code: cut by characters.
+		runes := []rune(text)
+		keep := budget * 4
+		if keep > len(runes) {
+			keep = len(runes)
+		}
+		return string(runes[:keep]) + "\n[… truncated]"
+	}
+
+	omitted := len(lines) - head - tail
+	parts := append([]string{}, lines[:head]...)
+	parts = append(parts, fmt.Sprintf("[… %d lines truncated …]", omitted))
+	parts = append(parts, lines[len(lines)-tail:]...)
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,114 @@
+package discord
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+		ok   bool
+	}{
+		{"utf-8", []byte("héllo"), "héllo", true},
+		{"utf-8 bom", []byte("\xEF\xBB\xBFhi"), "hi", true},
+		{"utf-16le bom", []byte{0xFF, 0xFE, 'h', 0, 'i', 0}, "hi", true},
+		{"utf-16be bom", []byte{0xFE, 0xFF, 0, 'h', 0, 'i'}, "hi", true},
+		{"latin-1", []byte("caf\xe9"), "café", true},
+		{"binary", []byte("\x7fELF\x02\x01\x00\x00"), "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := decodeText(tt.data)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("decodeText = %q, %v, want %q, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestFencedFile(t *testing.T) {
+	got := fencedFile("main.go", "package main\n")
+	if got != "File main.go:\n```go\npackage main\n```" {
+		t.Errorf("fencedFile = %q", got)
+	}
+
+	got = fencedFile("README.md", "use ```go blocks```")
+	if !strings.HasPrefix(got, "File README.md:\n````markdown\n") || !strings.HasSuffix(got, "\n````") {
+		t.Errorf("fencedFile did not lengthen the fence: %q", got)
+	}
+}
+
+func TestTruncateTokens(t *testing.T) {
+	var lines []string
+	for i := 0; i < 500; i++ {
+		lines = append(lines, "line")
+	}
+	lines[0], lines[499] = "first", "last"
+	text := strings.Join(lines, "\n")
+
+	got := truncateTokens(text, 60)
+	if !strings.HasPrefix(got, "first\n") || !strings.HasSuffix(got, "\nlast") || !strings.Contains(got, "lines truncated") {
+		t.Errorf("truncateTokens kept the wrong parts:\n%s", got)
+	}
+	if strings.Count(got, "\n") > 60 {
+		t.Errorf("truncateTokens kept %d lines, want about 60 tokens' worth", strings.Count(got, "\n"))
+	}
+
+	if got := truncateTokens(text, 0); got != text {
+		t.Error("a zero budget should not truncate")
+	}
+	if got := truncateTokens(strings.Repeat("x;", 5000), 10); !strings.HasSuffix(got, "[… truncated]") || len(got) > 100 {
+		t.Errorf("single long line = %q", got)
+	}
+}
+
+func TestTextFiles(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/main.go":
+			w.Write([]byte("package main\n"))
+		case "/blob.txt":
+			w.Write([]byte{0x00, 0x01, 0x02})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	b := &Bot{config: BotConfig{MaxTextKB: 64, TextTokens: 1000}, httpClient: srv.Client()}
+	blocks := b.textFiles(context.Background(), []*discordgo.MessageAttachment{
+		{Filename: "main.go", URL: srv.URL + "/main.go", ContentType: "text/x-go", Size: 13},
+		{Filename: "blob.txt", URL: srv.URL + "/blob.txt", ContentType: "text/plain", Size: 3},
+		{Filename: "gone.txt", URL: srv.URL + "/gone.txt", ContentType: "text/plain", Size: 3},
+	})
+
+	want := []string{
+		"File main.go:\n```go\npackage main\n```",
+		"[attachment: blob.txt (text/plain, 3 B) — not sent: not a text file]",
+		"[attachment: gone.txt (text/plain, 3 B) — not sent: download failed]",
+	}
+	if strings.Join(blocks, "\n") != strings.Join(want, "\n") {
+		t.Errorf("blocks =\n%s\nwant\n%s", strings.Join(blocks, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSplitMessage(t *testing.T) {
+	got := splitMessage("aaaa\nbbbb\ncccc", 7)
+	want := []string{"aaaa\n", "bbbb\n", "cccc"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("splitMessage = %q, want %q", got, want)
+	}
+
+	got = splitMessage(strings.Repeat("é", 5), 2)
+	if len(got) != 3 || got[0] != "éé" || got[2] != "é" {
+		t.Errorf("splitMessage split a character: %q", got)
+	}
+}
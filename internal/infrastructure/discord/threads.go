@@ -0,0 +1,98 @@
+package discord
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxThreadNameLength keeps thread names short; Discord allows up to 100.
+const maxThreadNameLength = 60
+
+// channel looks up a channel in the state cache, falling back to the REST API.
+func (b *Bot) channel(channelID string) *discordgo.Channel {
+	if ch, err := b.session.State.Channel(channelID); err == nil {
+		return ch
+	}
+	ch, err := b.session.Channel(channelID)
+	if err != nil {
+		return nil
+	}
+	return ch
+}
+
+// isConversationThread reports whether channelID is a thread the bot started
+// for a conversation.
+func (b *Bot) isConversationThread(channelID string) bool {
+	if !b.config.Threads {
+		return false
+	}
+	ch := b.channel(channelID)
+	return ch != nil && ch.IsThread() && b.session.State.User != nil && ch.OwnerID == b.session.State.User.ID
+}
+
+// startThread starts a public thread from message m, named after its content.
+func (b *Bot) startThread(s *discordgo.Session, m *discordgo.MessageCreate, content string) (*discordgo.Channel, error) {
+	return s.MessageThreadStartComplex(m.ChannelID, m.ID, &discordgo.ThreadStart{
+		Name:                threadName(content),
+		AutoArchiveDuration: threadArchiveMinutes(b.config.ThreadArchive),
+	})
+}
+
+// threadName derives a thread name from the first line of a message.
+func threadName(content string) string {
+	name, _, _ := strings.Cut(strings.TrimSpace(content), "\n")
+	name = strings.Join(strings.Fields(name), " ")
+	if runes := []rune(name); len(runes) > maxThreadNameLength {
+		name = strings.TrimSpace(string(runes[:maxThreadNameLength-1])) + "…"
+	}
+	if name == "" {
+		name = "Laserbeak chat"
+	}
+	return name
+}
+
+// threadArchiveMinutes rounds d up to an auto-archive duration Discord
+// accepts: one hour, one day, three days or one week.
+func threadArchiveMinutes(d time.Duration) int {
+	for _, minutes := range []int{60, 1440, 4320} {
+		if d <= time.Duration(minutes)*time.Minute {
+			return minutes
+		}
+	}
+	return 10080
+}
+
+// onThreadUpdate drops a conversation once its thread is archived. A later
+// message in the thread unarchives it and starts a fresh conversation.
+func (b *Bot) onThreadUpdate(s *discordgo.Session, t *discordgo.ThreadUpdate) {
+	if t.ThreadMetadata == nil || !t.ThreadMetadata.Archived {
+		return
+	}
+	if s.State.User == nil || t.OwnerID != s.State.User.ID {
+		return
+	}
+	slog.Info("thread archived, dropping its conversation", "thread", t.ID)
+	b.forgetThread(t.ID)
+}
+
+// onThreadDelete drops the conversation of a deleted thread. Deletions carry
+// no owner, so this runs for every thread; clearing an unknown one is a no-op.
+func (b *Bot) onThreadDelete(s *discordgo.Session, t *discordgo.ThreadDelete) {
+	b.forgetThread(t.ID)
+}
+
+// forgetThread removes a thread's conversation from the repository.
+func (b *Bot) forgetThread(threadID string) {
+	if b.chatHandler == nil {
+		return
+	}
+	req := bot.ChatRequest{ChannelID: threadID, Content: "/clear"}
+	if _, err := b.chatHandler(context.Background(), req); err != nil {
+		slog.Warn("failed to clear conversation for thread", "thread", threadID, "err", err)
+	}
+}
@@ -0,0 +1,47 @@
+package discord
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestThreadName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"what's the   weather like?", "what's the weather like?"},
+		{"first line\nsecond line", "first line"},
+		{"   ", "Laserbeak chat"},
+	}
+	for _, tt := range tests {
+		if got := threadName(tt.in); got != tt.want {
+			t.Errorf("threadName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	long := threadName(strings.Repeat("é", 200))
+	if n := len([]rune(long)); n != maxThreadNameLength || !strings.HasSuffix(long, "…") {
+		t.Errorf("long name has %d runes (%q), want %d ending in an ellipsis", n, long, maxThreadNameLength)
+	}
+}
+
+func TestThreadArchiveMinutes(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want int
+	}{
+		{0, 60},
+		{time.Hour, 60},
+		{2 * time.Hour, 1440},
+		{24 * time.Hour, 1440},
+		{48 * time.Hour, 4320},
+		{30 * 24 * time.Hour, 10080},
+	}
+	for _, tt := range tests {
+		if got := threadArchiveMinutes(tt.in); got != tt.want {
+			t.Errorf("threadArchiveMinutes(%s) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
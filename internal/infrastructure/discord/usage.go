@@ -0,0 +1,121 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/usage"
+	"github.com/bwmarrin/discordgo"
+)
+
+// UsageReporter provides usage totals and quotas for the usage command.
+type UsageReporter interface {
+	Totals(scope usage.Scope, id string, period usage.Period) usage.Totals
+	Quotas() []usage.Quota
+}
+
+// SetUsageReporter enables the usage command.
+func (b *Bot) SetUsageReporter(r UsageReporter) {
+	b.usage = r
+}
+
+// withAttribution returns a context attributing API usage to the sender of a
+// message in a channel.
+func withAttribution(ctx context.Context, guildID, channelID, userID string) context.Context {
+	return usage.WithAttribution(ctx, usage.Attribution{GuildID: guildID, ChannelID: channelID, UserID: userID})
+}
+
+// handleUsage shows today's and this month's usage for the caller, or for
+// the first user mentioned, along with the server's.
+func (b *Bot) handleUsage(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if b.usage == nil {
+		s.ChannelMessageSend(m.ChannelID, "Usage tracking is disabled.")
+		return
+	}
+
+	user := m.Author
+	for _, u := range m.Mentions {
+		if u.ID != s.State.User.ID {
+			user = u
+			break
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**Usage for <@%s>**\n", user.ID)
+	b.writeUsage(&sb, usage.ScopeUser, user.ID)
+	if m.GuildID != "" {
+		sb.WriteString("**This server**\n")
+		b.writeUsage(&sb, usage.ScopeGuild, m.GuildID)
+	}
+	s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Content:         sb.String(),
+		AllowedMentions: &discordgo.MessageAllowedMentions{}, // don't ping the user
+	})
+}
+
+// writeUsage writes one line per period, followed by the quotas that apply.
+func (b *Bot) writeUsage(sb *strings.Builder, scope usage.Scope, id string) {
+	labels := map[usage.Period]string{usage.PeriodDaily: "Today", usage.PeriodMonthly: "This month"}
+	for _, period := range usage.Periods {
+		t := b.usage.Totals(scope, id, period)
+		fmt.Fprintf(sb, "%s: %s\n", labels[period], formatTotals(t))
+
+		for _, q := range b.usage.Quotas() {
+			if q.Scope == scope && q.Period == period {
+				fmt.Fprintf(sb, "↳ %s quota: %s\n", q.Period, formatQuota(q, t))
+			}
+		}
+	}
+}
+
+// formatTotals summarizes usage in one line.
+func formatTotals(t usage.Totals) string {
+	parts := []string{fmt.Sprintf("%d requests", t.Requests), fmt.Sprintf("%d tokens", t.Tokens())}
+	if t.AudioSeconds > 0 {
+		parts = append(parts, fmt.Sprintf("%.0fs of audio", t.AudioSeconds))
+	}
+	if t.Cost > 0 {
+		parts = append(parts, fmt.Sprintf("$%.4f", t.Cost))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// formatQuota shows how much of each of a quota's limits has been used.
+func formatQuota(q usage.Quota, t usage.Totals) string {
+	var parts []string
+	if q.Tokens > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d tokens", t.Tokens(), q.Tokens))
+	}
+	if q.AudioSeconds > 0 {
+		parts = append(parts, fmt.Sprintf("%.0f/%.0fs of audio", t.AudioSeconds, q.AudioSeconds))
+	}
+	if q.Cost > 0 {
+		parts = append(parts, fmt.Sprintf("$%.2f/$%.2f", t.Cost, q.Cost))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// quotaMessage is the reply to a request blocked by a usage quota.
+func quotaMessage(e *usage.QuotaError, userID string) string {
+	wait := formatReset(time.Until(e.Reset))
+	switch e.Quota.Scope {
+	case usage.ScopeChannel:
+		return fmt.Sprintf("This channel has used up its %s quota. It resets in %s.", e.Quota.Period, wait)
+	case usage.ScopeGuild:
+		return fmt.Sprintf("This server has used up its %s quota. It resets in %s.", e.Quota.Period, wait)
+	default:
+		return fmt.Sprintf("<@%s> you've used up your %s quota. It resets in %s.", userID, e.Quota.Period, wait)
+	}
+}
+
+// formatReset formats the time until a quota resets, to the minute once it's
+// more than a minute away.
+func formatReset(d time.Duration) string {
+	if d < time.Minute {
+		return formatWait(d)
+	}
+	return strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+}
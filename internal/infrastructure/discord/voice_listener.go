@@ -2,11 +2,12 @@ package discord
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/adrock-miles/go-laserbeak/internal/infrastructure/audio"
+	"github.com/adrock-miles/go-laserbeak/internal/logging"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -25,6 +26,9 @@ type VoiceTranscription struct {
 	GuildID   string
 	ChannelID string // text channel to respond in
 	Audio     []byte // WAV-encoded audio
+	// CorrelationID follows the utterance through transcription, matching
+	// and sending in the logs.
+	CorrelationID string
 }
 
 // VoiceListener manages voice connections and collects user audio.
@@ -32,6 +36,8 @@ type VoiceListener struct {
 	mu          sync.RWMutex
 	connections map[string]*voiceConn // guildID -> voiceConn
 	resultChan  chan VoiceTranscription
+	speaks      bool // join unmuted, to play sounds
+	metrics     Metrics
 
 	ssrcMu     sync.RWMutex
 	ssrcToUser map[uint32]string // SSRC -> userID (populated by VoiceSpeakingUpdate)
@@ -57,6 +63,31 @@ func (vl *VoiceListener) Results() <-chan VoiceTranscription {
 	return vl.resultChan
 }
 
+// SetSpeaks sets whether the bot joins voice channels unmuted, so it can play
+// sounds. It only listens by default.
+func (vl *VoiceListener) SetSpeaks(speaks bool) {
+	vl.mu.Lock()
+	vl.speaks = speaks
+	vl.mu.Unlock()
+}
+
+// SetMetrics counts the utterances emitted and dropped.
+func (vl *VoiceListener) SetMetrics(m Metrics) {
+	vl.mu.Lock()
+	vl.metrics = m
+	vl.mu.Unlock()
+}
+
+// countUtterance counts an utterance when metrics are enabled.
+func (vl *VoiceListener) countUtterance(result string) {
+	vl.mu.RLock()
+	m := vl.metrics
+	vl.mu.RUnlock()
+	if m != nil {
+		m.VoiceUtterance(result)
+	}
+}
+
 // Join connects to a voice channel and begins listening.
 func (vl *VoiceListener) Join(s *discordgo.Session, guildID, voiceChannelID, textChannelID string) error {
 	vl.mu.Lock()
@@ -69,7 +100,7 @@ func (vl *VoiceListener) Join(s *discordgo.Session, guildID, voiceChannelID, tex
 		delete(vl.connections, guildID)
 	}
 
-	vc, err := s.ChannelVoiceJoin(guildID, voiceChannelID, true, false) // mute=true (listen only), deaf=false
+	vc, err := s.ChannelVoiceJoin(guildID, voiceChannelID, !vl.speaks, false) // muted unless playing sounds, deaf=false
 	if err != nil {
 		return err
 	}
@@ -119,10 +150,31 @@ func (vl *VoiceListener) Leave(guildID string) bool {
 	conn.cancel()
 	conn.vc.Disconnect()
 	delete(vl.connections, guildID)
-	log.Printf("Disconnected from voice in guild %s", guildID)
+	slog.Info("disconnected from voice", "guild", guildID)
 	return true
 }
 
+// ChannelID returns the voice channel the bot is connected to in a guild.
+func (vl *VoiceListener) ChannelID(guildID string) (string, bool) {
+	vl.mu.RLock()
+	defer vl.mu.RUnlock()
+	conn, ok := vl.connections[guildID]
+	if !ok {
+		return "", false
+	}
+	return conn.vc.ChannelID, true
+}
+
+// Connection returns the voice connection in a guild, or nil if there is none.
+func (vl *VoiceListener) Connection(guildID string) *discordgo.VoiceConnection {
+	vl.mu.RLock()
+	defer vl.mu.RUnlock()
+	if conn, ok := vl.connections[guildID]; ok {
+		return conn.vc
+	}
+	return nil
+}
+
 // LeaveAll disconnects from all voice channels.
 func (vl *VoiceListener) LeaveAll() {
 	vl.mu.Lock()
@@ -145,7 +197,7 @@ func (vl *VoiceListener) listenLoop(ctx context.Context, conn *voiceConn) {
 
 	decoder, err := audio.NewOpusDecoder()
 	if err != nil {
-		log.Printf("error creating opus decoder: %v", err)
+		slog.Error("failed to create opus decoder", "guild", conn.vc.GuildID, "err", err)
 		return
 	}
 
@@ -162,6 +214,11 @@ func (vl *VoiceListener) listenLoop(ctx context.Context, conn *voiceConn) {
 	for {
 		select {
 		case <-ctx.Done():
+			for _, buf := range buffers {
+				if buf.frames >= minSpeechFrames {
+					vl.countUtterance("shutdown")
+				}
+			}
 			return
 
 		case pkt, ok := <-opusChan:
@@ -193,7 +250,9 @@ func (vl *VoiceListener) listenLoop(ctx context.Context, conn *voiceConn) {
 
 				if buf.frames >= minSpeechFrames {
 					userID := vl.getUserID(ssrc)
-					vl.emitPCM(conn, userID, buf.pcm)
+					vl.emitPCM(ctx, conn, userID, buf.pcm)
+				} else {
+					vl.countUtterance("short")
 				}
 
 				delete(buffers, ssrc)
@@ -203,17 +262,31 @@ func (vl *VoiceListener) listenLoop(ctx context.Context, conn *voiceConn) {
 }
 
 // emitPCM converts accumulated PCM samples to WAV and sends to results channel.
-func (vl *VoiceListener) emitPCM(conn *voiceConn, userID string, pcm []int16) {
+// The utterance is dropped if the connection closes while it waits.
+func (vl *VoiceListener) emitPCM(connCtx context.Context, conn *voiceConn, userID string, pcm []int16) {
+	t := VoiceTranscription{
+		UserID:        userID,
+		GuildID:       conn.vc.GuildID,
+		ChannelID:     conn.textChannelID,
+		CorrelationID: logging.NewCorrelationID(),
+	}
+	ctx := logging.WithCorrelationID(context.Background(), t.CorrelationID)
+	ctx = withAttribution(ctx, t.GuildID, t.ChannelID, userID)
+
 	wav, err := audio.PCMToWAV(pcm, audio.SampleRate, audio.Channels)
 	if err != nil {
-		log.Printf("error encoding WAV: %v", err)
+		slog.ErrorContext(ctx, "failed to encode utterance as WAV", "err", err)
+		vl.countUtterance("encode")
 		return
 	}
-
-	vl.resultChan <- VoiceTranscription{
-		UserID:    userID,
-		GuildID:   conn.vc.GuildID,
-		ChannelID: conn.textChannelID,
-		Audio:     wav,
+	t.Audio = wav
+
+	select {
+	case vl.resultChan <- t:
+		slog.DebugContext(ctx, "utterance emitted", "duration", audio.WAVDuration(wav))
+		vl.countUtterance("emitted")
+	case <-connCtx.Done():
+		slog.DebugContext(ctx, "dropped an utterance: left the voice channel")
+		vl.countUtterance("shutdown")
 	}
 }
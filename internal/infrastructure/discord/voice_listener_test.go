@@ -0,0 +1,52 @@
+package discord
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// countingMetrics counts utterances by result.
+type countingMetrics struct {
+	mu         sync.Mutex
+	utterances map[string]int
+}
+
+func (m *countingMetrics) ChatRequest(string)               {}
+func (m *countingMetrics) CommandDispatched(string, string) {}
+func (m *countingMetrics) RateLimited(string)               {}
+
+func (m *countingMetrics) VoiceUtterance(result string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.utterances == nil {
+		m.utterances = make(map[string]int)
+	}
+	m.utterances[result]++
+}
+
+func TestVoiceListener_EmitPCM(t *testing.T) {
+	vl := NewVoiceListener()
+	vl.resultChan = make(chan VoiceTranscription, 1)
+	metrics := &countingMetrics{}
+	vl.SetMetrics(metrics)
+	conn := &voiceConn{vc: &discordgo.VoiceConnection{GuildID: "g1"}, textChannelID: "c1"}
+	pcm := make([]int16, 960)
+
+	vl.emitPCM(context.Background(), conn, "u1", pcm)
+	if got := <-vl.Results(); got.UserID != "u1" || got.ChannelID != "c1" || len(got.Audio) == 0 {
+		t.Errorf("emitted %+v", got)
+	}
+
+	// A full channel blocks until the connection closes, then drops.
+	vl.resultChan <- VoiceTranscription{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	vl.emitPCM(ctx, conn, "u1", pcm)
+
+	if metrics.utterances["emitted"] != 1 || metrics.utterances["shutdown"] != 1 {
+		t.Errorf("utterances = %v, want one emitted and one shutdown", metrics.utterances)
+	}
+}
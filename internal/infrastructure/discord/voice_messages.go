@@ -0,0 +1,110 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/ratelimit"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/usage"
+	"github.com/adrock-miles/go-laserbeak/internal/infrastructure/audio"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// maxVoiceMessageBytes caps voice message downloads; Discord encodes
+	// them at about 32 kbit/s, so this is well over five minutes.
+	maxVoiceMessageBytes = 4 << 20
+	// maxVoiceMessageDuration is the longest voice message transcribed.
+	maxVoiceMessageDuration = 5 * time.Minute
+)
+
+// handleVoiceMessage transcribes a voice message, sending any wake-phrase
+// command to the output channel like one spoken in voice chat. With
+// VoiceMessageChat, other voice messages in bot channels are answered as chat.
+func (b *Bot) handleVoiceMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.config.VoiceMessages || b.voiceMessages == nil || !b.transcribesVoiceMessages(m.ChannelID) {
+		return
+	}
+	a := voiceAttachment(m.Attachments)
+	if a == nil || !b.firstDelivery(m.ID) {
+		return
+	}
+	if a.DurationSecs > maxVoiceMessageDuration.Seconds() {
+		slog.Info("skipping a voice message longer than the limit", "message", m.ID, "duration", a.DurationSecs, "limit", maxVoiceMessageDuration)
+		return
+	}
+
+	key := ratelimit.Key{GuildID: m.GuildID, ChannelID: m.ChannelID, UserID: m.Author.ID}
+	if !b.allow(ratelimit.KindSTT, key, a.DurationSecs, "") {
+		return
+	}
+
+	msgCtx := messageContext(m)
+	go func() {
+		ctx, cancel := context.WithTimeout(msgCtx, 2*time.Minute)
+		defer cancel()
+
+		data, err := b.download(ctx, a.URL, maxVoiceMessageBytes)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to download voice message", "message", m.ID, "err", err)
+			return
+		}
+		wav, err := audio.OggOpusToWAV(data)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to decode voice message", "message", m.ID, "err", err)
+			return
+		}
+
+		transcript, command, err := b.voiceMessages(ctx, m.ChannelID, m.Author.ID, wav)
+		var quotaErr *usage.QuotaError
+		if errors.As(err, &quotaErr) {
+			s.ChannelMessageSend(m.ChannelID, quotaMessage(quotaErr, m.Author.ID))
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "voice message handler failed", "message", m.ID, "err", err)
+			return
+		}
+
+		switch {
+		case command.Text != "":
+			outputCh := b.outputChannel(m.ChannelID)
+			if b.allow(ratelimit.KindVoice, key, 1, outputCh) {
+				b.sendVoiceCommand(ctx, m.GuildID, m.Author.ID, outputCh, command, "voice_message")
+			}
+		case transcript != "" && b.config.VoiceMessageChat && b.isBotChannel(m.ChannelID) && b.chatHandler != nil:
+			req := b.chatRequest(m, transcript)
+			if req.ParentChannelID != "" {
+				key.ChannelID = req.ParentChannelID
+			}
+			if b.allow(ratelimit.KindChat, key, 1, m.ChannelID) {
+				b.dispatchChat(msgCtx, s, req, nil)
+			}
+		}
+	}()
+}
+
+// voiceAttachment returns the audio of a voice message.
+func voiceAttachment(attachments []*discordgo.MessageAttachment) *discordgo.MessageAttachment {
+	for _, a := range attachments {
+		if strings.HasPrefix(a.ContentType, "audio/ogg") {
+			return a
+		}
+	}
+	return nil
+}
+
+// transcribesVoiceMessages reports whether voice messages in a channel are
+// transcribed: in bot channels, or anywhere when no text channel is set.
+func (b *Bot) transcribesVoiceMessages(channelID string) bool {
+	return b.config.TextChannelID == "" || b.isBotChannel(channelID)
+}
+
+// isBotChannel reports whether a channel is the configured text channel or a
+// thread the bot started.
+func (b *Bot) isBotChannel(channelID string) bool {
+	return channelID == b.config.TextChannelID || b.isConversationThread(channelID)
+}
@@ -0,0 +1,33 @@
+package discord
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestVoiceAttachment(t *testing.T) {
+	voice := &discordgo.MessageAttachment{Filename: "voice-message.ogg", ContentType: "audio/ogg", DurationSecs: 3.2}
+	got := voiceAttachment([]*discordgo.MessageAttachment{
+		{Filename: "notes.txt", ContentType: "text/plain"},
+		voice,
+	})
+	if got != voice {
+		t.Errorf("voiceAttachment = %+v, want the ogg attachment", got)
+	}
+	if voiceAttachment(nil) != nil {
+		t.Error("voiceAttachment(nil) should be nil")
+	}
+}
+
+func TestTranscribesVoiceMessages(t *testing.T) {
+	anywhere := &Bot{}
+	if !anywhere.transcribesVoiceMessages("c1") {
+		t.Error("without a text channel, voice messages should be transcribed anywhere")
+	}
+
+	b := &Bot{config: BotConfig{TextChannelID: "bot-ch"}}
+	if !b.transcribesVoiceMessages("bot-ch") || b.transcribesVoiceMessages("other") {
+		t.Error("with a text channel, only voice messages there should be transcribed")
+	}
+}
@@ -0,0 +1,365 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/usage"
+)
+
+const (
+	// anthropicVersion is the Messages API version this client speaks.
+	anthropicVersion = "2023-06-01"
+
+	// defaultAnthropicMaxTokens is used when no max_tokens is configured;
+	// the Messages API requires one on every request.
+	defaultAnthropicMaxTokens = 1024
+)
+
+// AnthropicClient implements bot.LLMService and bot.ToolCallingLLM using the
+// Anthropic Messages API.
+type AnthropicClient struct {
+	apiKey    string
+	baseURL   string
+	model     string
+	maxTokens int
+	client    *resilientClient
+	recorder  usage.Recorder
+}
+
+// NewAnthropicClient creates a new Anthropic Messages API client.
+func NewAnthropicClient(apiKey, baseURL, model string, maxTokens int) *AnthropicClient {
+	if baseURL == "" {
+		baseURL = "https://api.anthropic.com/v1"
+	}
+	if maxTokens <= 0 {
+		maxTokens = defaultAnthropicMaxTokens
+	}
+	return &AnthropicClient{
+		apiKey:    apiKey,
+		baseURL:   baseURL,
+		model:     model,
+		maxTokens: maxTokens,
+		client: newResilientClient("LLM", &http.Client{
+			Timeout: 120 * time.Second,
+			Transport: &http.Transport{
+				MaxIdleConns:        20,
+				MaxIdleConnsPerHost: 10,
+				IdleConnTimeout:     90 * time.Second,
+			},
+		}),
+	}
+}
+
+// SetUsageRecorder reports the tokens used by each completion to r.
+func (c *AnthropicClient) SetUsageRecorder(r usage.Recorder) {
+	c.recorder = r
+}
+
+// SetRequestObserver reports the duration and outcome of each request to o.
+func (c *AnthropicClient) SetRequestObserver(o RequestObserver) {
+	c.client.observer, c.client.model = o, c.model
+}
+
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	MaxTokens int                `json:"max_tokens"`
+	System    string             `json:"system,omitempty"`
+	Messages  []anthropicMessage `json:"messages"`
+	Tools     []anthropicTool    `json:"tools,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type anthropicMessage struct {
+	Role    string      `json:"role"`
+	Content string      `json:"content"`
+	Images  []bot.Image `json:"-"`
+	// Tool blocks: calls made by an assistant turn, and the results a user
+	// turn returns for them.
+	ToolUses    []anthropicBlock `json:"-"`
+	ToolResults []anthropicBlock `json:"-"`
+}
+
+type anthropicBlock struct {
+	Type   string                `json:"type"`
+	Text   string                `json:"text,omitempty"`
+	Source *anthropicImageSource `json:"source,omitempty"`
+
+	ID    string          `json:"id,omitempty"`    // tool_use
+	Name  string          `json:"name,omitempty"`  // tool_use
+	Input json.RawMessage `json:"input,omitempty"` // tool_use
+
+	ToolUseID string `json:"tool_use_id,omitempty"` // tool_result
+	Content   string `json:"content,omitempty"`     // tool_result
+}
+
+type anthropicImageSource struct {
+	Type      string `json:"type"` // "url" or "base64"
+	URL       string `json:"url,omitempty"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+}
+
+// MarshalJSON sends content as a plain string, or as blocks when the message
+// has images or tool blocks. Tool results come first, as the API requires.
+func (m anthropicMessage) MarshalJSON() ([]byte, error) {
+	if len(m.Images) == 0 && len(m.ToolUses) == 0 && len(m.ToolResults) == 0 {
+		type plain anthropicMessage
+		return json.Marshal(plain(m))
+	}
+
+	blocks := slices.Clone(m.ToolResults)
+	for _, img := range m.Images {
+		source := &anthropicImageSource{Type: "url", URL: img.URL}
+		if len(img.Data) > 0 {
+			mediaType := img.MIMEType
+			if mediaType == "" {
+				mediaType = http.DetectContentType(img.Data)
+			}
+			source = &anthropicImageSource{Type: "base64", MediaType: mediaType, Data: base64.StdEncoding.EncodeToString(img.Data)}
+		}
+		blocks = append(blocks, anthropicBlock{Type: "image", Source: source})
+	}
+	if m.Content != "" {
+		blocks = append(blocks, anthropicBlock{Type: "text", Text: m.Content})
+	}
+	blocks = append(blocks, m.ToolUses...)
+	return json.Marshal(struct {
+		Role    string           `json:"role"`
+		Content []anthropicBlock `json:"content"`
+	}{m.Role, blocks})
+}
+
+type anthropicResponse struct {
+	Type       string           `json:"type"`
+	Content    []anthropicBlock `json:"content"`
+	StopReason string           `json:"stop_reason"`
+	Usage      *struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage,omitempty"`
+	Error *anthropicErrorDetail `json:"error,omitempty"`
+}
+
+type anthropicErrorEnvelope struct {
+	Type  string                `json:"type"`
+	Error *anthropicErrorDetail `json:"error"`
+}
+
+type anthropicErrorDetail struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+func (c *AnthropicClient) ChatCompletion(ctx context.Context, messages []bot.LLMMessage) (string, error) {
+	reply, err := c.complete(ctx, messages, nil)
+	if err != nil {
+		return "", err
+	}
+	return reply.Content, nil
+}
+
+// ChatCompletionWithTools implements bot.ToolCallingLLM using the tools
+// field and the tool_use and tool_result blocks of the Messages API.
+func (c *AnthropicClient) ChatCompletionWithTools(ctx context.Context, messages []bot.LLMMessage, tools []bot.ToolDefinition) (bot.LLMMessage, error) {
+	return c.complete(ctx, messages, tools)
+}
+
+func (c *AnthropicClient) complete(ctx context.Context, messages []bot.LLMMessage, tools []bot.ToolDefinition) (bot.LLMMessage, error) {
+	system, msgs := toAnthropicMessages(messages)
+	if len(msgs) == 0 {
+		return bot.LLMMessage{}, fmt.Errorf("no user message to send")
+	}
+
+	reqBody := anthropicRequest{
+		Model:     c.model,
+		MaxTokens: c.maxTokens,
+		System:    system,
+		Messages:  msgs,
+	}
+	for _, t := range tools {
+		schema := t.Parameters
+		if schema == nil {
+			schema = map[string]any{"type": "object"}
+		}
+		reqBody.Tools = append(reqBody.Tools, anthropicTool{Name: t.Name, Description: t.Description, InputSchema: schema})
+	}
+
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return bot.LLMMessage{}, fmt.Errorf("marshal request: %w", err)
+	}
+
+	endpoint := c.baseURL + "/messages"
+	slog.DebugContext(ctx, "LLM request", "messages", len(msgs), "tools", len(tools), "model", c.model, "endpoint", endpoint)
+	start := time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return bot.LLMMessage{}, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Api-Key", c.apiKey)
+	req.Header.Set("Anthropic-Version", anthropicVersion)
+
+	respBody, err := c.client.Do(req)
+	if err != nil {
+		return bot.LLMMessage{}, describeAnthropicError(err)
+	}
+
+	var resp anthropicResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return bot.LLMMessage{}, fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	if resp.Error != nil {
+		return bot.LLMMessage{}, fmt.Errorf("API error: %s: %s", resp.Error.Type, resp.Error.Message)
+	}
+
+	if resp.Usage != nil && c.recorder != nil {
+		c.recorder.Record(ctx, usage.Record{
+			Model:            c.model,
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+		})
+	}
+
+	var sb strings.Builder
+	reply := bot.LLMMessage{Role: "assistant"}
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			sb.WriteString(block.Text)
+		case "tool_use":
+			reply.ToolCalls = append(reply.ToolCalls, bot.ToolCall{ID: block.ID, Name: block.Name, Arguments: string(block.Input)})
+		}
+	}
+	if sb.Len() == 0 && len(reply.ToolCalls) == 0 {
+		return bot.LLMMessage{}, fmt.Errorf("no text content in response (stop_reason=%s)", resp.StopReason)
+	}
+
+	reply.Content = sb.String()
+	slog.InfoContext(ctx, "LLM response", "model", c.model, "duration", time.Since(start), "length", len(reply.Content), "tool_calls", len(reply.ToolCalls))
+	return reply, nil
+}
+
+// toAnthropicMessages converts LLM messages to the Messages API shape.
+// Leading system messages are lifted into the separate system field; later
+// ones, such as quoted reply context, keep their place by prefixing the next
+// user turn with "[context]". Consecutive messages with the same role are
+// merged, and leading assistant turns are dropped, since the API requires
+// strictly alternating turns starting with user. The API has no speaker
+// field, so named user turns are prefixed with the name. Tool calls become
+// tool_use blocks, and "tool" messages tool_result blocks in a user turn.
+func toAnthropicMessages(messages []bot.LLMMessage) (string, []anthropicMessage) {
+	var system, pending []string
+	var msgs []anthropicMessage
+	leading := true
+
+	for _, m := range messages {
+		if m.Role == "system" {
+			if leading {
+				system = append(system, m.Content)
+			} else {
+				pending = append(pending, m.Content)
+			}
+			continue
+		}
+		role := m.Role
+		if role != "assistant" {
+			role = "user"
+		}
+		content := m.Content
+		if role == "user" && m.Name != "" {
+			content = m.Name + ": " + content
+		}
+		turn := anthropicMessage{Role: role, Content: content, Images: m.Images}
+		switch {
+		case m.Role == "tool" && answersToolUse(msgs, m.ToolCallID):
+			turn.Content = ""
+			turn.ToolResults = []anthropicBlock{{Type: "tool_result", ToolUseID: m.ToolCallID, Content: m.Content}}
+		case role == "user" && len(pending) > 0:
+			turn.Content = "[context] " + strings.Join(pending, "\n\n") + "\n\n" + content
+			pending = nil
+		}
+		for _, tc := range m.ToolCalls {
+			input := json.RawMessage(tc.Arguments)
+			if !json.Valid(input) {
+				input = json.RawMessage("{}")
+			}
+			turn.ToolUses = append(turn.ToolUses, anthropicBlock{Type: "tool_use", ID: tc.ID, Name: tc.Name, Input: input})
+		}
+		msgs = appendAnthropicTurn(msgs, turn)
+		leading = len(msgs) == 0
+	}
+	if len(pending) > 0 {
+		msgs = appendAnthropicTurn(msgs, anthropicMessage{Role: "user", Content: "[context] " + strings.Join(pending, "\n\n")})
+	}
+
+	return strings.Join(system, "\n\n"), msgs
+}
+
+// answersToolUse reports whether the last turn is an assistant turn that made
+// the tool call id. A result whose call was trimmed from the history is sent
+// as plain text, since the API rejects orphaned tool_result blocks.
+func answersToolUse(msgs []anthropicMessage, id string) bool {
+	if len(msgs) == 0 || msgs[len(msgs)-1].Role != "assistant" {
+		return false
+	}
+	return slices.ContainsFunc(msgs[len(msgs)-1].ToolUses, func(b anthropicBlock) bool { return b.ID == id })
+}
+
+// appendAnthropicTurn appends m, merging it into the last turn when both have
+// the same role. Slices are copied so merging never writes to the caller's.
+func appendAnthropicTurn(msgs []anthropicMessage, m anthropicMessage) []anthropicMessage {
+	n := len(msgs)
+	switch {
+	case n == 0 && m.Role == "assistant":
+		return msgs
+	case n > 0 && msgs[n-1].Role == m.Role:
+		last := &msgs[n-1]
+		switch {
+		case last.Content == "":
+			last.Content = m.Content
+		case m.Content != "":
+			last.Content += "\n\n" + m.Content
+		}
+		last.Images = append(last.Images, m.Images...)
+		last.ToolUses = append(last.ToolUses, m.ToolUses...)
+		last.ToolResults = append(last.ToolResults, m.ToolResults...)
+		return msgs
+	}
+	m.Images = slices.Clone(m.Images)
+	m.ToolUses = slices.Clone(m.ToolUses)
+	m.ToolResults = slices.Clone(m.ToolResults)
+	return append(msgs, m)
+}
+
+// describeAnthropicError replaces the raw body of an APIError with the
+// type and message from Anthropic's error envelope, when present.
+func describeAnthropicError(err error) error {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	var env anthropicErrorEnvelope
+	if json.Unmarshal([]byte(apiErr.Body), &env) == nil && env.Error != nil {
+		apiErr.Body = env.Error.Type + ": " + env.Error.Message
+	}
+	return err
+}
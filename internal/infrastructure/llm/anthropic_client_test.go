@@ -0,0 +1,307 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+)
+
+// fakeAnthropic is a minimal local stand-in for the Messages API. It records the
+// last request and replies with the configured status and body.
+type fakeAnthropic struct {
+	status int
+	body   string
+
+	lastHeader  http.Header
+	lastRequest anthropicRequest
+}
+
+func newFakeAnthropic(t *testing.T, status int, body string) (*fakeAnthropic, *httptest.Server) {
+	t.Helper()
+	f := &fakeAnthropic{status: status, body: body}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/messages" {
+			http.NotFound(w, r)
+			return
+		}
+		f.lastHeader = r.Header.Clone()
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &f.lastRequest)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(f.status)
+		io.WriteString(w, f.body)
+	}))
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+const okAnthropic = `{"type":"message","role":"assistant","content":[{"type":"text","text":"Ahoy"},{"type":"text","text":" there"}],"stop_reason":"end_turn"}`
+
+func TestAnthropic_RequestShape(t *testing.T) {
+	fake, srv := newFakeAnthropic(t, 200, okAnthropic)
+	c := NewAnthropicClient("secret", srv.URL, "claude-test", 0)
+
+	got, err := c.ChatCompletion(context.Background(), []bot.LLMMessage{
+		{Role: "system", Content: "You are a pirate."},
+		{Role: "user", Content: "hi"},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletion error: %v", err)
+	}
+	if got != "Ahoy there" {
+		t.Errorf("ChatCompletion = %q, want %q", got, "Ahoy there")
+	}
+
+	if h := fake.lastHeader.Get("X-Api-Key"); h != "secret" {
+		t.Errorf("x-api-key = %q, want %q", h, "secret")
+	}
+	if h := fake.lastHeader.Get("Anthropic-Version"); h != anthropicVersion {
+		t.Errorf("anthropic-version = %q, want %q", h, anthropicVersion)
+	}
+	if h := fake.lastHeader.Get("Authorization"); h != "" {
+		t.Errorf("Authorization header sent: %q", h)
+	}
+
+	req := fake.lastRequest
+	if req.Model != "claude-test" {
+		t.Errorf("model = %q, want %q", req.Model, "claude-test")
+	}
+	if req.MaxTokens != defaultAnthropicMaxTokens {
+		t.Errorf("max_tokens = %d, want %d", req.MaxTokens, defaultAnthropicMaxTokens)
+	}
+	if req.System != "You are a pirate." {
+		t.Errorf("system = %q, want the system prompt", req.System)
+	}
+	if len(req.Messages) != 1 || req.Messages[0].Role != "user" {
+		t.Errorf("messages = %+v, want a single user turn", req.Messages)
+	}
+}
+
+func TestToAnthropicMessages_Alternation(t *testing.T) {
+	system, msgs := toAnthropicMessages([]bot.LLMMessage{
+		{Role: "assistant", Content: "stale reply left over from trimming"},
+		{Role: "system", Content: "sys one"},
+		{Role: "user", Content: "a"},
+		{Role: "user", Content: "b"},
+		{Role: "assistant", Content: "c"},
+		{Role: "system", Content: "sys two"},
+		{Role: "assistant", Content: "d"},
+		{Role: "user", Content: "e"},
+	})
+
+	if system != "sys one" {
+		t.Errorf("system = %q", system)
+	}
+
+	want := []anthropicMessage{
+		{Role: "user", Content: "a\n\nb"},
+		{Role: "assistant", Content: "c\n\nd"},
+		{Role: "user", Content: "[context] sys two\n\ne"},
+	}
+	if len(msgs) != len(want) {
+		t.Fatalf("messages = %+v, want %+v", msgs, want)
+	}
+	for i := range want {
+		if msgs[i].Role != want[i].Role || msgs[i].Content != want[i].Content {
+			t.Errorf("messages[%d] = %+v, want %+v", i, msgs[i], want[i])
+		}
+	}
+}
+
+func TestAnthropic_ErrorEnvelope(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantClass ErrorClass
+		wantText  string
+	}{
+		{
+			name:      "overloaded",
+			status:    529,
+			body:      `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+			wantClass: ErrorRetryable,
+			wantText:  "overloaded_error: Overloaded",
+		},
+		{
+			name:      "invalid request",
+			status:    400,
+			body:      `{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens: field required"}}`,
+			wantClass: ErrorFatal,
+			wantText:  "invalid_request_error: max_tokens: field required",
+		},
+		{
+			name:      "authentication",
+			status:    401,
+			body:      `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`,
+			wantClass: ErrorFallthrough,
+			wantText:  "authentication_error: invalid x-api-key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, srv := newFakeAnthropic(t, tt.status, tt.body)
+			c := NewAnthropicClient("key", srv.URL, "claude-test", 256)
+			recordSleeps(c.client)
+
+			_, err := c.ChatCompletion(context.Background(), []bot.LLMMessage{{Role: "user", Content: "hi"}})
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %v, want *APIError", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantText) {
+				t.Errorf("err = %q, want it to contain %q", err, tt.wantText)
+			}
+			if got := ClassifyError(err); got != tt.wantClass {
+				t.Errorf("ClassifyError = %s, want %s", got, tt.wantClass)
+			}
+		})
+	}
+}
+
+func TestAnthropic_NoUserMessage(t *testing.T) {
+	_, srv := newFakeAnthropic(t, 200, okAnthropic)
+	c := NewAnthropicClient("key", srv.URL, "claude-test", 0)
+
+	if _, err := c.ChatCompletion(context.Background(), []bot.LLMMessage{{Role: "system", Content: "only system"}}); err == nil {
+		t.Error("expected error when there is no user message")
+	}
+}
+
+func TestToAnthropicMessages_SpeakerNames(t *testing.T) {
+	_, msgs := toAnthropicMessages([]bot.LLMMessage{
+		{Role: "user", Name: "Sam", Content: "hi"},
+		{Role: "user", Name: "Alex", Content: "yo"},
+		{Role: "assistant", Content: "hello both"},
+	})
+
+	if len(msgs) != 2 || msgs[0].Content != "Sam: hi\n\nAlex: yo" {
+		t.Errorf("messages = %+v, want named user turns merged", msgs)
+	}
+}
+
+func TestAnthropic_ImageBlocks(t *testing.T) {
+	_, msgs := toAnthropicMessages([]bot.LLMMessage{
+		{Role: "user", Content: "what's wrong here?", Images: []bot.Image{{URL: "https://cdn.example/shot.png"}}},
+		{Role: "user", Content: "and this", Images: []bot.Image{{Data: []byte("GIF89a"), MIMEType: "image/gif"}}},
+	})
+	if len(msgs) != 1 {
+		t.Fatalf("messages = %+v, want one merged user turn", msgs)
+	}
+
+	data, err := json.Marshal(msgs[0])
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want := `{"role":"user","content":[` +
+		`{"type":"image","source":{"type":"url","url":"https://cdn.example/shot.png"}},` +
+		`{"type":"image","source":{"type":"base64","media_type":"image/gif","data":"R0lGODlh"}},` +
+		`{"type":"text","text":"what's wrong here?\n\nand this"}]}`
+	if string(data) != want {
+		t.Errorf("message =\n%s\nwant\n%s", data, want)
+	}
+
+	plain, _ := json.Marshal(anthropicMessage{Role: "user", Content: "hi"})
+	if string(plain) != `{"role":"user","content":"hi"}` {
+		t.Errorf("text-only message = %s", plain)
+	}
+}
+
+func TestToAnthropicMessages_ContextBeforeLatestTurn(t *testing.T) {
+	system, msgs := toAnthropicMessages([]bot.LLMMessage{
+		{Role: "system", Content: "You are a pirate."},
+		{Role: "user", Name: "Sam", Content: "hi"},
+		{Role: "assistant", Content: "ahoy"},
+		{Role: "system", Content: "Sam is replying to: ahoy"},
+		{Role: "user", Name: "Sam", Content: "what?"},
+	})
+
+	if system != "You are a pirate." {
+		t.Errorf("system = %q, want only the leading prompt", system)
+	}
+	if len(msgs) != 3 || msgs[2].Content != "[context] Sam is replying to: ahoy\n\nSam: what?" {
+		t.Errorf("messages = %+v, want the context folded into the last user turn", msgs)
+	}
+}
+
+func TestToAnthropicMessages_MergeKeepsCallerImages(t *testing.T) {
+	first := make([]bot.Image, 1, 4)
+	first[0] = bot.Image{URL: "https://cdn.example/a.png"}
+	spare := first[:2]
+	spare[1] = bot.Image{URL: "https://cdn.example/untouched.png"}
+
+	toAnthropicMessages([]bot.LLMMessage{
+		{Role: "user", Content: "a", Images: first},
+		{Role: "user", Content: "b", Images: []bot.Image{{URL: "https://cdn.example/b.png"}}},
+	})
+	if spare[1].URL != "https://cdn.example/untouched.png" {
+		t.Errorf("merging wrote %q into the caller's images", spare[1].URL)
+	}
+}
+
+func TestAnthropic_ToolUse(t *testing.T) {
+	fake, srv := newFakeAnthropic(t, 200, `{"type":"message","role":"assistant","content":[`+
+		`{"type":"text","text":"Checking."},`+
+		`{"type":"tool_use","id":"toolu_1","name":"current_time","input":{"timezone":"UTC"}}],"stop_reason":"tool_use"}`)
+	c := NewAnthropicClient("secret", srv.URL, "claude-test", 0)
+
+	reply, err := c.ChatCompletionWithTools(context.Background(), []bot.LLMMessage{
+		{Role: "user", Content: "what time is it?"},
+	}, []bot.ToolDefinition{
+		{Name: "current_time", Description: "Get the time."},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletionWithTools: %v", err)
+	}
+	if reply.Content != "Checking." || len(reply.ToolCalls) != 1 {
+		t.Fatalf("reply = %+v, want text and one tool call", reply)
+	}
+	if call := reply.ToolCalls[0]; call.ID != "toolu_1" || call.Name != "current_time" || call.Arguments != `{"timezone":"UTC"}` {
+		t.Errorf("tool call = %+v", call)
+	}
+
+	tools := fake.lastRequest.Tools
+	if len(tools) != 1 || tools[0].Name != "current_time" || tools[0].InputSchema["type"] != "object" {
+		t.Errorf("tools = %+v, want current_time with an object input_schema", tools)
+	}
+}
+
+func TestToAnthropicMessages_ToolBlocks(t *testing.T) {
+	_, msgs := toAnthropicMessages([]bot.LLMMessage{
+		{Role: "user", Content: "play airhorn"},
+		{Role: "assistant", ToolCalls: []bot.ToolCall{{ID: "toolu_1", Name: "play_sound", Arguments: `{"name":"airhorn"}`}}},
+		{Role: "tool", ToolCallID: "toolu_1", Content: "queued"},
+	})
+	if len(msgs) != 3 {
+		t.Fatalf("messages = %+v, want user, assistant, user", msgs)
+	}
+
+	call, _ := json.Marshal(msgs[1])
+	wantCall := `{"role":"assistant","content":[{"type":"tool_use","id":"toolu_1","name":"play_sound","input":{"name":"airhorn"}}]}`
+	if string(call) != wantCall {
+		t.Errorf("tool call turn =\n%s\nwant\n%s", call, wantCall)
+	}
+	result, _ := json.Marshal(msgs[2])
+	wantResult := `{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"queued"}]}`
+	if string(result) != wantResult {
+		t.Errorf("tool result turn =\n%s\nwant\n%s", result, wantResult)
+	}
+
+	// A result whose call was trimmed from the history goes as text.
+	_, msgs = toAnthropicMessages([]bot.LLMMessage{
+		{Role: "tool", ToolCallID: "toolu_0", Content: "queued"},
+		{Role: "user", Content: "thanks"},
+	})
+	if len(msgs) != 1 || len(msgs[0].ToolResults) != 0 || msgs[0].Content != "queued\n\nthanks" {
+		t.Errorf("messages = %+v, want the orphaned result as plain text", msgs)
+	}
+}
@@ -0,0 +1,177 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+)
+
+// ErrorClass describes how a Fallback chain reacts to a provider error.
+type ErrorClass int
+
+const (
+	// ErrorRetryable is a transient failure (rate limit, 5xx, timeout, open
+	// circuit). The next provider is tried, and if every provider fails this
+	// way the chain reports bot.ErrServiceUnavailable.
+	ErrorRetryable ErrorClass = iota
+	// ErrorFallthrough is a provider-specific failure (bad credentials, unknown
+	// model) that another provider may not share. The next provider is tried.
+	ErrorFallthrough
+	// ErrorFatal means the request itself was rejected or the caller gave up;
+	// no other provider is tried.
+	ErrorFatal
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorRetryable:
+		return "retryable"
+	case ErrorFallthrough:
+		return "fallthrough"
+	case ErrorFatal:
+		return "fatal"
+	}
+	return "unknown"
+}
+
+// ClassifyError maps a provider error to an ErrorClass.
+func ClassifyError(err error) ErrorClass {
+	if errors.Is(err, context.Canceled) {
+		return ErrorFatal
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, bot.ErrServiceUnavailable) {
+		return ErrorRetryable
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.Retryable():
+			return ErrorRetryable
+		case apiErr.StatusCode == http.StatusBadRequest,
+			apiErr.StatusCode == http.StatusRequestEntityTooLarge,
+			apiErr.StatusCode == http.StatusUnprocessableEntity:
+			return ErrorFatal
+		default:
+			return ErrorFallthrough
+		}
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return ErrorRetryable
+	}
+	return ErrorFallthrough
+}
+
+// Provider is one entry in a Fallback chain.
+type Provider struct {
+	Name    string
+	Service bot.LLMService
+	Timeout time.Duration // per-attempt timeout; zero means only the caller's deadline applies
+}
+
+// Fallback implements bot.LLMService by trying an ordered list of providers
+// until one succeeds.
+type Fallback struct {
+	providers []Provider
+}
+
+// NewFallback creates a Fallback chain from one or more providers, in priority order.
+func NewFallback(providers ...Provider) *Fallback {
+	return &Fallback{providers: providers}
+}
+
+// ChatCompletion sends messages to each provider in turn, returning the first reply.
+func (f *Fallback) ChatCompletion(ctx context.Context, messages []bot.LLMMessage) (string, error) {
+	reply, err := f.run(ctx, func(ctx context.Context, p Provider) (bot.LLMMessage, error) {
+		content, err := p.Service.ChatCompletion(ctx, messages)
+		return bot.LLMMessage{Role: "assistant", Content: content}, err
+	})
+	return reply.Content, err
+}
+
+// ChatCompletionWithTools implements bot.ToolCallingLLM. Providers without
+// tool support answer the conversation without the tool exchange, so a chain
+// that falls back to them still produces a plain reply.
+func (f *Fallback) ChatCompletionWithTools(ctx context.Context, messages []bot.LLMMessage, tools []bot.ToolDefinition) (bot.LLMMessage, error) {
+	return f.run(ctx, func(ctx context.Context, p Provider) (bot.LLMMessage, error) {
+		if tc, ok := p.Service.(bot.ToolCallingLLM); ok {
+			return tc.ChatCompletionWithTools(ctx, messages, tools)
+		}
+		content, err := p.Service.ChatCompletion(ctx, withoutToolExchange(messages))
+		return bot.LLMMessage{Role: "assistant", Content: content}, err
+	})
+}
+
+// run calls each provider in turn until one succeeds.
+func (f *Fallback) run(ctx context.Context, call func(context.Context, Provider) (bot.LLMMessage, error)) (bot.LLMMessage, error) {
+	if len(f.providers) == 0 {
+		return bot.LLMMessage{}, fmt.Errorf("no LLM providers configured")
+	}
+
+	var lastErr error
+	allRetryable := true
+
+	for i, p := range f.providers {
+		start := time.Now()
+		reply, err := f.try(ctx, p, call)
+		if err == nil {
+			slog.InfoContext(ctx, "LLM request served", "provider", p.Name, "position", i+1,
+				"providers", len(f.providers), "duration", time.Since(start).Round(time.Millisecond))
+			return reply, nil
+		}
+
+		// The caller's own deadline or cancellation ends the chain regardless of class.
+		if ctx.Err() != nil {
+			return bot.LLMMessage{}, fmt.Errorf("LLM provider %q: %w", p.Name, err)
+		}
+
+		class := ClassifyError(err)
+		if class == ErrorFatal {
+			return bot.LLMMessage{}, fmt.Errorf("LLM provider %q (%s): %w", p.Name, class, err)
+		}
+		if class != ErrorRetryable {
+			allRetryable = false
+		}
+
+		lastErr = fmt.Errorf("LLM provider %q (%s): %w", p.Name, class, err)
+		if i < len(f.providers)-1 {
+			slog.WarnContext(ctx, "LLM provider failed, falling back", "provider", p.Name, "class", class.String(),
+				"next", f.providers[i+1].Name, "err", err)
+		}
+	}
+
+	if allRetryable {
+		return bot.LLMMessage{}, fmt.Errorf("all LLM providers failed: %w: %w", bot.ErrServiceUnavailable, lastErr)
+	}
+	return bot.LLMMessage{}, fmt.Errorf("all LLM providers failed: %w", lastErr)
+}
+
+func (f *Fallback) try(ctx context.Context, p Provider, call func(context.Context, Provider) (bot.LLMMessage, error)) (bot.LLMMessage, error) {
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+	return call(ctx, p)
+}
+
+// withoutToolExchange drops tool calls and tool results from messages, for
+// providers that cannot represent them.
+func withoutToolExchange(messages []bot.LLMMessage) []bot.LLMMessage {
+	out := make([]bot.LLMMessage, 0, len(messages))
+	for _, m := range messages {
+		if m.Role == "tool" || len(m.ToolCalls) > 0 {
+			continue
+		}
+		out = append(out, m)
+	}
+	return out
+}
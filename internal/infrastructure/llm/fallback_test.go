@@ -0,0 +1,149 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+)
+
+type stubLLM struct {
+	reply string
+	err   error
+	delay time.Duration
+	calls int
+	last  []bot.LLMMessage
+}
+
+func (s *stubLLM) ChatCompletion(ctx context.Context, messages []bot.LLMMessage) (string, error) {
+	s.calls++
+	s.last = messages
+	if s.delay > 0 {
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	return s.reply, s.err
+}
+
+func TestFallback_FirstProviderServes(t *testing.T) {
+	primary := &stubLLM{reply: "primary"}
+	secondary := &stubLLM{reply: "secondary"}
+	f := NewFallback(Provider{Name: "a", Service: primary}, Provider{Name: "b", Service: secondary})
+
+	got, err := f.ChatCompletion(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ChatCompletion error: %v", err)
+	}
+	if got != "primary" || secondary.calls != 0 {
+		t.Errorf("got %q with %d secondary calls, want primary only", got, secondary.calls)
+	}
+}
+
+func TestFallback_FallsThroughOnProviderErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"server error", &APIError{StatusCode: 503}},
+		{"rate limited", &APIError{StatusCode: 429}},
+		{"unauthorized", &APIError{StatusCode: 401}},
+		{"unknown model", &APIError{StatusCode: 404}},
+		{"circuit open", ErrCircuitOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFallback(
+				Provider{Name: "a", Service: &stubLLM{err: tt.err}},
+				Provider{Name: "b", Service: &stubLLM{reply: "backup"}},
+			)
+			got, err := f.ChatCompletion(context.Background(), nil)
+			if err != nil {
+				t.Fatalf("ChatCompletion error: %v", err)
+			}
+			if got != "backup" {
+				t.Errorf("ChatCompletion = %q, want %q", got, "backup")
+			}
+		})
+	}
+}
+
+func TestFallback_FatalStopsChain(t *testing.T) {
+	secondary := &stubLLM{reply: "backup"}
+	f := NewFallback(
+		Provider{Name: "a", Service: &stubLLM{err: &APIError{StatusCode: 400, Body: "context too long"}}},
+		Provider{Name: "b", Service: secondary},
+	)
+
+	_, err := f.ChatCompletion(context.Background(), nil)
+	if err == nil {
+		t.Fatal("expected error for fatal provider failure")
+	}
+	if secondary.calls != 0 {
+		t.Errorf("secondary called %d times after fatal error, want 0", secondary.calls)
+	}
+}
+
+func TestFallback_PerProviderTimeout(t *testing.T) {
+	f := NewFallback(
+		Provider{Name: "slow", Service: &stubLLM{reply: "late", delay: time.Second}, Timeout: 10 * time.Millisecond},
+		Provider{Name: "fast", Service: &stubLLM{reply: "fast"}},
+	)
+
+	got, err := f.ChatCompletion(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ChatCompletion error: %v", err)
+	}
+	if got != "fast" {
+		t.Errorf("ChatCompletion = %q, want %q", got, "fast")
+	}
+}
+
+func TestFallback_AllRetryableIsUnavailable(t *testing.T) {
+	f := NewFallback(
+		Provider{Name: "a", Service: &stubLLM{err: &APIError{StatusCode: 503}}},
+		Provider{Name: "b", Service: &stubLLM{err: ErrCircuitOpen}},
+	)
+
+	_, err := f.ChatCompletion(context.Background(), nil)
+	if !errors.Is(err, bot.ErrServiceUnavailable) {
+		t.Errorf("err = %v, want bot.ErrServiceUnavailable", err)
+	}
+}
+
+func TestFallback_MixedFailuresNotUnavailable(t *testing.T) {
+	f := NewFallback(
+		Provider{Name: "a", Service: &stubLLM{err: &APIError{StatusCode: 503}}},
+		Provider{Name: "b", Service: &stubLLM{err: &APIError{StatusCode: 401}}},
+	)
+
+	_, err := f.ChatCompletion(context.Background(), nil)
+	if err == nil || errors.Is(err, bot.ErrServiceUnavailable) {
+		t.Errorf("err = %v, want a non-unavailable error", err)
+	}
+}
+
+func TestFallback_ToolsDegradeForPlainProviders(t *testing.T) {
+	plain := &stubLLM{reply: "it is noon"}
+	f := NewFallback(Provider{Name: "plain", Service: plain})
+
+	reply, err := f.ChatCompletionWithTools(context.Background(), []bot.LLMMessage{
+		{Role: "user", Content: "time?"},
+		{Role: "assistant", ToolCalls: []bot.ToolCall{{ID: "1", Name: "current_time"}}},
+		{Role: "tool", ToolCallID: "1", Content: "12:00"},
+	}, []bot.ToolDefinition{{Name: "current_time"}})
+	if err != nil {
+		t.Fatalf("ChatCompletionWithTools error: %v", err)
+	}
+	if reply.Content != "it is noon" || len(reply.ToolCalls) != 0 {
+		t.Errorf("reply = %+v, want plain content", reply)
+	}
+	if len(plain.last) != 1 || plain.last[0].Role != "user" {
+		t.Errorf("plain provider got %+v, want only the user message", plain.last)
+	}
+}
@@ -3,22 +3,25 @@ package llm
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/usage"
 )
 
 // OpenAIClient implements bot.LLMService using the OpenAI-compatible chat completions API.
 type OpenAIClient struct {
-	apiKey  string
-	baseURL string
-	model   string
-	client  *http.Client
+	apiKey   string
+	baseURL  string
+	model    string
+	client   *resilientClient
+	recorder usage.Recorder
 }
 
 // NewOpenAIClient creates a new OpenAI-compatible LLM client.
@@ -30,94 +33,231 @@ func NewOpenAIClient(apiKey, baseURL, model string) *OpenAIClient {
 		apiKey:  apiKey,
 		baseURL: baseURL,
 		model:   model,
-		client: &http.Client{
+		client: newResilientClient("LLM", &http.Client{
 			Timeout: 120 * time.Second,
 			Transport: &http.Transport{
 				MaxIdleConns:        20,
 				MaxIdleConnsPerHost: 10,
 				IdleConnTimeout:     90 * time.Second,
 			},
-		},
+		}),
 	}
 }
 
+// SetUsageRecorder reports the tokens used by each completion to r.
+func (c *OpenAIClient) SetUsageRecorder(r usage.Recorder) {
+	c.recorder = r
+}
+
+// SetRequestObserver reports the duration and outcome of each request to o.
+func (c *OpenAIClient) SetRequestObserver(o RequestObserver) {
+	c.client.observer, c.client.model = o, c.model
+}
+
 type chatRequest struct {
-	Model    string       `json:"model"`
-	Messages []chatMsg    `json:"messages"`
+	Model    string     `json:"model"`
+	Messages []chatMsg  `json:"messages"`
+	Tools    []chatTool `json:"tools,omitempty"`
 }
 
 type chatMsg struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string         `json:"role"`
+	Content    any            `json:"content"` // string, or []chatPart when the message has images
+	Name       string         `json:"name,omitempty"`
+	ToolCalls  []chatToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string         `json:"tool_call_id,omitempty"`
+}
+
+type chatPart struct {
+	Type     string        `json:"type"`
+	Text     string        `json:"text,omitempty"`
+	ImageURL *chatImageURL `json:"image_url,omitempty"`
+}
+
+type chatImageURL struct {
+	URL string `json:"url"`
+}
+
+type chatTool struct {
+	Type     string       `json:"type"`
+	Function chatFunction `json:"function"`
+}
+
+type chatFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+type chatToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
 }
 
 type chatResponse struct {
 	Choices []struct {
 		Message struct {
-			Content string `json:"content"`
+			Content   string         `json:"content"`
+			ToolCalls []chatToolCall `json:"tool_calls"`
 		} `json:"message"`
 	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage,omitempty"`
 	Error *struct {
 		Message string `json:"message"`
 	} `json:"error,omitempty"`
 }
 
 func (c *OpenAIClient) ChatCompletion(ctx context.Context, messages []bot.LLMMessage) (string, error) {
+	reply, err := c.complete(ctx, messages, nil)
+	if err != nil {
+		return "", err
+	}
+	return reply.Content, nil
+}
+
+// ChatCompletionWithTools implements bot.ToolCallingLLM using the tools and
+// tool_calls fields of the chat completions API.
+func (c *OpenAIClient) ChatCompletionWithTools(ctx context.Context, messages []bot.LLMMessage, tools []bot.ToolDefinition) (bot.LLMMessage, error) {
+	return c.complete(ctx, messages, tools)
+}
+
+func (c *OpenAIClient) complete(ctx context.Context, messages []bot.LLMMessage, tools []bot.ToolDefinition) (bot.LLMMessage, error) {
 	msgs := make([]chatMsg, len(messages))
 	for i, m := range messages {
-		msgs[i] = chatMsg{Role: m.Role, Content: m.Content}
+		msgs[i] = chatMsg{Role: m.Role, Content: openAIContent(m), ToolCallID: m.ToolCallID}
+		if m.Role == "user" {
+			msgs[i].Name = openAIName(m.Name)
+		}
+		for _, tc := range m.ToolCalls {
+			call := chatToolCall{ID: tc.ID, Type: "function"}
+			call.Function.Name = tc.Name
+			call.Function.Arguments = tc.Arguments
+			msgs[i].ToolCalls = append(msgs[i].ToolCalls, call)
+		}
 	}
 
 	reqBody := chatRequest{
 		Model:    c.model,
 		Messages: msgs,
 	}
+	for _, t := range tools {
+		reqBody.Tools = append(reqBody.Tools, chatTool{
+			Type:     "function",
+			Function: chatFunction{Name: t.Name, Description: t.Description, Parameters: t.Parameters},
+		})
+	}
 
 	body, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("marshal request: %w", err)
+		return bot.LLMMessage{}, fmt.Errorf("marshal request: %w", err)
 	}
 
 	endpoint := c.baseURL + "/chat/completions"
-	log.Printf("LLM request: messages=%d, model=%s, endpoint=%s", len(msgs), c.model, endpoint)
+	slog.DebugContext(ctx, "LLM request", "messages", len(msgs), "tools", len(tools), "model", c.model, "endpoint", endpoint)
 	start := time.Now()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
 	if err != nil {
-		return "", fmt.Errorf("create request: %w", err)
+		return bot.LLMMessage{}, fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 
-	resp, err := c.client.Do(req)
+	respBody, err := c.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+		return bot.LLMMessage{}, err
 	}
 
 	var chatResp chatResponse
 	if err := json.Unmarshal(respBody, &chatResp); err != nil {
-		return "", fmt.Errorf("unmarshal response: %w", err)
+		return bot.LLMMessage{}, fmt.Errorf("unmarshal response: %w", err)
 	}
 
 	if chatResp.Error != nil {
-		return "", fmt.Errorf("API error: %s", chatResp.Error.Message)
+		return bot.LLMMessage{}, fmt.Errorf("API error: %s", chatResp.Error.Message)
+	}
+
+	if chatResp.Usage != nil && c.recorder != nil {
+		c.recorder.Record(ctx, usage.Record{
+			Model:            c.model,
+			PromptTokens:     chatResp.Usage.PromptTokens,
+			CompletionTokens: chatResp.Usage.CompletionTokens,
+		})
 	}
 
 	if len(chatResp.Choices) == 0 {
-		return "", fmt.Errorf("no choices in response")
+		return bot.LLMMessage{}, fmt.Errorf("no choices in response")
 	}
 
-	result := chatResp.Choices[0].Message.Content
-	log.Printf("LLM response: duration=%s, length=%d", time.Since(start), len(result))
-	return result, nil
+	msg := chatResp.Choices[0].Message
+	reply := bot.LLMMessage{Role: "assistant", Content: msg.Content}
+	for _, tc := range msg.ToolCalls {
+		reply.ToolCalls = append(reply.ToolCalls, bot.ToolCall{
+			ID:        tc.ID,
+			Name:      tc.Function.Name,
+			Arguments: tc.Function.Arguments,
+		})
+	}
+	slog.InfoContext(ctx, "LLM response", "model", c.model, "duration", time.Since(start), "length", len(reply.Content), "tool_calls", len(reply.ToolCalls))
+	return reply, nil
+}
+
+// openAIContent returns a message's content as a plain string, or as an
+// array of text and image parts when it has images. Inline images are sent
+// as data URLs.
+func openAIContent(m bot.LLMMessage) any {
+	if len(m.Images) == 0 {
+		return m.Content
+	}
+	parts := make([]chatPart, 0, len(m.Images)+1)
+	if m.Content != "" {
+		parts = append(parts, chatPart{Type: "text", Text: m.Content})
+	}
+	for _, img := range m.Images {
+		parts = append(parts, chatPart{Type: "image_url", ImageURL: &chatImageURL{URL: imageURL(img)}})
+	}
+	return parts
+}
+
+// imageURL returns the URL for an image, encoding inline data as a data URL.
+func imageURL(img bot.Image) string {
+	if len(img.Data) == 0 {
+		return img.URL
+	}
+	mimeType := img.MIMEType
+	if mimeType == "" {
+		mimeType = http.DetectContentType(img.Data)
+	}
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(img.Data)
+}
+
+// openAIName converts a display name to the form the name field accepts:
+// at most 64 characters from [a-zA-Z0-9_-]. Other characters become
+// underscores; names with nothing usable are dropped.
+func openAIName(name string) string {
+	var sb strings.Builder
+	useful := false
+	for _, r := range name {
+		if sb.Len() >= 64 {
+			break
+		}
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+			sb.WriteRune(r)
+			useful = true
+		default:
+			sb.WriteByte('_')
+		}
+	}
+	if !useful {
+		return ""
+	}
+	return sb.String()
 }
@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+)
+
+func TestOpenAIName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Sam", "Sam"},
+		{"Sam Smith", "Sam_Smith"},
+		{"d.va-fan_99", "d_va-fan_99"},
+		{"🔥🔥", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := openAIName(tt.in); got != tt.want {
+			t.Errorf("openAIName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestOpenAI_ToolCallRoundTrip(t *testing.T) {
+	var got chatRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &got)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":null,
+			"tool_calls":[{"id":"call_2","type":"function","function":{"name":"play_sound","arguments":"{\"name\":\"wow\"}"}}]}}]}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewOpenAIClient("key", srv.URL, "gpt-test")
+	reply, err := c.ChatCompletionWithTools(context.Background(), []bot.LLMMessage{
+		{Role: "user", Content: "what time is it?"},
+		{Role: "assistant", ToolCalls: []bot.ToolCall{{ID: "call_1", Name: "current_time", Arguments: "{}"}}},
+		{Role: "tool", ToolCallID: "call_1", Content: "12:00"},
+	}, []bot.ToolDefinition{{
+		Name:        "play_sound",
+		Description: "Play a sound",
+		Parameters:  map[string]any{"type": "object"},
+	}})
+	if err != nil {
+		t.Fatalf("ChatCompletionWithTools error: %v", err)
+	}
+
+	if len(got.Tools) != 1 || got.Tools[0].Type != "function" || got.Tools[0].Function.Name != "play_sound" {
+		t.Errorf("request tools = %+v", got.Tools)
+	}
+	if len(got.Messages) != 3 {
+		t.Fatalf("request messages = %d, want 3", len(got.Messages))
+	}
+	if calls := got.Messages[1].ToolCalls; len(calls) != 1 || calls[0].ID != "call_1" || calls[0].Function.Name != "current_time" {
+		t.Errorf("assistant tool_calls = %+v", calls)
+	}
+	if got.Messages[2].ToolCallID != "call_1" {
+		t.Errorf("tool message tool_call_id = %q, want call_1", got.Messages[2].ToolCallID)
+	}
+
+	if len(reply.ToolCalls) != 1 {
+		t.Fatalf("reply tool calls = %+v", reply.ToolCalls)
+	}
+	if tc := reply.ToolCalls[0]; tc.ID != "call_2" || tc.Name != "play_sound" || tc.Arguments != `{"name":"wow"}` {
+		t.Errorf("reply tool call = %+v", tc)
+	}
+}
+
+func TestOpenAIContent_Images(t *testing.T) {
+	if got := openAIContent(bot.LLMMessage{Content: "plain"}); got != "plain" {
+		t.Errorf("text-only content = %#v, want a plain string", got)
+	}
+
+	got := openAIContent(bot.LLMMessage{
+		Content: "what's wrong here?",
+		Images: []bot.Image{
+			{URL: "https://cdn.example/shot.png", MIMEType: "image/png"},
+			{Data: []byte("GIF89a"), MIMEType: "image/gif"},
+		},
+	})
+	data, _ := json.Marshal(got)
+	want := `[{"type":"text","text":"what's wrong here?"},` +
+		`{"type":"image_url","image_url":{"url":"https://cdn.example/shot.png"}},` +
+		`{"type":"image_url","image_url":{"url":"data:image/gif;base64,R0lGODlh"}}]`
+	if string(data) != want {
+		t.Errorf("content =\n%s\nwant\n%s", data, want)
+	}
+}
@@ -0,0 +1,349 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+)
+
+// RetryPolicy controls how transient failures are retried.
+type RetryPolicy struct {
+	MaxAttempts int           // total attempts, including the first
+	BaseDelay   time.Duration // backoff before the first retry; doubles on each retry
+	MaxDelay    time.Duration // cap on a single wait; a longer Retry-After ends retrying
+}
+
+// DefaultRetryPolicy is used by the OpenAI-compatible clients.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+}
+
+// statusOverloaded is the non-standard status Anthropic returns when its API is overloaded.
+const statusOverloaded = 529
+
+// ErrCircuitOpen is returned without contacting the API while the circuit breaker is open.
+var ErrCircuitOpen = fmt.Errorf("circuit breaker open: %w", bot.ErrServiceUnavailable)
+
+// APIError is returned when an API responds with a non-200 status.
+type APIError struct {
+	Service    string // e.g. "LLM" or "STT"
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // parsed from Retry-After, zero if absent
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s API error (status %d): %s", e.Service, e.StatusCode, e.Body)
+}
+
+// Retryable reports whether the status indicates a transient failure worth retrying.
+func (e *APIError) Retryable() bool {
+	switch e.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout,
+		statusOverloaded:
+		return true
+	}
+	return false
+}
+
+// RequestObserver is told how long each API request took, retries included,
+// and whether it failed.
+type RequestObserver interface {
+	ObserveRequest(service, model string, d time.Duration, err error)
+}
+
+// resilientClient wraps an http.Client with retries, jittered exponential
+// backoff, Retry-After handling and a circuit breaker. It is shared by the
+// chat completion and transcription clients.
+type resilientClient struct {
+	service string
+	client  *http.Client
+	policy  RetryPolicy
+	breaker *CircuitBreaker
+
+	observer RequestObserver // may be nil
+	model    string          // reported to the observer
+
+	// sleep waits for d or until ctx is done; replaced in tests.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func newResilientClient(service string, client *http.Client) *resilientClient {
+	return &resilientClient{
+		service: service,
+		client:  client,
+		policy:  DefaultRetryPolicy,
+		breaker: NewCircuitBreaker(5, 30*time.Second),
+		sleep:   sleepContext,
+	}
+}
+
+// Do sends req and returns the body of a 200 response. Transient failures
+// (connection errors, 408/425/429/5xx) are retried per the policy. The request
+// body must be replayable, which http.NewRequest guarantees for bytes.Buffer,
+// bytes.Reader and strings.Reader bodies.
+func (c *resilientClient) Do(req *http.Request) ([]byte, error) {
+	if c.observer == nil {
+		return c.do(req)
+	}
+	start := time.Now()
+	body, err := c.do(req)
+	c.observer.ObserveRequest(c.service, c.model, time.Since(start), err)
+	return body, err
+}
+
+func (c *resilientClient) do(req *http.Request) ([]byte, error) {
+	ctx := req.Context()
+
+	if !c.breaker.Allow() {
+		return nil, fmt.Errorf("%s: %w", c.service, ErrCircuitOpen)
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		var body []byte
+		body, err = c.attempt(req, attempt)
+		if err == nil {
+			c.breaker.Success()
+			return body, nil
+		}
+
+		if attempt >= c.policy.MaxAttempts || !isRetryable(err) || ctx.Err() != nil {
+			break
+		}
+
+		delay, ok := c.backoff(err, attempt)
+		if !ok {
+			break
+		}
+		if deadline, hasDeadline := ctx.Deadline(); hasDeadline && time.Until(deadline) < delay {
+			break
+		}
+
+		slog.WarnContext(ctx, "request failed, retrying", "service", c.service, "attempt", attempt,
+			"max_attempts", c.policy.MaxAttempts, "delay", delay.Round(time.Millisecond), "err", err)
+		if c.sleep(ctx, delay) != nil {
+			break
+		}
+	}
+
+	switch {
+	case isRetryable(err) && ctx.Err() == nil:
+		c.breaker.Failure()
+	case ctx.Err() != nil:
+		// The caller gave up; that says nothing about the API's health.
+		c.breaker.Release()
+	default:
+		// The API answered (e.g. 400), so it is reachable.
+		c.breaker.Success()
+	}
+	return nil, err
+}
+
+// attempt performs a single HTTP round trip.
+func (c *resilientClient) attempt(req *http.Request, attempt int) ([]byte, error) {
+	r := req
+	if attempt > 1 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("rewind request body: %w", err)
+		}
+		r = req.Clone(req.Context())
+		r.Body = body
+	}
+
+	resp, err := c.client.Do(r)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIError{
+			Service:    c.service,
+			StatusCode: resp.StatusCode,
+			Body:       string(respBody),
+			RetryAfter: parseRetryAfter(resp.Header),
+		}
+	}
+	return respBody, nil
+}
+
+// backoff returns how long to wait before the next attempt. A server-provided
+// Retry-After is honoured as a minimum; if it exceeds MaxDelay, retrying stops.
+func (c *resilientClient) backoff(err error, attempt int) (time.Duration, bool) {
+	delay := c.policy.BaseDelay << (attempt - 1)
+	if delay > c.policy.MaxDelay || delay <= 0 {
+		delay = c.policy.MaxDelay
+	}
+	// Equal jitter: half fixed, half random, so retries never collapse to zero.
+	delay = delay/2 + rand.N(delay/2+1)
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		if apiErr.RetryAfter > c.policy.MaxDelay {
+			return 0, false
+		}
+		if apiErr.RetryAfter > delay {
+			delay = apiErr.RetryAfter
+		}
+	}
+	return delay, true
+}
+
+// isRetryable reports whether err is a transient API or transport failure.
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+	// Anything else from attempt is a transport-level failure.
+	return true
+}
+
+// parseRetryAfter reads Retry-After (seconds or HTTP date) or the
+// retry-after-ms extension used by OpenAI.
+func parseRetryAfter(h http.Header) time.Duration {
+	if ms := h.Get("Retry-After-Ms"); ms != "" {
+		if n, err := strconv.ParseFloat(ms, 64); err == nil && n > 0 {
+			return time.Duration(n * float64(time.Millisecond))
+		}
+	}
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// CircuitBreaker stops calls to a failing API so callers fall back fast.
+// After threshold consecutive failures it opens for cooldown, then lets a
+// single probe through; the probe's outcome closes or reopens the circuit.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     circuitState
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	now       func() time.Time
+}
+
+// NewCircuitBreaker creates a closed CircuitBreaker.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether a call may proceed.
+func (cb *CircuitBreaker) Allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case circuitOpen:
+		if cb.now().Sub(cb.openedAt) < cb.cooldown {
+			return false
+		}
+		cb.state = circuitHalfOpen
+		cb.probing = true
+		return true
+	case circuitHalfOpen:
+		if cb.probing {
+			return false
+		}
+		cb.probing = true
+		return true
+	}
+	return true
+}
+
+// Success records a healthy response and closes the circuit.
+func (cb *CircuitBreaker) Success() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state != circuitClosed {
+		slog.Info("circuit breaker closed")
+	}
+	cb.state = circuitClosed
+	cb.failures = 0
+	cb.probing = false
+}
+
+// Failure records a failed call, opening the circuit at the threshold
+// or immediately if the half-open probe failed.
+func (cb *CircuitBreaker) Failure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures++
+	cb.probing = false
+	if cb.state == circuitHalfOpen || cb.failures >= cb.threshold {
+		if cb.state != circuitOpen {
+			slog.Warn("circuit breaker opened", "failures", cb.failures, "cooldown", cb.cooldown)
+		}
+		cb.state = circuitOpen
+		cb.openedAt = cb.now()
+	}
+}
+
+// Release records a call that ended without saying anything about API health
+// (e.g. the caller's context was cancelled), freeing a half-open probe slot.
+func (cb *CircuitBreaker) Release() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.probing = false
+}
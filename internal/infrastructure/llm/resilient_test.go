@@ -0,0 +1,314 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+)
+
+// scriptedResponse is one step of a scripted server's failure sequence.
+type scriptedResponse struct {
+	status     int
+	body       string
+	retryAfter string
+}
+
+// scriptedServer replies with each scripted response in order, repeating the
+// last one once the script is exhausted. It records every request body.
+type scriptedServer struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	script []scriptedResponse
+	bodies []string
+}
+
+func newScriptedServer(t *testing.T, script ...scriptedResponse) *scriptedServer {
+	t.Helper()
+	s := &scriptedServer{script: script}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		s.mu.Lock()
+		i := len(s.bodies)
+		s.bodies = append(s.bodies, string(body))
+		step := s.script[min(i, len(s.script)-1)]
+		s.mu.Unlock()
+
+		if step.retryAfter != "" {
+			w.Header().Set("Retry-After", step.retryAfter)
+		}
+		w.WriteHeader(step.status)
+		io.WriteString(w, step.body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *scriptedServer) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.bodies...)
+}
+
+// recordSleeps replaces the client's sleep with one that returns immediately
+// and records the requested delays.
+func recordSleeps(c *resilientClient) *[]time.Duration {
+	var delays []time.Duration
+	c.sleep = func(_ context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	return &delays
+}
+
+const okChat = `{"choices":[{"message":{"content":"hello"}}]}`
+
+func TestChatCompletion_RetriesTransientFailures(t *testing.T) {
+	srv := newScriptedServer(t,
+		scriptedResponse{status: 503, body: "unavailable"},
+		scriptedResponse{status: 502, body: "bad gateway"},
+		scriptedResponse{status: 200, body: okChat},
+	)
+	c := NewOpenAIClient("key", srv.URL, "m")
+	delays := recordSleeps(c.client)
+
+	got, err := c.ChatCompletion(context.Background(), []bot.LLMMessage{{Role: "user", Content: "hi"}})
+	if err != nil {
+		t.Fatalf("ChatCompletion error: %v", err)
+	}
+	if got != "hello" {
+		t.Errorf("ChatCompletion = %q, want %q", got, "hello")
+	}
+
+	reqs := srv.requests()
+	if len(reqs) != 3 {
+		t.Fatalf("server saw %d requests, want 3", len(reqs))
+	}
+	for i, body := range reqs {
+		if !strings.Contains(body, `"content":"hi"`) {
+			t.Errorf("request %d body not replayed: %s", i+1, body)
+		}
+	}
+	if len(*delays) != 2 {
+		t.Fatalf("slept %d times, want 2", len(*delays))
+	}
+	if (*delays)[1] < DefaultRetryPolicy.BaseDelay {
+		t.Errorf("second backoff %s shorter than base delay; want exponential growth", (*delays)[1])
+	}
+}
+
+func TestChatCompletion_HonoursRetryAfter(t *testing.T) {
+	srv := newScriptedServer(t,
+		scriptedResponse{status: 429, body: "slow down", retryAfter: "3"},
+		scriptedResponse{status: 200, body: okChat},
+	)
+	c := NewOpenAIClient("key", srv.URL, "m")
+	delays := recordSleeps(c.client)
+
+	if _, err := c.ChatCompletion(context.Background(), nil); err != nil {
+		t.Fatalf("ChatCompletion error: %v", err)
+	}
+	if len(*delays) != 1 || (*delays)[0] != 3*time.Second {
+		t.Errorf("delays = %v, want [3s]", *delays)
+	}
+}
+
+func TestChatCompletion_RetryAfterBeyondMaxDelayGivesUp(t *testing.T) {
+	srv := newScriptedServer(t,
+		scriptedResponse{status: 429, body: "come back tomorrow", retryAfter: "3600"},
+	)
+	c := NewOpenAIClient("key", srv.URL, "m")
+	recordSleeps(c.client)
+
+	_, err := c.ChatCompletion(context.Background(), nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 429 {
+		t.Fatalf("err = %v, want 429 APIError", err)
+	}
+	if n := len(srv.requests()); n != 1 {
+		t.Errorf("server saw %d requests, want 1", n)
+	}
+}
+
+func TestChatCompletion_DoesNotRetryClientErrors(t *testing.T) {
+	srv := newScriptedServer(t,
+		scriptedResponse{status: 400, body: `{"error":{"message":"bad request"}}`},
+	)
+	c := NewOpenAIClient("key", srv.URL, "m")
+	recordSleeps(c.client)
+
+	if _, err := c.ChatCompletion(context.Background(), nil); err == nil {
+		t.Fatal("expected error for 400 response")
+	}
+	if n := len(srv.requests()); n != 1 {
+		t.Errorf("server saw %d requests, want 1", n)
+	}
+}
+
+func TestChatCompletion_GivesUpAfterMaxAttempts(t *testing.T) {
+	srv := newScriptedServer(t, scriptedResponse{status: 500, body: "boom"})
+	c := NewOpenAIClient("key", srv.URL, "m")
+	recordSleeps(c.client)
+
+	_, err := c.ChatCompletion(context.Background(), nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 500 {
+		t.Fatalf("err = %v, want 500 APIError", err)
+	}
+	if n := len(srv.requests()); n != DefaultRetryPolicy.MaxAttempts {
+		t.Errorf("server saw %d requests, want %d", n, DefaultRetryPolicy.MaxAttempts)
+	}
+}
+
+func TestCircuitBreaker_OpensAndRecovers(t *testing.T) {
+	srv := newScriptedServer(t, scriptedResponse{status: 503, body: "down"})
+	c := NewOpenAIClient("key", srv.URL, "m")
+	recordSleeps(c.client)
+	c.client.policy.MaxAttempts = 1
+
+	now := time.Unix(1_700_000_000, 0)
+	c.client.breaker = NewCircuitBreaker(2, 30*time.Second)
+	c.client.breaker.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		c.ChatCompletion(context.Background(), nil)
+	}
+
+	_, err := c.ChatCompletion(context.Background(), nil)
+	if !errors.Is(err, ErrCircuitOpen) || !errors.Is(err, bot.ErrServiceUnavailable) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	if n := len(srv.requests()); n != 2 {
+		t.Fatalf("server saw %d requests while open, want 2", n)
+	}
+
+	// After the cooldown a single probe goes through; the server has recovered.
+	srv.mu.Lock()
+	srv.script = []scriptedResponse{{status: 200, body: okChat}}
+	srv.bodies = nil
+	srv.mu.Unlock()
+	now = now.Add(30 * time.Second)
+
+	if _, err := c.ChatCompletion(context.Background(), nil); err != nil {
+		t.Fatalf("probe request failed: %v", err)
+	}
+	if _, err := c.ChatCompletion(context.Background(), nil); err != nil {
+		t.Fatalf("request after recovery failed: %v", err)
+	}
+}
+
+func TestCircuitBreaker_FailedProbeReopens(t *testing.T) {
+	cb := NewCircuitBreaker(1, time.Second)
+	now := time.Unix(1_700_000_000, 0)
+	cb.now = func() time.Time { return now }
+
+	cb.Failure()
+	if cb.Allow() {
+		t.Fatal("Allow = true while open")
+	}
+
+	now = now.Add(time.Second)
+	if !cb.Allow() {
+		t.Fatal("probe not allowed after cooldown")
+	}
+	if cb.Allow() {
+		t.Fatal("second concurrent probe allowed while half-open")
+	}
+
+	cb.Failure()
+	if cb.Allow() {
+		t.Fatal("Allow = true after failed probe")
+	}
+}
+
+func TestTranscribe_RetriesTransientFailures(t *testing.T) {
+	srv := newScriptedServer(t,
+		scriptedResponse{status: 504, body: "timeout"},
+		scriptedResponse{status: 200, body: `{"text":"laser stop"}`},
+	)
+	c := NewSTTClient("key", srv.URL, "")
+	recordSleeps(c.client)
+
+	got, err := c.Transcribe(context.Background(), []byte("RIFF-fake-audio"))
+	if err != nil {
+		t.Fatalf("Transcribe error: %v", err)
+	}
+	if got != "laser stop" {
+		t.Errorf("Transcribe = %q, want %q", got, "laser stop")
+	}
+
+	reqs := srv.requests()
+	if len(reqs) != 2 {
+		t.Fatalf("server saw %d requests, want 2", len(reqs))
+	}
+	if !strings.Contains(reqs[1], "RIFF-fake-audio") {
+		t.Error("multipart body not replayed on retry")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"absent", http.Header{}, 0},
+		{"seconds", http.Header{"Retry-After": {"7"}}, 7 * time.Second},
+		{"milliseconds", http.Header{"Retry-After-Ms": {"250"}}, 250 * time.Millisecond},
+		{"garbage", http.Header{"Retry-After": {"soon"}}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.header); got != tt.want {
+				t.Errorf("parseRetryAfter = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+type observedRequest struct {
+	service, model string
+	err            error
+}
+
+type recordingObserver struct{ requests []observedRequest }
+
+func (o *recordingObserver) ObserveRequest(service, model string, _ time.Duration, err error) {
+	o.requests = append(o.requests, observedRequest{service, model, err})
+}
+
+func TestRequestObserver_SeesEachCallOnce(t *testing.T) {
+	srv := newScriptedServer(t,
+		scriptedResponse{status: 503, body: "unavailable"},
+		scriptedResponse{status: 200, body: okChat},
+		scriptedResponse{status: 400, body: "bad request"},
+	)
+	c := NewOpenAIClient("key", srv.URL, "gpt-test")
+	recordSleeps(c.client)
+	obs := &recordingObserver{}
+	c.SetRequestObserver(obs)
+
+	msgs := []bot.LLMMessage{{Role: "user", Content: "hi"}}
+	c.ChatCompletion(context.Background(), msgs)
+	c.ChatCompletion(context.Background(), msgs)
+
+	if len(obs.requests) != 2 {
+		t.Fatalf("observed %d requests, want 2 (retries aren't separate requests)", len(obs.requests))
+	}
+	if r := obs.requests[0]; r.service != "LLM" || r.model != "gpt-test" || r.err != nil {
+		t.Errorf("first request = %+v, want a success for gpt-test", r)
+	}
+	if obs.requests[1].err == nil {
+		t.Error("second request should be reported as failed")
+	}
+}
@@ -5,19 +5,22 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
 	"mime/multipart"
 	"net/http"
 	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/usage"
+	"github.com/adrock-miles/go-laserbeak/internal/infrastructure/audio"
 )
 
 // STTClient implements bot.STTService using the OpenAI-compatible Whisper API.
 type STTClient struct {
-	apiKey  string
-	baseURL string
-	model   string
-	client  *http.Client
+	apiKey   string
+	baseURL  string
+	model    string
+	client   *resilientClient
+	recorder usage.Recorder
 }
 
 // NewSTTClient creates a new speech-to-text client using the OpenAI Whisper API.
@@ -32,17 +35,27 @@ func NewSTTClient(apiKey, baseURL, model string) *STTClient {
 		apiKey:  apiKey,
 		baseURL: baseURL,
 		model:   model,
-		client: &http.Client{
+		client: newResilientClient("STT", &http.Client{
 			Timeout: 30 * time.Second,
 			Transport: &http.Transport{
 				MaxIdleConns:        20,
 				MaxIdleConnsPerHost: 10,
 				IdleConnTimeout:     90 * time.Second,
 			},
-		},
+		}),
 	}
 }
 
+// SetUsageRecorder reports the seconds of audio in each transcription to r.
+func (c *STTClient) SetUsageRecorder(r usage.Recorder) {
+	c.recorder = r
+}
+
+// SetRequestObserver reports the duration and outcome of each request to o.
+func (c *STTClient) SetRequestObserver(o RequestObserver) {
+	c.client.observer, c.client.model = o, c.model
+}
+
 type transcriptionResponse struct {
 	Text  string `json:"text"`
 	Error *struct {
@@ -70,7 +83,7 @@ func (c *STTClient) Transcribe(ctx context.Context, audioData []byte) (string, e
 	}
 
 	endpoint := c.baseURL + "/audio/transcriptions"
-	log.Printf("STT request: audio_size=%d bytes, model=%s, endpoint=%s", len(audioData), c.model, endpoint)
+	slog.DebugContext(ctx, "STT request", "audio_bytes", len(audioData), "model", c.model, "endpoint", endpoint)
 	start := time.Now()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &buf)
@@ -80,19 +93,9 @@ func (c *STTClient) Transcribe(ctx context.Context, audioData []byte) (string, e
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := c.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("STT API error (status %d): %s", resp.StatusCode, string(respBody))
+		return "", err
 	}
 
 	var transResp transcriptionResponse
@@ -104,6 +107,10 @@ func (c *STTClient) Transcribe(ctx context.Context, audioData []byte) (string, e
 		return "", fmt.Errorf("STT API error: %s", transResp.Error.Message)
 	}
 
-	log.Printf("STT response: duration=%s, text_length=%d", time.Since(start), len(transResp.Text))
+	if c.recorder != nil {
+		c.recorder.Record(ctx, usage.Record{Model: c.model, AudioSeconds: audio.WAVDuration(audioData).Seconds()})
+	}
+
+	slog.InfoContext(ctx, "STT response", "model", c.model, "duration", time.Since(start), "length", len(transResp.Text))
 	return transResp.Text, nil
 }
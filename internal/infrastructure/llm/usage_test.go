@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"github.com/adrock-miles/go-laserbeak/internal/domain/usage"
+)
+
+// usageLog records usage reported by the clients, with its attribution.
+type usageLog struct {
+	records []usage.Record
+	attrs   []usage.Attribution
+}
+
+func (l *usageLog) Record(ctx context.Context, r usage.Record) {
+	l.records = append(l.records, r)
+	l.attrs = append(l.attrs, usage.AttributionFrom(ctx))
+}
+
+func TestOpenAI_RecordsUsage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"choices":[{"message":{"content":"hi"}}],"usage":{"prompt_tokens":12,"completion_tokens":3,"total_tokens":15}}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	log := &usageLog{}
+	c := NewOpenAIClient("key", srv.URL, "gpt-test")
+	c.SetUsageRecorder(log)
+
+	ctx := usage.WithAttribution(context.Background(), usage.Attribution{GuildID: "g", UserID: "u"})
+	if _, err := c.ChatCompletion(ctx, []bot.LLMMessage{{Role: "user", Content: "hi"}}); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if len(log.records) != 1 {
+		t.Fatalf("records = %+v, want one", log.records)
+	}
+	if r := log.records[0]; r.Model != "gpt-test" || r.PromptTokens != 12 || r.CompletionTokens != 3 {
+		t.Errorf("record = %+v", r)
+	}
+	if log.attrs[0].UserID != "u" {
+		t.Errorf("attribution = %+v, want the request's", log.attrs[0])
+	}
+}
+
+func TestAnthropic_RecordsUsage(t *testing.T) {
+	_, srv := newFakeAnthropic(t, 200, `{"type":"message","content":[{"type":"text","text":"hi"}],"usage":{"input_tokens":20,"output_tokens":5}}`)
+	log := &usageLog{}
+	c := NewAnthropicClient("key", srv.URL, "claude-test", 0)
+	c.SetUsageRecorder(log)
+
+	if _, err := c.ChatCompletion(context.Background(), []bot.LLMMessage{{Role: "user", Content: "hi"}}); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if len(log.records) != 1 || log.records[0].PromptTokens != 20 || log.records[0].CompletionTokens != 5 {
+		t.Errorf("records = %+v", log.records)
+	}
+}
+
+func TestSTT_RecordsAudioSeconds(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"text":"laser stop"}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	log := &usageLog{}
+	c := NewSTTClient("key", srv.URL, "")
+	c.SetUsageRecorder(log)
+
+	// 1.5 seconds of 48kHz stereo 16-bit audio.
+	wav := make([]byte, 44)
+	copy(wav, "RIFF")
+	binary.LittleEndian.PutUint32(wav[28:32], 48000*2*2)
+	binary.LittleEndian.PutUint32(wav[40:44], 48000*2*2*3/2)
+
+	if _, err := c.Transcribe(context.Background(), wav); err != nil {
+		t.Fatalf("Transcribe: %v", err)
+	}
+	if len(log.records) != 1 || log.records[0].Model != "whisper-1" || log.records[0].AudioSeconds != 1.5 {
+		t.Errorf("records = %+v", log.records)
+	}
+}
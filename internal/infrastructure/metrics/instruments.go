@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"strings"
+	"time"
+)
+
+// Instruments are the bot's metrics. They implement the observer interfaces
+// of the Discord and LLM adapters.
+type Instruments struct {
+	chatRequests *Counter
+	apiDuration  *Histogram
+	apiErrors    *Counter
+	utterances   *Counter
+	commands     *Counter
+	rateLimited  *Counter
+}
+
+// NewInstruments registers the bot's metrics in r.
+func NewInstruments(r *Registry) *Instruments {
+	return &Instruments{
+		chatRequests: r.Counter("laserbeak_chat_requests_total",
+			"Chat requests answered, by result (ok, error, quota or unavailable).", "result"),
+		apiDuration: r.Histogram("laserbeak_api_request_duration_seconds",
+			"Duration of LLM and STT API requests, retries included.", DurationBuckets, "service", "model"),
+		apiErrors: r.Counter("laserbeak_api_request_errors_total",
+			"LLM and STT API requests that failed after retries.", "service", "model"),
+		utterances: r.Counter("laserbeak_voice_utterances_total",
+			"Utterances heard in voice, by whether they were emitted for transcription or why they were dropped.", "result"),
+		commands: r.Counter("laserbeak_commands_total",
+			"Play and stop commands dispatched, by command and where they came from.", "command", "source"),
+		rateLimited: r.Counter("laserbeak_rate_limited_total",
+			"Requests refused by the rate limiter, by kind.", "kind"),
+	}
+}
+
+// ChatRequest counts an answered chat request.
+func (m *Instruments) ChatRequest(result string) {
+	m.chatRequests.Inc(result)
+}
+
+// ObserveRequest records the duration and outcome of an API request.
+func (m *Instruments) ObserveRequest(service, model string, d time.Duration, err error) {
+	service = strings.ToLower(service)
+	m.apiDuration.Observe(d.Seconds(), service, model)
+	if err != nil {
+		m.apiErrors.Inc(service, model)
+	}
+}
+
+// VoiceUtterance counts an utterance emitted for transcription ("emitted")
+// or dropped, with the reason.
+func (m *Instruments) VoiceUtterance(result string) {
+	m.utterances.Inc(result)
+}
+
+// CommandDispatched counts a dispatched command.
+func (m *Instruments) CommandDispatched(command, source string) {
+	m.commands.Inc(command, source)
+}
+
+// RateLimited counts a request refused by the rate limiter.
+func (m *Instruments) RateLimited(kind string) {
+	m.rateLimited.Inc(kind)
+}
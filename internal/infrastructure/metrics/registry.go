@@ -0,0 +1,236 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry holds counters, histograms and gauges, and writes them in the
+// Prometheus text exposition format.
+type Registry struct {
+	mu       sync.Mutex
+	families []*family // in registration order
+}
+
+// family is every series of one metric.
+type family struct {
+	name, help, kind string
+	labels           []string
+	buckets          []float64 // histogram upper bounds, ascending
+
+	mu     sync.Mutex
+	series map[string]*series // label values joined by \xff -> series
+	gauges []gaugeFunc
+}
+
+type series struct {
+	values []string
+	value  float64  // counter total, or histogram sum
+	counts []uint64 // histogram counts per bucket, then +Inf
+}
+
+type gaugeFunc struct {
+	labels string // formatted label pairs
+	fn     func() float64
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// register adds f, or returns the family already registered under its name.
+// Registering a name again as another kind, or with other label names or
+// buckets, is a programming error and panics.
+func (r *Registry) register(f *family) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.families {
+		if existing.name != f.name {
+			continue
+		}
+		if existing.kind != f.kind || !slices.Equal(existing.labels, f.labels) || !slices.Equal(existing.buckets, f.buckets) {
+			panic(fmt.Sprintf("metrics: %s registered again as %s %v, was %s %v", f.name, f.kind, f.labels, existing.kind, existing.labels))
+		}
+		return existing
+	}
+	f.series = make(map[string]*series)
+	r.families = append(r.families, f)
+	return f
+}
+
+// Counter is a monotonically increasing count, per set of label values.
+type Counter struct{ f *family }
+
+// Counter registers a counter with the given label names.
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	return &Counter{r.register(&family{name: name, help: help, kind: "counter", labels: labels})}
+}
+
+// Inc adds one to the series with the given label values.
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds v to the series with the given label values.
+func (c *Counter) Add(v float64, values ...string) {
+	c.f.mu.Lock()
+	c.f.get(values).value += v
+	c.f.mu.Unlock()
+}
+
+// Histogram counts observations into buckets, per set of label values.
+type Histogram struct{ f *family }
+
+// DurationBuckets suit request latencies in seconds.
+var DurationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// Histogram registers a histogram with the given bucket upper bounds and
+// label names.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+	return &Histogram{r.register(&family{name: name, help: help, kind: "histogram", labels: labels, buckets: b})}
+}
+
+// Observe records v in the series with the given label values.
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+	s := h.f.get(values)
+	if s.counts == nil {
+		s.counts = make([]uint64, len(h.f.buckets)+1)
+	}
+	s.value += v
+	i := sort.SearchFloat64s(h.f.buckets, v) // first bound >= v
+	s.counts[i]++
+}
+
+// GaugeFunc registers a gauge read from fn when scraped. A name may be
+// registered again with other label pairs (name, value, ...) to add series.
+func (r *Registry) GaugeFunc(name, help string, fn func() float64, labelPairs ...string) {
+	f := r.register(&family{name: name, help: help, kind: "gauge"})
+	var names, values []string
+	for i := 0; i+1 < len(labelPairs); i += 2 {
+		names = append(names, labelPairs[i])
+		values = append(values, labelPairs[i+1])
+	}
+	f.mu.Lock()
+	f.gauges = append(f.gauges, gaugeFunc{labels: formatLabels(names, values, "", ""), fn: fn})
+	f.mu.Unlock()
+}
+
+// get returns the series for label values, creating it. Callers hold f.mu.
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		f.series[key] = s
+	}
+	return s
+}
+
+// ServeHTTP writes the metrics for a Prometheus scrape.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.Write(w)
+}
+
+// Write writes every metric in the text exposition format.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	families := append([]*family(nil), r.families...)
+	r.mu.Unlock()
+
+	var sb strings.Builder
+	for _, f := range families {
+		f.write(&sb)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (f *family) write(sb *strings.Builder) {
+	f.mu.Lock()
+	keys := make([]string, 0, len(f.series))
+	for k := range f.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	gauges := append([]gaugeFunc(nil), f.gauges...)
+	f.mu.Unlock()
+
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s %s\n", f.name, escapeHelp(f.help), f.name, f.kind)
+	for _, g := range gauges {
+		fmt.Fprintf(sb, "%s%s %s\n", f.name, g.labels, formatValue(g.fn()))
+	}
+
+	for _, k := range keys {
+		f.mu.Lock()
+		s := *f.series[k]
+		s.counts = append([]uint64(nil), s.counts...)
+		f.mu.Unlock()
+
+		if f.kind != "histogram" {
+			fmt.Fprintf(sb, "%s%s %s\n", f.name, formatLabels(f.labels, s.values, "", ""), formatValue(s.value))
+			continue
+		}
+		var total uint64
+		for i, c := range s.counts {
+			total += c
+			le := "+Inf"
+			if i < len(f.buckets) {
+				le = formatValue(f.buckets[i])
+			}
+			fmt.Fprintf(sb, "%s_bucket%s %d\n", f.name, formatLabels(f.labels, s.values, "le", le), total)
+		}
+		labels := formatLabels(f.labels, s.values, "", "")
+		fmt.Fprintf(sb, "%s_sum%s %s\n%s_count%s %d\n", f.name, labels, formatValue(s.value), f.name, labels, total)
+	}
+}
+
+// formatLabels formats label pairs as {a="1",b="2"}, adding extra=extraValue
+// when extra is set, or returns "" when there are none.
+func formatLabels(names, values []string, extra, extraValue string) string {
+	if extra != "" {
+		names = append(append([]string(nil), names...), extra)
+		values = append(append([]string(nil), values...), extraValue)
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, n := range names {
+		pairs[i] = n + `="` + escapeLabel(values[i]) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("test_commands_total", "Commands.", "command")
+	h := r.Histogram("test_duration_seconds", "Durations.", []float64{1, 0.1}, "service")
+	r.GaugeFunc("test_options", "Options.", func() float64 { return 3 }, "source", "api")
+
+	c.Inc("!play")
+	c.Add(2, `say "hi"`)
+	h.Observe(0.05, "llm")
+	h.Observe(0.5, "llm")
+	h.Observe(2, "llm")
+
+	var sb strings.Builder
+	if err := r.Write(&sb); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP test_commands_total Commands.
+# TYPE test_commands_total counter
+test_commands_total{command="!play"} 1
+test_commands_total{command="say \"hi\""} 2
+# HELP test_duration_seconds Durations.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{service="llm",le="0.1"} 1
+test_duration_seconds_bucket{service="llm",le="1"} 2
+test_duration_seconds_bucket{service="llm",le="+Inf"} 3
+test_duration_seconds_sum{service="llm"} 2.55
+test_duration_seconds_count{service="llm"} 3
+# HELP test_options Options.
+# TYPE test_options gauge
+test_options{source="api"} 3
+`
+	if sb.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", sb.String(), want)
+	}
+}
+
+func TestRegistry_RegisterAgain(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("test_total", "Tests.", "result")
+	if again := r.Counter("test_total", "Tests.", "result"); again.f != c.f {
+		t.Error("registering the same counter again made a new family")
+	}
+
+	for name, register := range map[string]func(){
+		"other labels": func() { r.Counter("test_total", "Tests.", "kind") },
+		"other kind":   func() { r.Histogram("test_total", "Tests.", DurationBuckets, "result") },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: register did not panic", name)
+				}
+			}()
+			register()
+		}()
+	}
+}
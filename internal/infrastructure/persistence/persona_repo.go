@@ -0,0 +1,126 @@
+package persistence
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/persona"
+)
+
+// FilePersonaRepo implements persona.Repository, keeping personas in memory
+// and writing them to a JSON file on every change. An empty path keeps them
+// in memory only.
+type FilePersonaRepo struct {
+	path string
+
+	mu    sync.RWMutex
+	store map[string]persona.Persona
+}
+
+// personaRecord is the on-disk form of a persona, keyed by channel ID.
+type personaRecord struct {
+	Preset    string    `json:"preset,omitempty"`
+	Prompt    string    `json:"prompt"`
+	SetBy     string    `json:"setBy,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// NewFilePersonaRepo creates a persona repository backed by the file at path,
+// loading any personas already saved there. A missing file is not an error.
+func NewFilePersonaRepo(path string) (*FilePersonaRepo, error) {
+	r := &FilePersonaRepo{
+		path:  path,
+		store: make(map[string]persona.Persona),
+	}
+	if path == "" {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return r, nil
+		}
+		return nil, fmt.Errorf("read personas: %w", err)
+	}
+
+	var records map[string]personaRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("parse personas %s: %w", path, err)
+	}
+	for channelID, rec := range records {
+		r.store[channelID] = persona.Persona{
+			ChannelID: channelID,
+			Preset:    rec.Preset,
+			Prompt:    rec.Prompt,
+			SetBy:     rec.SetBy,
+			UpdatedAt: rec.UpdatedAt,
+		}
+	}
+	return r, nil
+}
+
+func (r *FilePersonaRepo) FindByChannel(channelID string) (persona.Persona, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.store[channelID]
+	return p, ok
+}
+
+func (r *FilePersonaRepo) Save(p persona.Persona) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	prev, existed := r.store[p.ChannelID]
+	r.store[p.ChannelID] = p
+	if err := r.flush(); err != nil {
+		if existed {
+			r.store[p.ChannelID] = prev
+		} else {
+			delete(r.store, p.ChannelID)
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *FilePersonaRepo) Delete(channelID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	prev, existed := r.store[channelID]
+	if !existed {
+		return nil
+	}
+	delete(r.store, channelID)
+	if err := r.flush(); err != nil {
+		r.store[channelID] = prev
+		return err
+	}
+	return nil
+}
+
+// flush writes the store to disk. Callers must hold r.mu.
+func (r *FilePersonaRepo) flush() error {
+	if r.path == "" {
+		return nil
+	}
+	records := make(map[string]personaRecord, len(r.store))
+	for channelID, p := range r.store {
+		records[channelID] = personaRecord{
+			Preset:    p.Preset,
+			Prompt:    p.Prompt,
+			SetBy:     p.SetBy,
+			UpdatedAt: p.UpdatedAt,
+		}
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal personas: %w", err)
+	}
+	if err := WriteFileAtomic(r.path, data); err != nil {
+		return fmt.Errorf("save personas: %w", err)
+	}
+	return nil
+}
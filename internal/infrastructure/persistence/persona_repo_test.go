@@ -0,0 +1,56 @@
+package persistence
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/persona"
+)
+
+func TestFilePersonaRepo_PersistsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "personas.json")
+
+	repo, err := NewFilePersonaRepo(path)
+	if err != nil {
+		t.Fatalf("NewFilePersonaRepo: %v", err)
+	}
+	when := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := repo.Save(persona.Persona{ChannelID: "a", Preset: "pirate", Prompt: "Arr.", SetBy: "u1", UpdatedAt: when}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := repo.Save(persona.Persona{ChannelID: "b", Prompt: "Be brief."}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := repo.Delete("b"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	reloaded, err := NewFilePersonaRepo(path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	got, ok := reloaded.FindByChannel("a")
+	if !ok || got.Preset != "pirate" || got.Prompt != "Arr." || got.SetBy != "u1" || !got.UpdatedAt.Equal(when) {
+		t.Errorf("reloaded persona = %+v, %v", got, ok)
+	}
+	if _, ok := reloaded.FindByChannel("b"); ok {
+		t.Error("deleted persona came back after reload")
+	}
+
+	// No temp files are left behind.
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only personas.json", len(entries))
+	}
+}
+
+func TestFilePersonaRepo_RejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "personas.json")
+	os.WriteFile(path, []byte("{not json"), 0o644)
+
+	if _, err := NewFilePersonaRepo(path); err == nil {
+		t.Error("expected error for a corrupt personas file")
+	}
+}
@@ -0,0 +1,113 @@
+package persistence
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/playstats"
+)
+
+// FilePlayLog implements playstats.Repository as an append-only file with one
+// JSON play per line. An empty path keeps plays in memory only.
+type FilePlayLog struct {
+	path string
+
+	mu    sync.Mutex
+	file  *os.File
+	plays []playstats.Play // used when there is no file
+}
+
+// playRecord is the on-disk form of a play.
+type playRecord struct {
+	Time      time.Time       `json:"time"`
+	GuildID   string          `json:"guildId,omitempty"`
+	ChannelID string          `json:"channelId,omitempty"`
+	UserID    string          `json:"userId,omitempty"`
+	Option    string          `json:"option"`
+	Match     playstats.Match `json:"match"`
+}
+
+// NewFilePlayLog opens the play log at path for appending, creating it if
+// needed.
+func NewFilePlayLog(path string) (*FilePlayLog, error) {
+	l := &FilePlayLog{path: path}
+	if path == "" {
+		return l, nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open play log: %w", err)
+	}
+	l.file = f
+	return l, nil
+}
+
+// Append writes a play as one line.
+func (l *FilePlayLog) Append(p playstats.Play) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		l.plays = append(l.plays, p)
+		return nil
+	}
+
+	data, err := json.Marshal(playRecord(p))
+	if err != nil {
+		return fmt.Errorf("marshal play: %w", err)
+	}
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write play: %w", err)
+	}
+	return nil
+}
+
+// All returns every play in the log, oldest first. Lines that can't be
+// parsed are skipped.
+func (l *FilePlayLog) All() ([]playstats.Play, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.path == "" {
+		return append([]playstats.Play(nil), l.plays...), nil
+	}
+
+	f, err := os.Open(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open play log: %w", err)
+	}
+	defer f.Close()
+
+	var out []playstats.Play
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec playRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil || rec.Option == "" {
+			continue
+		}
+		out = append(out, playstats.Play(rec))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read play log: %w", err)
+	}
+	return out, nil
+}
+
+// Close closes the log file.
+func (l *FilePlayLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
@@ -0,0 +1,48 @@
+package persistence
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/playstats"
+)
+
+func TestFilePlayLog_AppendAndAll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plays.jsonl")
+	play := playstats.Play{
+		Time:    time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC),
+		GuildID: "g1",
+		UserID:  "u1",
+		Option:  "airhorn",
+		Match:   playstats.MatchHistory,
+	}
+
+	l, err := NewFilePlayLog(path)
+	if err != nil {
+		t.Fatalf("NewFilePlayLog: %v", err)
+	}
+	if err := l.Append(play); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	l.Close()
+
+	// A line cut short by a crash is skipped.
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString(`{"time":"2026-03-01T21:00:00Z","opt`)
+	f.Close()
+
+	reopened, err := NewFilePlayLog(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	got, err := reopened.All()
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	if len(got) != 1 || got[0] != play {
+		t.Errorf("All = %+v, want [%+v]", got, play)
+	}
+}
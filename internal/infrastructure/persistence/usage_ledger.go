@@ -0,0 +1,131 @@
+package persistence
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/usage"
+)
+
+// FileUsageLedger implements usage.Repository as an append-only file with one
+// JSON record per line. An empty path keeps records in memory only.
+type FileUsageLedger struct {
+	path string
+
+	mu      sync.Mutex
+	file    *os.File
+	records []usage.Record // used when there is no file
+}
+
+// usageRecord is the on-disk form of a usage record.
+type usageRecord struct {
+	Time             time.Time `json:"time"`
+	GuildID          string    `json:"guildId,omitempty"`
+	ChannelID        string    `json:"channelId,omitempty"`
+	UserID           string    `json:"userId,omitempty"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"promptTokens,omitempty"`
+	CompletionTokens int       `json:"completionTokens,omitempty"`
+	AudioSeconds     float64   `json:"audioSeconds,omitempty"`
+	Cost             float64   `json:"cost,omitempty"`
+}
+
+// NewFileUsageLedger opens the ledger at path for appending, creating it if
+// needed.
+func NewFileUsageLedger(path string) (*FileUsageLedger, error) {
+	l := &FileUsageLedger{path: path}
+	if path == "" {
+		return l, nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open usage ledger: %w", err)
+	}
+	l.file = f
+	return l, nil
+}
+
+// Append writes a record as one line, so a crash can at worst lose the line
+// being written.
+func (l *FileUsageLedger) Append(r usage.Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		l.records = append(l.records, r)
+		return nil
+	}
+
+	data, err := json.Marshal(usageRecord(r))
+	if err != nil {
+		return fmt.Errorf("marshal usage record: %w", err)
+	}
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write usage record: %w", err)
+	}
+	return nil
+}
+
+// Since returns the records at or after t, oldest first. Lines that can't
+// be parsed, such as one cut short by a crash, are skipped.
+func (l *FileUsageLedger) Since(t time.Time) ([]usage.Record, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.path == "" {
+		var out []usage.Record
+		for _, r := range l.records {
+			if !r.Time.Before(t) {
+				out = append(out, r)
+			}
+		}
+		return out, nil
+	}
+	return ReadUsageLedger(l.path, t)
+}
+
+// Close closes the ledger file.
+func (l *FileUsageLedger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// ReadUsageLedger reads the records at or after t from a ledger file. A
+// missing file holds no records.
+func ReadUsageLedger(path string, t time.Time) ([]usage.Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open usage ledger: %w", err)
+	}
+	defer f.Close()
+
+	var out []usage.Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var rec usageRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		if !rec.Time.Before(t) {
+			out = append(out, usage.Record(rec))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read usage ledger: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,59 @@
+package persistence
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/usage"
+)
+
+func TestFileUsageLedger_AppendAndSince(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.jsonl")
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	ledger, err := NewFileUsageLedger(path)
+	if err != nil {
+		t.Fatalf("NewFileUsageLedger: %v", err)
+	}
+	old := usage.Record{Time: day.Add(-time.Hour), UserID: "u1", Model: "gpt-4o", PromptTokens: 10}
+	recent := usage.Record{Time: day.Add(time.Hour), GuildID: "g1", UserID: "u2", Model: "whisper-1", AudioSeconds: 4.5, Cost: 0.00045}
+	for _, r := range []usage.Record{old, recent} {
+		if err := ledger.Append(r); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	ledger.Close()
+
+	// A line cut short by a crash is skipped.
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString(`{"time":"2026-03-01T05:00:00Z","mod`)
+	f.Close()
+
+	reopened, err := NewFileUsageLedger(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	got, err := reopened.Since(day)
+	if err != nil {
+		t.Fatalf("Since: %v", err)
+	}
+	if len(got) != 1 || got[0].UserID != "u2" || got[0].AudioSeconds != 4.5 || !got[0].Time.Equal(recent.Time) {
+		t.Errorf("Since = %+v, want only the recent record", got)
+	}
+}
+
+func TestFileUsageLedger_InMemory(t *testing.T) {
+	ledger, err := NewFileUsageLedger("")
+	if err != nil {
+		t.Fatalf("NewFileUsageLedger: %v", err)
+	}
+	now := time.Now()
+	ledger.Append(usage.Record{Time: now, Model: "m"})
+	got, _ := ledger.Since(now.Add(-time.Minute))
+	if len(got) != 1 {
+		t.Errorf("Since = %+v, want one record", got)
+	}
+}
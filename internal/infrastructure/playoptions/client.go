@@ -2,10 +2,9 @@ package playoptions
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"sync"
 	"time"
@@ -13,16 +12,26 @@ import (
 	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
 )
 
+// maxResponseBytes caps the size of a play options response.
+const maxResponseBytes = 8 << 20
+
 // Client fetches play options from an external bot's HTTP API and caches them.
+// Refreshes are conditional, so an unchanged list isn't downloaded again.
 type Client struct {
 	apiURL   string
 	cacheTTL time.Duration
+	timeout  time.Duration
 	client   *http.Client
-
-	mu        sync.RWMutex
-	cache     []bot.PlayOption
-	cacheTime time.Time
-	stopCh    chan struct{}
+	headers  map[string]string
+	format   Format
+	selector string
+
+	mu           sync.RWMutex
+	cache        []bot.PlayOption
+	cacheTime    time.Time
+	etag         string // validators of the cached response
+	lastModified string
+	stopCh       chan struct{}
 }
 
 // NewClient creates a new play options client with caching.
@@ -30,18 +39,42 @@ func NewClient(apiURL string, cacheTTL time.Duration) *Client {
 	return &Client{
 		apiURL:   apiURL,
 		cacheTTL: cacheTTL,
-		client:   &http.Client{Timeout: 10 * time.Second},
+		timeout:  10 * time.Second,
+		client:   &http.Client{},
 		stopCh:   make(chan struct{}),
 	}
 }
 
+// SetTimeout sets how long a refresh may take.
+func (c *Client) SetTimeout(d time.Duration) {
+	if d > 0 {
+		c.timeout = d
+	}
+}
+
+// SetHeaders sets headers sent with every request, such as Authorization.
+func (c *Client) SetHeaders(headers map[string]string) {
+	c.headers = headers
+}
+
+// SetFormat sets the response format; FormatAuto detects it.
+func (c *Client) SetFormat(f Format) {
+	c.format = f
+}
+
+// SetSelector sets the path to the names in a nested JSON or YAML response,
+// such as "data.sounds[*].title", or the column to read from a CSV response.
+func (c *Client) SetSelector(selector string) {
+	c.selector = selector
+}
+
 // Start begins background cache refresh. Call Stop to clean up.
 func (c *Client) Start() {
 	// Initial fetch
 	if err := c.refresh(); err != nil {
-		log.Printf("initial play options fetch failed (url=%s): %v", c.apiURL, err)
+		slog.Error("initial play options fetch failed", "url", c.apiURL, "err", err)
 	} else {
-		log.Printf("loaded %d play options from %s", len(c.cache), c.apiURL)
+		slog.Info("loaded play options", "url", c.apiURL, "options", c.Len())
 	}
 
 	go c.refreshLoop()
@@ -63,8 +96,8 @@ func (c *Client) GetOptions(ctx context.Context) ([]bot.PlayOption, error) {
 		if err := c.refresh(); err != nil {
 			// Return stale cache if available
 			if options != nil {
-				log.Printf("play options refresh failed (url=%s), using stale cache (%d items, age %s): %v",
-					c.apiURL, len(options), time.Since(cacheTime).Truncate(time.Second), err)
+				slog.WarnContext(ctx, "play options refresh failed, using stale cache", "url", c.apiURL,
+					"options", len(options), "age", time.Since(cacheTime).Truncate(time.Second), "err", err)
 				return options, nil
 			}
 			return nil, fmt.Errorf("play options fetch failed (url=%s) and no cached data available: %w", c.apiURL, err)
@@ -77,6 +110,30 @@ func (c *Client) GetOptions(ctx context.Context) ([]bot.PlayOption, error) {
 	return options, nil
 }
 
+// Len returns the number of cached options.
+func (c *Client) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.cache)
+}
+
+// CacheAge returns how long ago the cache was last fetched or revalidated,
+// and false if it never has been.
+func (c *Client) CacheAge() (time.Duration, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.cacheTime.IsZero() {
+		return 0, false
+	}
+	return time.Since(c.cacheTime), true
+}
+
+// Loaded reports whether options have been fetched at least once.
+func (c *Client) Loaded() bool {
+	_, ok := c.CacheAge()
+	return ok
+}
+
 func (c *Client) refreshLoop() {
 	ticker := time.NewTicker(c.cacheTTL)
 	defer ticker.Stop()
@@ -87,25 +144,36 @@ func (c *Client) refreshLoop() {
 			return
 		case <-ticker.C:
 			if err := c.refresh(); err != nil {
-				log.Printf("play options refresh failed (url=%s): %v", c.apiURL, err)
+				slog.Warn("play options refresh failed", "url", c.apiURL, "err", err)
 			} else {
-				c.mu.RLock()
-				count := len(c.cache)
-				c.mu.RUnlock()
-				log.Printf("refreshed play options: %d items", count)
+				slog.Debug("refreshed play options", "url", c.apiURL, "options", c.Len())
 			}
 		}
 	}
 }
 
 func (c *Client) refresh() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiURL, nil)
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	c.mu.RLock()
+	etag, lastModified, cached := c.etag, c.lastModified, c.cache != nil
+	c.mu.RUnlock()
+	if cached {
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lastModified != "" {
+			req.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
 
 	resp, err := c.client.Do(req)
 	if err != nil {
@@ -113,33 +181,42 @@ func (c *Client) refresh() error {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && cached {
+		c.mu.Lock()
+		c.cacheTime = time.Now()
+		c.mu.Unlock()
+		return nil
+	}
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
 		return fmt.Errorf("play options API returned status %d from %s: %s", resp.StatusCode, c.apiURL, string(body))
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes+1))
 	if err != nil {
 		return fmt.Errorf("read response: %w", err)
 	}
+	if len(body) > maxResponseBytes {
+		return fmt.Errorf("play options response from %s is larger than %d MB", c.apiURL, maxResponseBytes>>20)
+	}
 
-	// Try parsing as array of strings first, then as array of objects with "name" field
-	var options []bot.PlayOption
-
-	var names []string
-	if err := json.Unmarshal(body, &names); err == nil {
-		for _, name := range names {
-			options = append(options, bot.PlayOption{Name: name})
-		}
-	} else {
-		if err := json.Unmarshal(body, &options); err != nil {
-			return fmt.Errorf("parse play options: %w", err)
-		}
+	format := c.format
+	if format == FormatAuto {
+		format = detectFormat(resp.Header.Get("Content-Type"), c.apiURL, body)
+	}
+	options, err := decodeOptions(body, format, c.selector)
+	if err != nil {
+		return fmt.Errorf("parse play options (%s): %w", format, err)
 	}
 
 	c.mu.Lock()
 	c.cache = options
+	if c.cache == nil {
+		c.cache = []bot.PlayOption{} // an empty list is still a cached response
+	}
 	c.cacheTime = time.Now()
+	c.etag = resp.Header.Get("ETag")
+	c.lastModified = resp.Header.Get("Last-Modified")
 	c.mu.Unlock()
 
 	return nil
@@ -0,0 +1,122 @@
+package playoptions
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_ConditionalRefresh(t *testing.T) {
+	const etag = `"v1"`
+	const modified = "Mon, 19 Oct 2026 10:00:00 GMT"
+	var full, notModified atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag && r.Header.Get("If-Modified-Since") == modified {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full.Add(1)
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", modified)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`["airhorn","rimshot"]`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, time.Hour)
+	for i := 0; i < 3; i++ {
+		if err := c.refresh(); err != nil {
+			t.Fatalf("refresh %d: %v", i, err)
+		}
+	}
+	if full.Load() != 1 || notModified.Load() != 2 {
+		t.Errorf("full = %d, not modified = %d; want 1 and 2", full.Load(), notModified.Load())
+	}
+
+	options, err := c.GetOptions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(names(options), ","); got != "airhorn,rimshot" {
+		t.Errorf("options = %s", got)
+	}
+}
+
+func TestClient_HeadersSelectorAndFormat(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("X-Guild") != "42" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("sounds:\n  - {title: airhorn}\n  - {title: rimshot}\n"))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, time.Hour)
+	if err := c.refresh(); err == nil || !strings.Contains(err.Error(), "status 401") {
+		t.Fatalf("refresh without auth: err = %v, want status 401", err)
+	}
+
+	c.SetHeaders(map[string]string{"Authorization": "Bearer secret", "X-Guild": "42"})
+	c.SetFormat(FormatYAML)
+	c.SetSelector("sounds[*].title")
+	if err := c.refresh(); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	options, _ := c.GetOptions(context.Background())
+	if got := strings.Join(names(options), ","); got != "airhorn,rimshot" {
+		t.Errorf("options = %s", got)
+	}
+}
+
+func TestClient_DetectsCSV(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write([]byte("name,duration\nairhorn,2\nrimshot,1\n"))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, time.Hour)
+	if err := c.refresh(); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	options, _ := c.GetOptions(context.Background())
+	if got := strings.Join(names(options), ","); got != "airhorn,rimshot" {
+		t.Errorf("options = %s", got)
+	}
+}
+
+func TestClient_KeepsCacheOnBadPayload(t *testing.T) {
+	var broken atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if broken.Load() {
+			w.Write([]byte(`{"oops":`))
+			return
+		}
+		w.Write([]byte(`["airhorn"]`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, time.Nanosecond)
+	if err := c.refresh(); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	broken.Store(true)
+
+	// The cache is stale, so GetOptions refreshes, fails and falls back.
+	options, err := c.GetOptions(context.Background())
+	if err != nil {
+		t.Fatalf("GetOptions: %v", err)
+	}
+	if got := strings.Join(names(options), ","); got != "airhorn" {
+		t.Errorf("options = %s", got)
+	}
+}
@@ -2,32 +2,169 @@ package playoptions
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
 )
 
-// Composite merges play options from multiple sources into a single PlayOptionsService.
+// DefaultSourceTimeout is how long a source may take when its Timeout is unset.
+const DefaultSourceTimeout = 3 * time.Second
+
+// Source is a named play options source for a Composite.
+type Source struct {
+	Name     string
+	Priority int           // higher wins when sources share an option
+	Timeout  time.Duration // how long to wait for the source; 0 means DefaultSourceTimeout
+	Service  bot.PlayOptionsService
+}
+
+// Composite merges play options from multiple sources into a single
+// PlayOptionsService. Options with the same normalized name are merged: the
+// name and source come from the highest-priority source, and aliases and tags
+// are combined from all of them.
 type Composite struct {
-	sources []bot.PlayOptionsService
+	sources []Source
+
+	mu   sync.Mutex
+	last map[string][]bot.PlayOption // each source's latest options, by name
 }
 
-// NewComposite creates a Composite from one or more PlayOptionsService sources.
-func NewComposite(sources ...bot.PlayOptionsService) *Composite {
-	return &Composite{sources: sources}
+// NewComposite creates a Composite from one or more sources.
+func NewComposite(sources ...Source) *Composite {
+	sorted := append([]Source(nil), sources...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Priority > sorted[j].Priority })
+	return &Composite{sources: sorted, last: make(map[string][]bot.PlayOption)}
 }
 
-// GetOptions returns the merged options from all sources.
-// Errors from individual sources are logged but don't fail the whole call.
+// GetOptions fetches every source concurrently and returns the merged
+// options, highest-priority source first. A source that fails or takes longer
+// than its timeout is logged and its previous options are used, so one slow
+// source can't stall the others.
 func (c *Composite) GetOptions(ctx context.Context) ([]bot.PlayOption, error) {
-	var all []bot.PlayOption
+	results := make([][]bot.PlayOption, len(c.sources))
+	var wg sync.WaitGroup
+	for i, src := range c.sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.fetch(ctx, src)
+		}()
+	}
+	wg.Wait()
+	return mergeOptions(c.sources, results), nil
+}
+
+// loader is implemented by sources that load their options in the background.
+type loader interface {
+	Loaded() bool
+}
+
+// Ready returns an error naming the first source that hasn't loaded its
+// options yet.
+func (c *Composite) Ready(context.Context) error {
 	for _, src := range c.sources {
-		opts, err := src.GetOptions(ctx)
-		if err != nil {
-			log.Printf("play options source error: %v", err)
+		if l, ok := src.Service.(loader); ok && !l.Loaded() {
+			return fmt.Errorf("play options source %s hasn't loaded", src.Name)
+		}
+	}
+	return nil
+}
+
+// fetch returns a source's options, or its last good options if it fails or
+// times out.
+func (c *Composite) fetch(ctx context.Context, src Source) []bot.PlayOption {
+	timeout := src.Timeout
+	if timeout <= 0 {
+		timeout = DefaultSourceTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+
+	type result struct {
+		options []bot.PlayOption
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer cancel()
+		options, err := src.Service.GetOptions(ctx)
+		if err == nil {
+			// Keep late results too, for the next call.
+			c.mu.Lock()
+			c.last[src.Name] = options
+			c.mu.Unlock()
+		}
+		done <- result{options, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err == nil {
+			return r.options
+		}
+		slog.WarnContext(ctx, "play options source failed", "source", src.Name, "err", r.err)
+	case <-ctx.Done():
+		slog.WarnContext(ctx, "play options source timed out", "source", src.Name, "timeout", timeout)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.last[src.Name]
+}
+
+// mergeOptions merges the options of sources sorted by priority, keyed by
+// normalized name. An option's file comes from the first source that has one.
+func mergeOptions(sources []Source, results [][]bot.PlayOption) []bot.PlayOption {
+	var merged []bot.PlayOption
+	index := make(map[string]int)
+	for i, options := range results {
+		for _, opt := range options {
+			key := bot.NormalizeName(opt.Name)
+			if key == "" {
+				continue
+			}
+			if j, ok := index[key]; ok {
+				merged[j].Aliases = appendUnique(merged[j].Aliases, opt.Aliases...)
+				merged[j].Tags = appendUnique(merged[j].Tags, opt.Tags...)
+				if merged[j].File == "" {
+					merged[j].File = opt.File
+				}
+				continue
+			}
+			index[key] = len(merged)
+			merged = append(merged, bot.PlayOption{
+				Name:    opt.Name,
+				Aliases: appendUnique(nil, opt.Aliases...),
+				Tags:    appendUnique(nil, opt.Tags...),
+				Source:  sources[i].Name,
+				File:    opt.File,
+			})
+		}
+	}
+	return merged
+}
+
+// appendUnique appends the values not already in list, ignoring case.
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
 			continue
 		}
-		all = append(all, opts...)
+		dup := false
+		for _, existing := range list {
+			if strings.EqualFold(existing, v) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			list = append(list, v)
+		}
 	}
-	return all, nil
+	return list
 }
@@ -0,0 +1,96 @@
+package playoptions
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+)
+
+type staticSource struct {
+	options []bot.PlayOption
+	err     error
+	delay   time.Duration
+}
+
+func (s *staticSource) GetOptions(ctx context.Context) ([]bot.PlayOption, error) {
+	time.Sleep(s.delay)
+	return s.options, s.err
+}
+
+func TestComposite_MergesByPriority(t *testing.T) {
+	file := &staticSource{options: []bot.PlayOption{
+		{Name: "Air Horn", Aliases: []string{"horn"}, Tags: []string{"loud"}},
+		{Name: "rimshot"},
+	}}
+	api := &staticSource{options: []bot.PlayOption{
+		{Name: "sad trombone"},
+		{Name: "air_horn", Aliases: []string{"HORN", "foghorn"}, Tags: []string{"meme"}, File: "horn.ogg"},
+	}}
+	c := NewComposite(
+		Source{Name: "api", Priority: 0, Service: api},
+		Source{Name: "file", Priority: 10, Service: file},
+	)
+
+	got, err := c.GetOptions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []bot.PlayOption{
+		{Name: "Air Horn", Aliases: []string{"horn", "foghorn"}, Tags: []string{"loud", "meme"}, Source: "file", File: "horn.ogg"},
+		{Name: "rimshot", Source: "file"},
+		{Name: "sad trombone", Source: "api"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("options =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestComposite_SlowOrFailingSourceUsesLastOptions(t *testing.T) {
+	api := &staticSource{options: []bot.PlayOption{{Name: "airhorn"}}}
+	broken := &staticSource{err: errors.New("boom")}
+	c := NewComposite(
+		Source{Name: "api", Timeout: 50 * time.Millisecond, Service: api},
+		Source{Name: "broken", Service: broken},
+	)
+
+	if got, _ := c.GetOptions(context.Background()); len(got) != 1 {
+		t.Fatalf("options = %+v, want airhorn", got)
+	}
+
+	api.delay = time.Second
+	start := time.Now()
+	got, _ := c.GetOptions(context.Background())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("GetOptions took %s, want it to give up on the slow source", elapsed)
+	}
+	if len(got) != 1 || got[0].Name != "airhorn" || got[0].Source != "api" {
+		t.Errorf("options = %+v, want the last airhorn from api", got)
+	}
+}
+
+type loadingSource struct {
+	staticSource
+	loaded bool
+}
+
+func (s *loadingSource) Loaded() bool { return s.loaded }
+
+func TestComposite_Ready(t *testing.T) {
+	api := &loadingSource{}
+	c := NewComposite(
+		Source{Name: "file", Service: &staticSource{}},
+		Source{Name: "api", Service: api},
+	)
+	if err := c.Ready(context.Background()); err == nil || !strings.Contains(err.Error(), "api") {
+		t.Errorf("Ready = %v, want the api source not loaded", err)
+	}
+	api.loaded = true
+	if err := c.Ready(context.Background()); err != nil {
+		t.Errorf("Ready = %v, want nil", err)
+	}
+}
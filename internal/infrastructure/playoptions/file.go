@@ -3,15 +3,40 @@ package playoptions
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"github.com/adrock-miles/go-laserbeak/internal/infrastructure/persistence"
+	"github.com/fsnotify/fsnotify"
 )
 
-// FileSource reads play options from a local JSON file on each call.
-// Supports both string arrays (["a","b"]) and object arrays ([{"name":"a"}]).
+// reloadDelay lets an editor finish writing before the file is re-read.
+const reloadDelay = 250 * time.Millisecond
+
+// FileSource serves play options from a local JSON file, reloading it when it
+// changes. Supports both string arrays (["a","b"]) and object arrays
+// ([{"name":"a","aliases":["b"],"tags":["c"]}]). An invalid file is rejected
+// and the last good options are kept. It implements bot.PlayOptionsStore,
+// rewriting the file atomically on every change.
 type FileSource struct {
 	path string
+
+	writeMu sync.Mutex // serializes AddOption and RemoveOption
+	mu      sync.RWMutex
+	options []bot.PlayOption
+	loaded  bool // the file has been read successfully, or found missing
+
+	watcher *fsnotify.Watcher
+	stopCh  chan struct{}
+	done    chan struct{}
 }
 
 // NewFileSource creates a FileSource that reads from the given path.
@@ -19,27 +44,260 @@ func NewFileSource(path string) *FileSource {
 	return &FileSource{path: path}
 }
 
-// GetOptions reads and parses the JSON file, returning the options.
+// Start loads the file and watches it for changes. Call Stop to clean up.
+func (f *FileSource) Start() {
+	f.reload()
+
+	// Watch the directory rather than the file, so files replaced by
+	// rename (as most editors save) or created later are picked up.
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		slog.Warn("play options file will not be reloaded", "file", f.path, "err", err)
+		return
+	}
+	if err := watcher.Add(filepath.Dir(f.path)); err != nil {
+		watcher.Close()
+		slog.Warn("play options file will not be reloaded", "file", f.path, "err", err)
+		return
+	}
+	f.watcher = watcher
+	f.stopCh = make(chan struct{})
+	f.done = make(chan struct{})
+	go f.watchLoop()
+}
+
+// Stop ends the file watcher.
+func (f *FileSource) Stop() {
+	if f.watcher == nil {
+		return
+	}
+	close(f.stopCh)
+	<-f.done
+	f.watcher.Close()
+}
+
+// GetOptions returns the options from the last valid version of the file.
 // Returns an empty list (not an error) if the file doesn't exist.
 func (f *FileSource) GetOptions(ctx context.Context) ([]bot.PlayOption, error) {
-	data, err := os.ReadFile(f.path)
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.options, nil
+}
+
+// AddOption adds an option and saves the file.
+func (f *FileSource) AddOption(ctx context.Context, opt bot.PlayOption) error {
+	f.writeMu.Lock()
+	defer f.writeMu.Unlock()
+
+	opt.Name = strings.TrimSpace(opt.Name)
+	if bot.NormalizeName(opt.Name) == "" {
+		return errors.New("play option name is empty")
+	}
+	current, _ := f.GetOptions(ctx)
+	for _, existing := range current {
+		if bot.NormalizeName(existing.Name) == bot.NormalizeName(opt.Name) {
+			return fmt.Errorf("%w: %q", bot.ErrOptionExists, existing.Name)
+		}
+	}
+	return f.save(append(append([]bot.PlayOption(nil), current...), opt))
+}
+
+// RemoveOption removes the option with the given name and saves the file.
+func (f *FileSource) RemoveOption(ctx context.Context, name string) error {
+	f.writeMu.Lock()
+	defer f.writeMu.Unlock()
+
+	current, _ := f.GetOptions(ctx)
+	for i, existing := range current {
+		if bot.NormalizeName(existing.Name) == bot.NormalizeName(name) {
+			options := append(append([]bot.PlayOption(nil), current[:i]...), current[i+1:]...)
+			return f.save(options)
+		}
+	}
+	return fmt.Errorf("%w: %q", bot.ErrOptionNotFound, name)
+}
+
+// fileOption is the on-disk form of an option with aliases, tags or a file.
+type fileOption struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	File    string   `json:"file,omitempty"`
+}
+
+// save writes options to the file and serves them. Options are written as
+// plain names unless one of them has aliases, tags or a file.
+func (f *FileSource) save(options []bot.PlayOption) error {
+	var doc any
+	names := make([]string, len(options))
+	objects := make([]fileOption, len(options))
+	plain := true
+	for i, o := range options {
+		names[i] = o.Name
+		objects[i] = fileOption{Name: o.Name, Aliases: o.Aliases, Tags: o.Tags, File: o.File}
+		plain = plain && len(o.Aliases) == 0 && len(o.Tags) == 0 && o.File == ""
+	}
+	doc = objects
+	if plain {
+		doc = names
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
+		return fmt.Errorf("marshal play options: %w", err)
+	}
+	if err := persistence.WriteFileAtomic(f.path, append(data, '\n')); err != nil {
+		return fmt.Errorf("save play options: %w", err)
+	}
+
+	f.mu.Lock()
+	f.options = options
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *FileSource) watchLoop() {
+	defer close(f.done)
+
+	name := filepath.Clean(f.path)
+	timer := time.NewTimer(reloadDelay)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-f.stopCh:
+			return
+		case event, ok := <-f.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) == name && !event.Has(fsnotify.Chmod) {
+				timer.Reset(reloadDelay)
+			}
+		case err, ok := <-f.watcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Warn("play options file watcher error", "file", f.path, "err", err)
+		case <-timer.C:
+			f.reload()
 		}
-		return nil, err
 	}
+}
 
+// reload re-reads the file, replacing the options if it is valid.
+func (f *FileSource) reload() {
 	var options []bot.PlayOption
-	if err := json.Unmarshal(data, &options); err != nil {
-		// Try as array of strings
-		var names []string
-		if err := json.Unmarshal(data, &names); err != nil {
-			return nil, err
+	data, err := os.ReadFile(f.path)
+	switch {
+	case os.IsNotExist(err):
+		// No file means no options.
+	case err != nil:
+		slog.Error("failed to read play options file, keeping the last options", "file", f.path, "options", f.Len(), "err", err)
+		return
+	default:
+		if options, err = parseOptions(data); err != nil {
+			slog.Error("rejected play options file, keeping the last options", "file", f.path, "options", f.Len(), "err", err)
+			return
 		}
+	}
+
+	f.mu.Lock()
+	previous := f.options
+	f.options = options
+	f.loaded = true
+	f.mu.Unlock()
+
+	added, removed := diffOptions(previous, options)
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	attrs := []any{"file", f.path, "options", len(options)}
+	if len(added) > 0 {
+		attrs = append(attrs, "added", listNames(added))
+	}
+	if len(removed) > 0 {
+		attrs = append(attrs, "removed", listNames(removed))
+	}
+	slog.Info("loaded play options", attrs...)
+}
+
+// Len returns the number of options being served.
+func (f *FileSource) Len() int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return len(f.options)
+}
+
+// Loaded reports whether the file has been read since the source started.
+func (f *FileSource) Loaded() bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.loaded
+}
+
+// parseOptions parses and validates a play options file. Names are trimmed;
+// empty names and duplicates (by normalized name) are errors.
+func parseOptions(data []byte) ([]bot.PlayOption, error) {
+	var options []bot.PlayOption
+	var names []string
+	if err := json.Unmarshal(data, &names); err == nil {
 		for _, name := range names {
 			options = append(options, bot.PlayOption{Name: name})
 		}
+	} else if err := json.Unmarshal(data, &options); err != nil {
+		return nil, fmt.Errorf("invalid JSON: want an array of names or of {\"name\": ...} objects: %w", err)
+	}
+
+	var problems []string
+	seen := make(map[string]int, len(options))
+	for i := range options {
+		options[i].Name = strings.TrimSpace(options[i].Name)
+		name := options[i].Name
+		key := bot.NormalizeName(name)
+		if key == "" {
+			problems = append(problems, fmt.Sprintf("entry %d has no name", i+1))
+			continue
+		}
+		if first, ok := seen[key]; ok {
+			problems = append(problems, fmt.Sprintf("entry %d duplicates %q from entry %d", i+1, name, first))
+			continue
+		}
+		seen[key] = i + 1
+	}
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "; "))
 	}
 	return options, nil
 }
+
+// diffOptions returns the names added and removed between two option lists,
+// sorted.
+func diffOptions(before, after []bot.PlayOption) (added, removed []string) {
+	old := make(map[string]bool, len(before))
+	for _, o := range before {
+		old[o.Name] = true
+	}
+	for _, o := range after {
+		if !old[o.Name] {
+			added = append(added, o.Name)
+		}
+		delete(old, o.Name)
+	}
+	for name := range old {
+		removed = append(removed, name)
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// listNames formats names for a log line, eliding all but the first few.
+func listNames(names []string) string {
+	const shown = 10
+	if len(names) <= shown {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:shown], ", "), len(names)-shown)
+}
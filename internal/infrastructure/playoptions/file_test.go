@@ -0,0 +1,202 @@
+package playoptions
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+)
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []string
+		wantErr string
+	}{
+		{name: "strings", data: `["airhorn", " rimshot "]`, want: []string{"airhorn", "rimshot"}},
+		{name: "objects", data: `[{"name":"airhorn"},{"name":"rimshot"}]`, want: []string{"airhorn", "rimshot"}},
+		{name: "empty list", data: `[]`},
+		{name: "bad JSON", data: `["airhorn",`, wantErr: "invalid JSON"},
+		{name: "empty file", data: ``, wantErr: "invalid JSON"},
+		{name: "not a list", data: `{"name":"airhorn"}`, wantErr: "invalid JSON"},
+		{name: "empty name", data: `["airhorn", "  "]`, wantErr: "entry 2 has no name"},
+		{name: "missing name", data: `[{"title":"airhorn"}]`, wantErr: "entry 1 has no name"},
+		{name: "duplicate", data: `["airhorn", "rimshot", "Airhorn"]`, wantErr: `entry 3 duplicates "Airhorn" from entry 1`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, err := parseOptions([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := names(options); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("names = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffOptions(t *testing.T) {
+	before := []bot.PlayOption{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	after := []bot.PlayOption{{Name: "c"}, {Name: "e"}, {Name: "d"}, {Name: "a"}}
+
+	added, removed := diffOptions(before, after)
+	if !reflect.DeepEqual(added, []string{"d", "e"}) {
+		t.Errorf("added = %v", added)
+	}
+	if !reflect.DeepEqual(removed, []string{"b"}) {
+		t.Errorf("removed = %v", removed)
+	}
+}
+
+func TestFileSource_KeepsLastGoodOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "play_options.json")
+	writeFile(t, path, `["airhorn", "rimshot"]`)
+
+	f := NewFileSource(path)
+	f.reload()
+	assertOptions(t, f, "airhorn", "rimshot")
+
+	writeFile(t, path, `["airhorn", "airhorn"]`)
+	f.reload()
+	assertOptions(t, f, "airhorn", "rimshot")
+
+	writeFile(t, path, `["sad trombone"]`)
+	f.reload()
+	assertOptions(t, f, "sad trombone")
+
+	os.Remove(path)
+	f.reload()
+	assertOptions(t, f)
+}
+
+func TestFileSource_ReloadsOnChange(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "play_options.json")
+	writeFile(t, path, `["airhorn"]`)
+
+	f := NewFileSource(path)
+	f.Start()
+	defer f.Stop()
+	assertOptions(t, f, "airhorn")
+
+	writeFile(t, path, `["airhorn", "rimshot"]`)
+	waitForOptions(t, f, "airhorn", "rimshot")
+
+	// Editors often save by writing a temporary file and renaming it.
+	tmp := filepath.Join(dir, "play_options.json.tmp")
+	writeFile(t, tmp, `["rimshot"]`)
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	waitForOptions(t, f, "rimshot")
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func names(options []bot.PlayOption) []string {
+	var out []string
+	for _, o := range options {
+		out = append(out, o.Name)
+	}
+	return out
+}
+
+func assertOptions(t *testing.T, f *FileSource, want ...string) {
+	t.Helper()
+	options, err := f.GetOptions(context.Background())
+	if err != nil {
+		t.Fatalf("GetOptions: %v", err)
+	}
+	if got := names(options); !reflect.DeepEqual(got, want) {
+		t.Errorf("options = %v, want %v", got, want)
+	}
+}
+
+func waitForOptions(t *testing.T, f *FileSource, want ...string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		options, _ := f.GetOptions(context.Background())
+		got := names(options)
+		if reflect.DeepEqual(got, want) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("options = %v, want %v", got, want)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestParseOptions_AliasesAndTags(t *testing.T) {
+	_, err := parseOptions([]byte(`[{"name":"air horn"},{"name":"Air-Horn"}]`))
+	if err == nil || !strings.Contains(err.Error(), `entry 2 duplicates "Air-Horn"`) {
+		t.Fatalf("err = %v, want a duplicate of the normalized name", err)
+	}
+
+	options, err := parseOptions([]byte(`[{"name":"airhorn","aliases":["fog horn"],"tags":["loud"],"file":"horns/air.ogg"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []bot.PlayOption{{Name: "airhorn", Aliases: []string{"fog horn"}, Tags: []string{"loud"}, File: "horns/air.ogg"}}
+	if !reflect.DeepEqual(options, want) {
+		t.Errorf("options = %+v, want %+v", options, want)
+	}
+}
+
+func TestFileSource_AddAndRemove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "play_options.json")
+	writeFile(t, path, `["rimshot"]`)
+	f := NewFileSource(path)
+	f.reload()
+	ctx := context.Background()
+
+	if err := f.AddOption(ctx, bot.PlayOption{Name: "sad trombone"}); err != nil {
+		t.Fatalf("AddOption: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "[\n  \"rimshot\",\n  \"sad trombone\"\n]\n" {
+		t.Errorf("file = %q, want plain names", data)
+	}
+
+	if err := f.AddOption(ctx, bot.PlayOption{Name: "airhorn", Aliases: []string{"fog horn"}}); err != nil {
+		t.Fatalf("AddOption: %v", err)
+	}
+	if err := f.AddOption(ctx, bot.PlayOption{Name: "Sad_Trombone"}); !errors.Is(err, bot.ErrOptionExists) {
+		t.Errorf("AddOption duplicate: err = %v, want ErrOptionExists", err)
+	}
+	if err := f.RemoveOption(ctx, "RIMSHOT"); err != nil {
+		t.Fatalf("RemoveOption: %v", err)
+	}
+	if err := f.RemoveOption(ctx, "rimshot"); !errors.Is(err, bot.ErrOptionNotFound) {
+		t.Errorf("RemoveOption missing: err = %v, want ErrOptionNotFound", err)
+	}
+	assertOptions(t, f, "sad trombone", "airhorn")
+
+	// The saved file reloads to the same options, aliases included.
+	reloaded := NewFileSource(path)
+	reloaded.reload()
+	options, _ := reloaded.GetOptions(ctx)
+	want := []bot.PlayOption{{Name: "sad trombone"}, {Name: "airhorn", Aliases: []string{"fog horn"}}}
+	if !reflect.DeepEqual(options, want) {
+		t.Errorf("reloaded options = %+v, want %+v", options, want)
+	}
+}
@@ -0,0 +1,320 @@
+package playoptions
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"go.yaml.in/yaml/v3"
+)
+
+// Format is the encoding of a play options payload.
+type Format string
+
+const (
+	FormatAuto Format = ""     // detect from the content type, URL and body
+	FormatJSON Format = "json" // array of names or of {"name": ...} objects
+	FormatYAML Format = "yaml" // same shapes as JSON
+	FormatCSV  Format = "csv"  // one option per row
+	FormatText Format = "text" // one option per line
+)
+
+// ParseFormat parses a format name; "" and "auto" detect the format.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case "", "auto":
+		return FormatAuto, nil
+	case FormatJSON, FormatYAML, FormatCSV, FormatText:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown play options format %q (want auto, json, yaml, csv or text)", s)
+}
+
+// detectFormat guesses a payload's format from its content type, then the
+// URL's extension, then its first byte.
+func detectFormat(contentType, url string, body []byte) Format {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return FormatJSON
+	case strings.HasSuffix(mediaType, "yaml"):
+		return FormatYAML
+	case mediaType == "text/csv":
+		return FormatCSV
+	}
+
+	switch strings.ToLower(path.Ext(strings.SplitN(url, "?", 2)[0])) {
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".csv":
+		return FormatCSV
+	case ".txt":
+		return FormatText
+	}
+
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+		return FormatJSON
+	}
+	return FormatText
+}
+
+// decodeOptions decodes a payload into play options. For JSON and YAML the
+// selector picks the names out of a nested document (see selectValues); for
+// CSV it names the column to use.
+func decodeOptions(body []byte, format Format, selector string) ([]bot.PlayOption, error) {
+	var decoded []bot.PlayOption
+	var err error
+	switch format {
+	case FormatJSON:
+		var doc any
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return nil, fmt.Errorf("parse JSON: %w", err)
+		}
+		decoded, err = documentOptions(doc, selector)
+	case FormatYAML:
+		var doc any
+		if err := yaml.Unmarshal(body, &doc); err != nil {
+			return nil, fmt.Errorf("parse YAML: %w", err)
+		}
+		decoded, err = documentOptions(doc, selector)
+	case FormatCSV:
+		var names []string
+		names, err = csvNames(body, selector)
+		decoded = namedOptions(names)
+	case FormatText:
+		decoded = namedOptions(textNames(body))
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Skip blanks and repeats rather than rejecting a list we don't control.
+	var options []bot.PlayOption
+	seen := make(map[string]bool, len(decoded))
+	for _, opt := range decoded {
+		opt.Name = strings.TrimSpace(opt.Name)
+		key := bot.NormalizeName(opt.Name)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		options = append(options, opt)
+	}
+	return options, nil
+}
+
+func namedOptions(names []string) []bot.PlayOption {
+	options := make([]bot.PlayOption, len(names))
+	for i, name := range names {
+		options[i] = bot.PlayOption{Name: name}
+	}
+	return options
+}
+
+// documentOptions returns the options selected from a decoded JSON or YAML
+// document. Each selected value may be a name, an object with a "name" field
+// (and optionally "aliases" and "tags" lists), or a list of either.
+func documentOptions(doc any, selector string) ([]bot.PlayOption, error) {
+	values, err := selectValues(doc, selector)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 1 {
+		if list, ok := values[0].([]any); ok {
+			values = list
+		}
+	}
+
+	options := make([]bot.PlayOption, 0, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case string:
+			options = append(options, bot.PlayOption{Name: v})
+		case float64, int, bool:
+			options = append(options, bot.PlayOption{Name: fmt.Sprint(v)})
+		case map[string]any:
+			name, ok := v["name"].(string)
+			if !ok {
+				return nil, fmt.Errorf("item %d has no \"name\" field; set a selector such as [*].title", i+1)
+			}
+			options = append(options, bot.PlayOption{Name: name, Aliases: stringList(v["aliases"]), Tags: stringList(v["tags"])})
+		case nil:
+		default:
+			return nil, fmt.Errorf("item %d is a %T, not a name", i+1, v)
+		}
+	}
+	return options, nil
+}
+
+// stringList returns the strings in a decoded list, ignoring anything else.
+func stringList(v any) []string {
+	list, _ := v.([]any)
+	var out []string
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// selectValues evaluates a JSONPath-style selector against a document. A
+// selector is a dot-separated path of object keys, each optionally followed
+// by [n] to index a list or [*] to take every element, as in
+// "data.sounds[*].title". A leading "$." is allowed; an empty selector
+// selects the whole document.
+func selectValues(doc any, selector string) ([]any, error) {
+	selector = strings.TrimPrefix(strings.TrimPrefix(selector, "$"), ".")
+	values := []any{doc}
+	if selector == "" {
+		return values, nil
+	}
+
+	for _, part := range strings.Split(selector, ".") {
+		key, indexes, err := splitSegment(part)
+		if err != nil {
+			return nil, fmt.Errorf("selector %q: %w", selector, err)
+		}
+
+		if key != "" {
+			next := make([]any, 0, len(values))
+			for _, v := range values {
+				obj, ok := v.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("selector %q: %q is applied to a %s, not an object", selector, key, kind(v))
+				}
+				if child, ok := obj[key]; ok {
+					next = append(next, child)
+				}
+			}
+			values = next
+		}
+
+		for _, index := range indexes {
+			next := make([]any, 0, len(values))
+			for _, v := range values {
+				list, ok := v.([]any)
+				if !ok {
+					return nil, fmt.Errorf("selector %q: [%s] is applied to a %s, not a list", selector, index, kind(v))
+				}
+				if index == "*" {
+					next = append(next, list...)
+					continue
+				}
+				n, _ := strconv.Atoi(index)
+				if n < len(list) {
+					next = append(next, list[n])
+				}
+			}
+			values = next
+		}
+	}
+	return values, nil
+}
+
+// splitSegment splits a selector segment such as "sounds[*]" into its key
+// and indexes.
+func splitSegment(part string) (key string, indexes []string, err error) {
+	key, rest, hasIndex := strings.Cut(part, "[")
+	if key == "" && !hasIndex {
+		return "", nil, fmt.Errorf("empty segment")
+	}
+	for hasIndex {
+		index, after, ok := strings.Cut(rest, "]")
+		if !ok {
+			return "", nil, fmt.Errorf("unclosed [ in %q", part)
+		}
+		if n, err := strconv.Atoi(index); index != "*" && (err != nil || n < 0) {
+			return "", nil, fmt.Errorf("bad index [%s] in %q (want a number or *)", index, part)
+		}
+		indexes = append(indexes, index)
+		if after == "" {
+			break
+		}
+		if !strings.HasPrefix(after, "[") {
+			return "", nil, fmt.Errorf("unexpected %q in %q", after, part)
+		}
+		rest = after[1:]
+	}
+	return key, indexes, nil
+}
+
+func kind(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "list"
+	case nil:
+		return "null"
+	default:
+		return "value"
+	}
+}
+
+// csvNames returns one name per CSV row. If the first row is a header with
+// the selected column (default "name"), that column is used; otherwise every
+// row is data and the first column is used.
+func csvNames(body []byte, column string) ([]string, error) {
+	r := csv.NewReader(bytes.NewReader(body))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parse CSV: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	if column == "" {
+		column = "name"
+	}
+	col := -1
+	for i, cell := range rows[0] {
+		if strings.EqualFold(strings.TrimSpace(cell), column) {
+			col = i
+			break
+		}
+	}
+	if col >= 0 {
+		rows = rows[1:]
+	} else if column != "name" {
+		return nil, fmt.Errorf("CSV has no %q column", column)
+	} else {
+		col = 0
+	}
+
+	names := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if col < len(row) {
+			names = append(names, row[col])
+		}
+	}
+	return names, nil
+}
+
+// textNames returns one name per line, skipping blank lines and # comments.
+func textNames(body []byte) []string {
+	var names []string
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, line)
+	}
+	return names
+}
@@ -0,0 +1,93 @@
+package playoptions
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		selector string
+		body     string
+		want     []string
+		wantErr  string
+	}{
+		{name: "JSON strings", format: FormatJSON, body: `["airhorn","rimshot"]`, want: []string{"airhorn", "rimshot"}},
+		{name: "JSON objects", format: FormatJSON, body: `[{"name":"airhorn"},{"name":"rimshot","id":2}]`, want: []string{"airhorn", "rimshot"}},
+		{
+			name:     "JSON nested",
+			format:   FormatJSON,
+			selector: "data.sounds[*].title",
+			body:     `{"data":{"sounds":[{"title":"airhorn"},{"title":"rimshot"}]}}`,
+			want:     []string{"airhorn", "rimshot"},
+		},
+		{name: "JSON nested list", format: FormatJSON, selector: "$.sounds", body: `{"sounds":["airhorn"]}`, want: []string{"airhorn"}},
+		{name: "JSON index", format: FormatJSON, selector: "pages[1][*]", body: `{"pages":[["a"],["b","c"]]}`, want: []string{"b", "c"}},
+		{name: "skips blanks and repeats", format: FormatJSON, body: `["airhorn"," ","Airhorn","rimshot"]`, want: []string{"airhorn", "rimshot"}},
+		{name: "JSON object without selector", format: FormatJSON, body: `{"sounds":["airhorn"]}`, wantErr: `no "name" field`},
+		{name: "JSON selector on a list", format: FormatJSON, selector: "sounds", body: `["airhorn"]`, wantErr: "not an object"},
+		{name: "bad JSON", format: FormatJSON, body: `["airhorn"`, wantErr: "parse JSON"},
+		{
+			name:     "YAML",
+			format:   FormatYAML,
+			selector: "sounds[*].title",
+			body:     "sounds:\n  - title: airhorn\n  - title: rimshot\n",
+			want:     []string{"airhorn", "rimshot"},
+		},
+		{name: "YAML list", format: FormatYAML, body: "- airhorn\n- 42\n", want: []string{"airhorn", "42"}},
+		{name: "CSV with header", format: FormatCSV, body: "id,name\n1,airhorn\n2,rimshot\n", want: []string{"airhorn", "rimshot"}},
+		{name: "CSV without header", format: FormatCSV, body: "airhorn,loud\nrimshot,short\n", want: []string{"airhorn", "rimshot"}},
+		{name: "CSV column", format: FormatCSV, selector: "Title", body: "id,title\n1,airhorn\n", want: []string{"airhorn"}},
+		{name: "CSV missing column", format: FormatCSV, selector: "title", body: "id,name\n1,airhorn\n", wantErr: `no "title" column`},
+		{name: "text", format: FormatText, body: "# sounds\nairhorn\r\n\n  rimshot  \n", want: []string{"airhorn", "rimshot"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, err := decodeOptions([]byte(tt.body), tt.format, tt.selector)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := names(options); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("names = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectValues_BadSelector(t *testing.T) {
+	for _, selector := range []string{"a..b", "a[", "a[x]", "a[-1]", "a[0]b"} {
+		if _, err := selectValues(map[string]any{}, selector); err == nil {
+			t.Errorf("selector %q: expected an error", selector)
+		}
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		contentType, url, body string
+		want                   Format
+	}{
+		{"application/json; charset=utf-8", "http://x/options", "", FormatJSON},
+		{"application/vnd.api+json", "http://x/options", "", FormatJSON},
+		{"application/yaml", "http://x/options", "", FormatYAML},
+		{"text/csv", "http://x/options", "", FormatCSV},
+		{"text/plain", "http://x/options.yml?v=1", "", FormatYAML},
+		{"", "http://x/options.csv", "", FormatCSV},
+		{"text/plain", "http://x/options", " [\"a\"]", FormatJSON},
+		{"text/plain", "http://x/options", "airhorn\nrimshot", FormatText},
+	}
+	for _, tt := range tests {
+		if got := detectFormat(tt.contentType, tt.url, []byte(tt.body)); got != tt.want {
+			t.Errorf("detectFormat(%q, %q) = %q, want %q", tt.contentType, tt.url, got, tt.want)
+		}
+	}
+}
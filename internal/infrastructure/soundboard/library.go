@@ -0,0 +1,210 @@
+package soundboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+	"github.com/adrock-miles/go-laserbeak/internal/infrastructure/audio"
+)
+
+// frameDuration is the packet length Discord expects: it sends one packet
+// every 20ms.
+const frameDuration = 20 * time.Millisecond
+
+// ErrSoundNotFound is returned when no sound file plays the requested name.
+var ErrSoundNotFound = errors.New("no sound file")
+
+// Library maps play options to Ogg Opus files in a directory. An option plays
+// its File if set, or the file named like the option or one of its aliases.
+// Files are parsed when first played and kept until they change.
+type Library struct {
+	dir     string
+	options bot.PlayOptionsService // may be nil
+
+	mu    sync.Mutex
+	cache map[string]cachedSound // path -> parsed file
+}
+
+type cachedSound struct {
+	modTime time.Time
+	size    int64
+	sound   *audio.OggOpus
+}
+
+// soundFile is a playable file in the directory.
+type soundFile struct {
+	name string // file name without its extension
+	path string
+}
+
+// NewLibrary creates a Library for the sound files in dir.
+func NewLibrary(dir string) *Library {
+	return &Library{dir: dir, cache: make(map[string]cachedSound)}
+}
+
+// SetPlayOptions sets the options whose names, aliases and files are used to
+// find sounds. It may include the library itself as a source.
+func (l *Library) SetPlayOptions(options bot.PlayOptionsService) {
+	l.options = options
+}
+
+// GetOptions implements bot.PlayOptionsService with an option per sound file,
+// so files play without being listed elsewhere.
+func (l *Library) GetOptions(_ context.Context) ([]bot.PlayOption, error) {
+	files, err := l.files()
+	if err != nil {
+		return nil, err
+	}
+	options := make([]bot.PlayOption, len(files))
+	for i, f := range files {
+		options[i] = bot.PlayOption{Name: f.name, File: filepath.Base(f.path)}
+	}
+	return options, nil
+}
+
+// Load returns the sound for name and the name of the option it plays.
+func (l *Library) Load(ctx context.Context, name string) (*audio.OggOpus, string, error) {
+	files, err := l.files()
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, opt := range l.playOptions(ctx) {
+		if !opt.Matches(name) {
+			continue
+		}
+		if path, ok := l.optionFile(opt, files); ok {
+			sound, err := l.parse(path)
+			return sound, opt.Name, err
+		}
+		return nil, "", fmt.Errorf("%w for %q", ErrSoundNotFound, opt.Name)
+	}
+
+	for _, f := range files {
+		if bot.NormalizeName(f.name) == bot.NormalizeName(name) {
+			sound, err := l.parse(f.path)
+			return sound, f.name, err
+		}
+	}
+	return nil, "", fmt.Errorf("%w for %q", ErrSoundNotFound, name)
+}
+
+// Random returns the name of a random option that has a sound file.
+func (l *Library) Random(ctx context.Context) (string, error) {
+	files, err := l.files()
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for _, opt := range l.playOptions(ctx) {
+		if _, ok := l.optionFile(opt, files); ok {
+			names = append(names, opt.Name)
+		}
+	}
+	if len(names) == 0 {
+		for _, f := range files {
+			names = append(names, f.name)
+		}
+	}
+	if len(names) == 0 {
+		return "", ErrSoundNotFound
+	}
+	return names[rand.IntN(len(names))], nil
+}
+
+func (l *Library) playOptions(ctx context.Context) []bot.PlayOption {
+	if l.options == nil {
+		return nil
+	}
+	options, err := l.options.GetOptions(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to get play options for sounds", "err", err)
+	}
+	return options
+}
+
+// optionFile returns the path of the file an option plays. A File outside
+// the directory is never played.
+func (l *Library) optionFile(opt bot.PlayOption, files []soundFile) (string, bool) {
+	if opt.File != "" {
+		rel := filepath.FromSlash(opt.File)
+		if !filepath.IsLocal(rel) {
+			return "", false
+		}
+		return filepath.Join(l.dir, rel), true
+	}
+	for _, name := range append([]string{opt.Name}, opt.Aliases...) {
+		for _, f := range files {
+			if bot.NormalizeName(f.name) == bot.NormalizeName(name) {
+				return f.path, true
+			}
+		}
+	}
+	return "", false
+}
+
+// files lists the .ogg and .opus files in the directory, sorted by name.
+func (l *Library) files() ([]soundFile, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, fmt.Errorf("read sounds directory: %w", err)
+	}
+	var files []soundFile
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || (ext != ".ogg" && ext != ".opus") {
+			continue
+		}
+		files = append(files, soundFile{
+			name: strings.TrimSuffix(e.Name(), filepath.Ext(e.Name())),
+			path: filepath.Join(l.dir, e.Name()),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+	return files, nil
+}
+
+// parse reads and demuxes a sound file, reusing the last parse while the file
+// is unchanged.
+func (l *Library) parse(path string) (*audio.OggOpus, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("open sound: %w", err)
+	}
+
+	l.mu.Lock()
+	cached, ok := l.cache[path]
+	l.mu.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.sound, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read sound: %w", err)
+	}
+	sound, err := audio.ParseOggOpus(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filepath.Base(path), err)
+	}
+	for _, p := range sound.Packets {
+		if d := audio.PacketDuration(p); d != frameDuration {
+			return nil, fmt.Errorf("%s has %s Opus frames; re-encode it with 20ms frames (opusenc --framesize 20)", filepath.Base(path), d)
+		}
+	}
+
+	l.mu.Lock()
+	l.cache[path] = cachedSound{modTime: info.ModTime(), size: info.Size(), sound: sound}
+	l.mu.Unlock()
+	return sound, nil
+}
@@ -0,0 +1,121 @@
+package soundboard
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/bot"
+)
+
+// oggOpus builds a minimal Ogg Opus file with one page per packet.
+func oggOpus(packets ...[]byte) []byte {
+	head := []byte("OpusHead\x01\x02\x38\x01\x80\xbb\x00\x00\x00\x00\x00")
+	tags := []byte("OpusTags\x00\x00\x00\x00\x00\x00\x00\x00")
+	var file []byte
+	for _, p := range append([][]byte{head, tags}, packets...) {
+		page := make([]byte, 27, 28+len(p))
+		copy(page, "OggS")
+		binary.LittleEndian.PutUint32(page[14:18], 1)
+		page[26] = 1
+		page = append(page, byte(len(p)))
+		file = append(file, append(page, p...)...)
+	}
+	return file
+}
+
+// celt20ms is a CELT packet header for one 20ms frame.
+const celt20ms = 31 << 3
+
+type staticOptions []bot.PlayOption
+
+func (o staticOptions) GetOptions(context.Context) ([]bot.PlayOption, error) { return o, nil }
+
+func writeSound(t *testing.T, dir, name string, packets ...[]byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), oggOpus(packets...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLibrary_Load(t *testing.T) {
+	dir := t.TempDir()
+	writeSound(t, dir, "Air-Horn.ogg", []byte{celt20ms, 1}, []byte{celt20ms, 2})
+	writeSound(t, dir, "trombone.opus", []byte{celt20ms, 3})
+	writeSound(t, dir, "clips/rim.ogg", []byte{celt20ms, 4})
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a sound"), 0o644)
+
+	lib := NewLibrary(dir)
+	lib.SetPlayOptions(staticOptions{
+		{Name: "sad trombone", Aliases: []string{"trombone"}},
+		{Name: "rimshot", File: "clips/rim.ogg"},
+		{Name: "bruh"},
+	})
+
+	tests := []struct {
+		query, name string
+		packet      byte
+	}{
+		{"air horn", "Air-Horn", 1}, // a file without an option
+		{"Sad Trombone", "sad trombone", 3},
+		{"rimshot", "rimshot", 4},
+	}
+	for _, tt := range tests {
+		sound, name, err := lib.Load(context.Background(), tt.query)
+		if err != nil {
+			t.Errorf("Load(%q): %v", tt.query, err)
+			continue
+		}
+		if name != tt.name || sound.Packets[0][1] != tt.packet {
+			t.Errorf("Load(%q) = %q packet %d, want %q packet %d", tt.query, name, sound.Packets[0][1], tt.name, tt.packet)
+		}
+	}
+
+	if _, _, err := lib.Load(context.Background(), "bruh"); !errors.Is(err, ErrSoundNotFound) {
+		t.Errorf("option without a file: err = %v, want ErrSoundNotFound", err)
+	}
+
+	options, err := lib.GetOptions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(options) != 2 || options[0].Name != "Air-Horn" || options[1].File != "trombone.opus" {
+		t.Errorf("options = %+v, want one per file in the directory", options)
+	}
+}
+
+func TestLibrary_IgnoresFilesOutsideDirectory(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "sounds")
+	writeSound(t, root, "secret.ogg", []byte{celt20ms, 1})
+	writeSound(t, dir, "horn.ogg", []byte{celt20ms, 2})
+
+	lib := NewLibrary(dir)
+	lib.SetPlayOptions(staticOptions{
+		{Name: "up", File: "../secret.ogg"},
+		{Name: "abs", File: filepath.Join(root, "secret.ogg")},
+	})
+
+	for _, query := range []string{"up", "abs"} {
+		if _, _, err := lib.Load(context.Background(), query); !errors.Is(err, ErrSoundNotFound) {
+			t.Errorf("Load(%q): err = %v, want ErrSoundNotFound", query, err)
+		}
+	}
+}
+
+func TestLibrary_RejectsOtherFrameSizes(t *testing.T) {
+	dir := t.TempDir()
+	writeSound(t, dir, "long.ogg", []byte{3 << 3, 0}) // SILK 60ms
+
+	_, _, err := NewLibrary(dir).Load(context.Background(), "long")
+	if err == nil || !strings.Contains(err.Error(), "20ms") {
+		t.Errorf("err = %v, want a frame size error", err)
+	}
+}
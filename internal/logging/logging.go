@@ -0,0 +1,104 @@
+// Package logging configures the process's structured logger: leveled text
+// or JSON output, request attributes and correlation IDs taken from the
+// context, and redaction of secrets and, optionally, transcripts.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/usage"
+)
+
+// KeyTranscript is the attribute key for speech transcripts and the commands
+// parsed from them, which Options.RedactTranscripts hides.
+const KeyTranscript = "transcript"
+
+// Options configures New.
+type Options struct {
+	Level             string   // debug, info, warn or error; empty means info
+	Format            string   // text or json; empty means text
+	RedactTranscripts bool     // replace transcript attributes with their length
+	Secrets           []string // values replaced wherever they appear, such as API keys
+}
+
+// New creates a logger writing to w. Every record gets the correlation ID,
+// guild, channel and user in its context, and is redacted before writing.
+func New(w io.Writer, opts Options) (*slog.Logger, error) {
+	var level slog.Level
+	if opts.Level != "" {
+		if err := level.UnmarshalText([]byte(opts.Level)); err != nil {
+			return nil, fmt.Errorf("invalid log level %q: %w", opts.Level, err)
+		}
+	}
+
+	r := newRedactor(opts.Secrets, opts.RedactTranscripts)
+	handlerOpts := &slog.HandlerOptions{Level: level, ReplaceAttr: r.replaceAttr}
+	var h slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case "", "text":
+		h = slog.NewTextHandler(w, handlerOpts)
+	case "json":
+		h = slog.NewJSONHandler(w, handlerOpts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q: want text or json", opts.Format)
+	}
+	return slog.New(contextHandler{h}), nil
+}
+
+type correlationKey struct{}
+
+// WithCorrelationID returns a context whose log records carry id.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationID returns the correlation ID stored in ctx, or "".
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// NewCorrelationID returns a random ID for following one utterance or
+// message through the logs.
+func NewCorrelationID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// contextHandler adds the correlation ID and attribution in a record's
+// context to the record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := CorrelationID(ctx); id != "" {
+		r.AddAttrs(slog.String("correlation_id", id))
+	}
+	a := usage.AttributionFrom(ctx)
+	for _, attr := range []slog.Attr{
+		slog.String("guild", a.GuildID),
+		slog.String("channel", a.ChannelID),
+		slog.String("user", a.UserID),
+	} {
+		if attr.Value.String() != "" {
+			r.AddAttrs(attr)
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/adrock-miles/go-laserbeak/internal/domain/usage"
+)
+
+func TestNew_AddsContextAndRedacts(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Options{
+		Format:            "json",
+		Level:             "debug",
+		RedactTranscripts: true,
+		Secrets:           []string{"discord-token-value"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithCorrelationID(context.Background(), "abc123")
+	ctx = usage.WithAttribution(ctx, usage.Attribution{GuildID: "g1", UserID: "u1"})
+	logger.DebugContext(ctx, "voice transcription",
+		KeyTranscript, "laser play wow",
+		"token", "anything",
+		"err", errors.New("401 from https://api.example/v1?api_key=hunter22 with Bearer sk-abcdefghijklmnopqrstuvwx"),
+		"detail", "session discord-token-value expired",
+	)
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("%v: %s", err, buf.String())
+	}
+	want := map[string]any{
+		"correlation_id": "abc123",
+		"guild":          "g1",
+		"user":           "u1",
+		KeyTranscript:    "[14 chars]",
+		"token":          redacted,
+		"detail":         "session [REDACTED] expired",
+	}
+	for k, v := range want {
+		if rec[k] != v {
+			t.Errorf("%s = %v, want %v", k, rec[k], v)
+		}
+	}
+	if _, ok := rec["channel"]; ok {
+		t.Error("empty channel attribution was logged")
+	}
+	if e := rec["err"].(string); strings.Contains(e, "hunter22") || strings.Contains(e, "sk-abc") {
+		t.Errorf("err not redacted: %s", e)
+	}
+}
+
+func TestNew_Level(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Options{Level: "warn"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("hidden")
+	logger.Warn("shown")
+	if out := buf.String(); strings.Contains(out, "hidden") || !strings.Contains(out, "shown") {
+		t.Errorf("output = %q, want only the warning", out)
+	}
+
+	if _, err := New(&buf, Options{Level: "loud"}); err == nil {
+		t.Error("accepted an invalid level")
+	}
+	if _, err := New(&buf, Options{Format: "xml"}); err == nil {
+		t.Error("accepted an invalid format")
+	}
+}
@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// secretKeys are attribute keys whose values are always redacted.
+var secretKeys = map[string]bool{
+	"token":         true,
+	"apikey":        true,
+	"api_key":       true,
+	"authorization": true,
+	"password":      true,
+	"secret":        true,
+}
+
+// secretPatterns find credentials embedded in messages and errors.
+var secretPatterns = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`(?i)\b(bearer|bot)\s+[A-Za-z0-9._~+/=-]{16,}`), "$1 " + redacted},
+	{regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{16,}`), redacted},
+	{regexp.MustCompile(`(?i)\b(api_?key|token|password|secret)=[^\s&"]+`), "$1=" + redacted},
+}
+
+// redactor scrubs secrets, and optionally transcripts, from log attributes.
+type redactor struct {
+	secrets     *strings.Replacer // nil without secrets
+	transcripts bool
+}
+
+func newRedactor(secrets []string, transcripts bool) *redactor {
+	r := &redactor{transcripts: transcripts}
+	var pairs []string
+	for _, s := range secrets {
+		if len(s) >= 8 { // shorter values would redact ordinary words
+			pairs = append(pairs, s, redacted)
+		}
+	}
+	if len(pairs) > 0 {
+		r.secrets = strings.NewReplacer(pairs...)
+	}
+	return r
+}
+
+// replaceAttr is the handlers' slog.HandlerOptions.ReplaceAttr.
+func (r *redactor) replaceAttr(_ []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	switch {
+	case secretKeys[key]:
+		return slog.String(a.Key, redacted)
+	case key == KeyTranscript && r.transcripts:
+		return slog.String(a.Key, fmt.Sprintf("[%d chars]", len(a.Value.String())))
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, r.scrub(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, r.scrub(err.Error()))
+		}
+	}
+	return a
+}
+
+// scrub replaces configured secrets and credential-like strings in s.
+func (r *redactor) scrub(s string) string {
+	if r.secrets != nil {
+		s = r.secrets.Replace(s)
+	}
+	for _, p := range secretPatterns {
+		s = p.re.ReplaceAllString(s, p.repl)
+	}
+	return s
+}